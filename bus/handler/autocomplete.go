@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/luke-goddard/taskninja/events"
+	"github.com/rs/zerolog/log"
+)
+
+func (handler *EventHandler) autocomplete(e *events.Autocomplete) []*events.Event {
+	var completions, err = handler.services.Autocomplete(e.Input, e.Cursor)
+	if err != nil {
+		log.Error().Err(err).Msg("error completing input")
+		return nil
+	}
+	var items = make([]events.Completion, 0, len(completions.Items))
+	for _, completion := range completions.Items {
+		items = append(items, events.Completion{Text: completion.Text, Description: completion.Description})
+	}
+	return []*events.Event{
+		events.NewAutocompleteResponseEvent(e.Input, completions.Start, completions.End, items),
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/luke-goddard/taskninja/events"
+	"github.com/rs/zerolog/log"
+)
+
+func (handler *EventHandler) taskDetails(e *events.TaskDetails) []*events.Event {
+	var task, err = handler.services.GetTaskDetailed(e.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("error getting the task details")
+		return []*events.Event{events.NewErrorEvent(err)}
+	}
+	if task == nil {
+		return []*events.Event{events.NewTaskDetailsResponseEvent(e.ID, nil, nil, nil)}
+	}
+	var annotations, annotationsErr = handler.services.ListTaskAnnotations(e.ID)
+	if annotationsErr != nil {
+		log.Error().Err(annotationsErr).Msg("error listing the task annotations")
+		return []*events.Event{events.NewErrorEvent(annotationsErr)}
+	}
+	var history, historyErr = handler.services.ListTaskHistory(e.ID)
+	if historyErr != nil {
+		log.Error().Err(historyErr).Msg("error listing the task history")
+		return []*events.Event{events.NewErrorEvent(historyErr)}
+	}
+	return []*events.Event{events.NewTaskDetailsResponseEvent(e.ID, task, annotations, history)}
+}
@@ -46,6 +46,10 @@ func (handler *EventHandler) handle(e *events.Event) []*events.Event {
 		return handler.decreasePriority(events.DecodeDecreasePriorityEvent(e))
 	case events.EventSetPriority:
 		return handler.setPriority(events.DecodeSetPriorityEvent(e))
+	case events.EventAutocomplete:
+		return handler.autocomplete(events.DecodeAutocompleteEvent(e))
+	case events.EventTaskDetails:
+		return handler.taskDetails(events.DecodeTaskDetailsEvent(e))
 	}
 	return nil
 }
@@ -12,6 +12,9 @@ func (handler *EventHandler) listTasks() []*events.Event {
 		var errorEvent = events.NewErrorEvent(err)
 		return []*events.Event{errorEvent}
 	}
+	if filter := handler.services.Filter(); filter != nil && filter.Report != "" {
+		return []*events.Event{events.NewListReportResponse(tasks, filter.Report, filter.Columns)}
+	}
 	var resp = events.NewListTasksResponse(tasks)
 	return []*events.Event{resp}
 }
@@ -2,15 +2,40 @@ package handler
 
 import (
 	"github.com/luke-goddard/taskninja/events"
+	"github.com/luke-goddard/taskninja/interpreter"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
 	"github.com/rs/zerolog/log"
 )
 
 func (handler *EventHandler) runProgram(e *events.RunProgram) []*events.Event {
-	var _, err = handler.services.RunProgram(e.Program)
+	if program, ok := interpreter.ParseExplain(e.Program); ok {
+		return handler.explainProgram(program)
+	}
+	var cmd, err = handler.services.RunProgram(e.Program)
 	if err != nil {
 		log.Error().Err(err).Msg("error running program")
 		var errorEvent = events.NewErrorEvent(err)
 		return []*events.Event{errorEvent}
 	}
-	return []*events.Event{events.NewListTasksEvent()}
+	var responses = []*events.Event{events.NewListTasksEvent()}
+	if id, ok := cmd.InfoTaskId(); ok {
+		responses = append(responses, events.NewOpenTaskDetailsEvent(id))
+	}
+	if cmd.Kind == ast.CommandKindTags {
+		responses = append(responses, events.NewListTagsResponseEvent(handler.services.Tags()))
+	}
+	if warnings := handler.services.Interprete.Diagnostics(); warnings != nil {
+		responses = append(responses, events.NewErrorEvent(warnings))
+	}
+	return responses
+}
+
+// explainProgram responds with what the program would do, nothing is changed
+func (handler *EventHandler) explainProgram(program string) []*events.Event {
+	var explanation, err = handler.services.ExplainProgram(program)
+	if explanation == nil {
+		log.Error().Err(err).Msg("error explaining program")
+		return []*events.Event{events.NewErrorEvent(err)}
+	}
+	return []*events.Event{events.NewExplainProgramEvent(explanation.String())}
 }
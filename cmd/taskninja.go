@@ -8,5 +8,5 @@ import (
 
 func main() {
 	var runner = core.NewRunner(os.Args)
-	runner.Run()
+	os.Exit(runner.Run())
 }
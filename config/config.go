@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/luke-goddard/taskninja/assert"
 	"github.com/rs/zerolog/log"
@@ -20,9 +21,42 @@ const (
 
 // Contains the SQL connection configuration
 type SqlConnectionConfig struct {
-	Mode       ConnectionMode `yaml:"mode"`       // in-memory, file, http
-	Path       string         `yaml:"connection"` // connection string
-	BackupPath string         `yaml:"backupPath"` // Location to backup the database (sqlite disk only)
+	Mode      ConnectionMode  `yaml:"mode"`       // in-memory, file, http
+	Path      string          `yaml:"connection"` // connection string
+	BackupDir string          `yaml:"backupDir"`  // Directory of the timestamped backups (sqlite disk only), see Backups
+	Retention BackupRetention `yaml:"retention"`  // How many backups are kept
+
+	// Deprecated: the file of the single backup kept by older releases, the backups are kept
+	// in its directory when BackupDir is not set, see Backups
+	BackupPath string `yaml:"backupPath"`
+}
+
+// BackupRetention is how many backups are kept, the most recent backup of each of the last
+// Daily days and of each of the last Weekly weeks is kept. The newest backup is always kept
+type BackupRetention struct {
+	Daily  int `yaml:"daily"`  // e.g 7 keeps a backup for each of the last 7 days with a backup
+	Weekly int `yaml:"weekly"` // e.g 4 keeps a backup for each of the last 4 weeks with a backup
+}
+
+// Interval returns how long after a backup the next backup is taken, the shortest period
+// that is retained e.g a day when daily backups are kept
+func (r BackupRetention) Interval() time.Duration {
+	if r.Daily == 0 && r.Weekly > 0 {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Backups returns the directory of the backups, defaults to the directory of the deprecated
+// BackupPath and then to the path of the database + ".backups"
+func (c *SqlConnectionConfig) Backups() string {
+	if c.BackupDir != "" {
+		return c.BackupDir
+	}
+	if c.BackupPath != "" {
+		return path.Dir(c.BackupPath)
+	}
+	return c.Path + ".backups"
 }
 
 // DSN returns the data source name for the connection e.g "sqlite://:memory:",
@@ -42,6 +76,10 @@ func (c *SqlConnectionConfig) DSN() string {
 type Config struct {
 	Connection SqlConnectionConfig `yaml:"connection"` // How to connect to the sqlite database
 	Log        Log                 `yaml:"log"`        // How to log
+	Aliases    map[string]string   `yaml:"aliases"`    // Command templates e.g today: list due:today
+	Reports    map[string]Report   `yaml:"reports"`    // Named task lists e.g report next, see DefaultReports
+	UDAs       map[string]UDA      `yaml:"udas"`       // User defined attributes e.g estimate:3
+	Format     string              `yaml:"format"`     // How the command line prints tasks e.g json, defaults to table
 }
 
 type ConfigErrorVariant string
@@ -102,6 +140,12 @@ func GetConfig() (*Config, *ConfigError) {
 		var err = fmt.Errorf("Failed to unmarshal config: %v", err)
 		return nil, &ConfigError{Err: err, Variant: ConfigErrorUnmarshal}
 	}
+	config.Reports = WithDefaultReports(config.Reports)
+	if config.Connection.BackupPath != "" && config.Connection.BackupDir == "" {
+		log.Warn().
+			Str("backupDir", config.Connection.Backups()).
+			Msg("connection.backupPath is deprecated, set connection.backupDir to the directory of the backups")
+	}
 	return &config, nil
 }
 
@@ -150,6 +194,8 @@ func Bootstrap() *Config {
 func setDefaults() {
 	viper.SetDefault("connection.mode", ConnectionModeInMemory)
 	viper.SetDefault("connection.path", "")
+	viper.SetDefault("connection.retention.daily", 7)
+	viper.SetDefault("connection.retention.weekly", 4)
 	viper.SetDefault("log.level", LogLevelInfo)
 	viper.SetDefault("log.mode", LogModePretty)
 	viper.SetDefault("log.path", DefaultLogPath)
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Report is a named task list e.g report next
+//
+//	reports:
+//	  home:
+//	    filter: +home -BLOCKED
+//	    sort: due+,urgency-
+//	    columns: [id, title, due, urgency]
+//	    limit: 10
+type Report struct {
+	Filter  string   `yaml:"filter"`  // Options of the list command e.g +home priority:high
+	Sort    string   `yaml:"sort"`    // e.g due+,urgency-, empty uses the default sort
+	Columns []string `yaml:"columns"` // Columns shown by the TUI e.g id, title, due, empty shows the default columns
+	Limit   int      `yaml:"limit"`   // The maximum number of tasks, 0 lists every task
+}
+
+// Program returns the list command that runs the report e.g list +home sort:due+ limit:10
+func (report Report) Program() string {
+	var parts = []string{"list"}
+	if filter := strings.TrimSpace(report.Filter); filter != "" {
+		parts = append(parts, filter)
+	}
+	if report.Sort != "" {
+		parts = append(parts, "sort:"+report.Sort)
+	}
+	if report.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("limit:%d", report.Limit))
+	}
+	return strings.Join(parts, " ")
+}
+
+// defaultReportNames is the order the built in reports are shown in
+var defaultReportNames = []string{"next", "active", "waiting", "overdue", "completed"}
+
+// DefaultReports returns the reports that are available without any configuration
+func DefaultReports() map[string]Report {
+	return map[string]Report{
+		"next": {
+			Filter: "-BLOCKED",
+			Sort:   "urgency-",
+			Limit:  25,
+		},
+		"active": {
+			Filter: "+ACTIVE",
+			Sort:   "urgency-",
+		},
+		"waiting": {
+			Filter: "+BLOCKED",
+			Sort:   "due+,urgency-",
+		},
+		"overdue": {
+			Filter: "+OVERDUE",
+			Sort:   "due+",
+		},
+		"completed": {
+			Filter:  "status:completed",
+			Sort:    "completed-",
+			Columns: []string{"id", "title", "project", "tags", "age"},
+		},
+	}
+}
+
+// WithDefaultReports adds the built in reports to the user's reports,
+// a report in the config replaces the built in report with the same name
+func WithDefaultReports(reports map[string]Report) map[string]Report {
+	var merged = DefaultReports()
+	for name, report := range reports {
+		merged[strings.ToLower(name)] = report
+	}
+	return merged
+}
+
+// ReportNames returns the name of every report, the built in reports come first
+// followed by the user's reports in alphabetical order
+func ReportNames(reports map[string]Report) []string {
+	var names = make([]string, 0, len(reports))
+	for _, name := range defaultReportNames {
+		if _, ok := reports[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var custom = make([]string, 0, len(reports))
+	for name := range reports {
+		if !slices.Contains(defaultReportNames, name) {
+			custom = append(custom, name)
+		}
+	}
+	slices.Sort(custom)
+	return append(names, custom...)
+}
@@ -0,0 +1,27 @@
+package config
+
+// UDAType is the type of the values of a user defined attribute
+type UDAType string
+
+const (
+	UDATypeString   UDAType = "string"   // Any text e.g ticket:OPS-12
+	UDATypeNumber   UDAType = "number"   // e.g estimate:3.5
+	UDATypeDate     UDAType = "date"     // A date expression e.g review:eow
+	UDATypeDuration UDAType = "duration" // e.g 90m, 2h or 3d
+)
+
+// UDA is a user defined attribute, a custom field that can be set on any task e.g
+//
+//	udas:
+//	  estimate:
+//	    type: number
+//	    urgency: 0.5
+//	  customer:
+//	    type: string
+//	    values: [acme, globex]
+type UDA struct {
+	Type    UDAType  `yaml:"type"`    // string, number, date or duration, defaults to string
+	Label   string   `yaml:"label"`   // Shown to the user e.g Estimate (hours)
+	Values  []string `yaml:"values"`  // The allowed values, empty allows any value
+	Urgency float64  `yaml:"urgency"` // Added to the urgency of tasks that have a value
+}
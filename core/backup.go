@@ -0,0 +1,150 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/output"
+	"github.com/rs/zerolog/log"
+)
+
+// BackupCommand lists and restores the backups of the database instead of running a program
+// e.g taskninja backup list
+const BackupCommand = "backup"
+
+const backupUsage = "Usage: taskninja backup list | restore <name>"
+
+// backupArgs returns the arguments after the backup command, false is returned when the
+// arguments are a program e.g taskninja list
+func backupArgs(args []string) ([]string, bool) {
+	if len(args) < 2 || args[1] != BackupCommand {
+		return nil, false
+	}
+	return args[2:], true
+}
+
+// BackupOnStart backs up the database before it is opened, the backups that are not kept
+// by the retention are removed. Nothing is backed up for an in-memory or new database, or
+// when the schema is current and the newest backup was taken within the interval of the
+// retention e.g a day. The database is always backed up before a migration is applied
+func BackupOnStart(conf *config.SqlConnectionConfig, now time.Time) error {
+	if conf.Mode != config.ConnectionModeFile {
+		return nil
+	}
+	if _, err := os.Stat(conf.Path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	var backups, err = db.ListBackups(conf.Backups())
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && now.Sub(backups[0].CreatedUtc) < conf.Retention.Interval() {
+		var pending int
+		pending, err = pendingMigrations(conf)
+		if err != nil {
+			return err
+		}
+		if pending == 0 {
+			log.Debug().Str("backup", backups[0].Path).Msg("Skipped the backup, the newest backup is recent")
+			return nil
+		}
+	}
+	var backup *db.Backup
+	backup, err = backupDatabase(conf, now)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("backup", backup.Path).Msg("Backed up the database")
+	var removed []db.Backup
+	removed, err = db.PruneBackups(conf.Backups(), conf.Retention)
+	if err != nil {
+		return err
+	}
+	log.Info().Int("removed", len(removed)).Msg("Pruned the backups")
+	return nil
+}
+
+// RunBackupCommand runs a command that lists or restores the backups e.g list or
+// restore taskninja-20240131T090000.000Z.db. The database must not be open
+func RunBackupCommand(
+	conf *config.SqlConnectionConfig,
+	args []string,
+	format output.Format,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	if conf.Mode != config.ConnectionModeFile {
+		fmt.Fprintln(stderr, "Backups need a file database, set the connection mode to file")
+		return ExitRejected
+	}
+	if len(args) == 2 && args[0] == "restore" {
+		return restoreBackup(conf, args[1], stdout, stderr)
+	}
+	if len(args) != 1 || args[0] != "list" {
+		fmt.Fprintln(stderr, backupUsage)
+		return ExitRejected
+	}
+	var backups, err = db.ListBackups(conf.Backups())
+	if err == nil {
+		err = output.Write(stdout, format, output.Backups(backups))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// restoreBackup replaces the database with the backup, the database is backed up first
+// so that the restore can be reverted
+func restoreBackup(conf *config.SqlConnectionConfig, name string, stdout io.Writer, stderr io.Writer) int {
+	var path = name
+	if !strings.ContainsRune(name, filepath.Separator) {
+		path = filepath.Join(conf.Backups(), name)
+	}
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(stderr, "The backup %s does not exist, see taskninja backup list\n", name)
+		return ExitRejected
+	}
+	if _, err := os.Stat(conf.Path); err == nil {
+		var current, err = backupDatabase(conf, time.Now())
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		fmt.Fprintf(stdout, "Backed up the current database to %s\n", current.Name())
+	}
+	if err := db.RestoreBackup(path, conf.Path); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	fmt.Fprintf(stdout, "Restored %s\n", filepath.Base(path))
+	return ExitOK
+}
+
+// pendingMigrations returns the number of migrations that will be applied when the database is opened
+func pendingMigrations(conf *config.SqlConnectionConfig) (int, error) {
+	var store, err = db.OpenStore(conf)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+	return store.PendingMigrations()
+}
+
+// backupDatabase writes a verified snapshot of the database to the backup directory
+func backupDatabase(conf *config.SqlConnectionConfig, now time.Time) (*db.Backup, error) {
+	var store, err = db.OpenStore(conf)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.CreateBackup(conf.Backups(), now)
+}
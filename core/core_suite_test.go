@@ -0,0 +1,302 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter"
+	"github.com/luke-goddard/taskninja/output"
+	"github.com/luke-goddard/taskninja/services"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestCore(t *testing.T) {
+	log.Logger = log.Output(zerolog.Nop())
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Core Suite")
+}
+
+var _ = Describe("Normalizing the command line arguments", func() {
+	DescribeTable("should rebuild the program",
+		func(args []string, expected string) {
+			Expect(normalizeArgs(append([]string{"taskninja"}, args...))).To(Equal(expected))
+		},
+		Entry("no arguments", []string{}, ``),
+		Entry("a single word", []string{"list", "+work"}, `list +work`),
+		Entry("a title with spaces", []string{"add", "fix build", "+ci"}, `add "fix build" +ci`),
+		Entry("a value with spaces", []string{"list", "project:the ci"}, `list project:"the ci"`),
+		Entry("a title with double quotes", []string{"add", `say "hi" now`}, `add 'say "hi" now'`),
+		Entry("a program in a single argument", []string{`add "fix build" +ci`}, `add "fix build" +ci`),
+	)
+})
+
+var _ = Describe("Reading the format flag", func() {
+	DescribeTable("should remove the flag from the arguments",
+		func(args []string, format string, remaining []string) {
+			var found, rest, err = formatFlag(args)
+			Expect(err).To(BeNil())
+			Expect(found).To(Equal(format))
+			Expect(rest).To(Equal(remaining))
+		},
+		Entry("no flag", []string{"taskninja", "list"}, "", []string{"taskninja", "list"}),
+		Entry("a separate value", []string{"taskninja", "list", "--format", "json"}, "json", []string{"taskninja", "list"}),
+		Entry("an equals value", []string{"taskninja", "--format=csv", "list"}, "csv", []string{"taskninja", "list"}),
+	)
+
+	It("should reject a flag without a value", func() {
+		var _, _, err = formatFlag([]string{"taskninja", "list", "--format"})
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Running a program without the TUI", func() {
+	var service *services.ServiceHandler
+	var stdout, stderr *bytes.Buffer
+
+	var run = func(program string) int {
+		stdout.Reset()
+		stderr.Reset()
+		return RunHeadless(service, program, output.FormatTable, stdout, stderr)
+	}
+
+	BeforeEach(func() {
+		var store = db.NewInMemoryStore()
+		service = services.NewServiceHandler(interpreter.NewInterpreter(store), store)
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	})
+
+	It("should print the task that was created", func() {
+		Expect(run(`add "fix build" +ci`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(Equal("Created task 1\n"))
+		Expect(stderr.String()).To(BeEmpty())
+	})
+
+	It("should print the tasks that were listed", func() {
+		Expect(run(`add "fix build" +ci`)).To(Equal(ExitOK))
+		Expect(run(`add "write docs" +work`)).To(Equal(ExitOK))
+		Expect(run(`list +work`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(ContainSubstring("write docs"))
+		Expect(stdout.String()).NotTo(ContainSubstring("fix build"))
+	})
+
+	It("should print the text that matched a search", func() {
+		Expect(run(`add "fix build" +ci`)).To(Equal(ExitOK))
+		Expect(run(`annotate 1 "the runner is out of disk"`)).To(Equal(ExitOK))
+		Expect(run(`search disk format:csv`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(HavePrefix("ID,Title,Status"))
+		Expect(stdout.String()).To(ContainSubstring(",Match\n"))
+		Expect(stdout.String()).To(ContainSubstring("the runner is out of disk"))
+	})
+
+	It("should print the tasks in the format of the format key", func() {
+		Expect(run(`add "fix build" +ci`)).To(Equal(ExitOK))
+		Expect(run(`list format:csv`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(HavePrefix("ID,Title,Status"))
+		Expect(run(`list format:yaml`)).To(Equal(ExitRejected))
+		Expect(stderr.String()).To(ContainSubstring("Unknown format yaml"))
+	})
+
+	It("should print the operations of other commands", func() {
+		Expect(run(`add "fix build"`)).To(Equal(ExitOK))
+		Expect(run(`annotate 1 "pinged vendor"`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(Equal("annotate task 1: pinged vendor\n"))
+	})
+
+	It("should print the tags with their task count", func() {
+		Expect(run(`add "fix build" +ci +work`)).To(Equal(ExitOK))
+		Expect(run(`add "write docs" +work`)).To(Equal(ExitOK))
+		Expect(run(`tags`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(Equal("Tag   Tasks\nci    1\nwork  2\n"))
+		stdout.Reset()
+		Expect(RunHeadless(service, `tags`, output.FormatJson, stdout, stderr)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(ContainSubstring(`"name": "work",
+    "count": 2`))
+	})
+
+	It("should print the info and history of a task", func() {
+		Expect(run(`add "fix build" +ci`)).To(Equal(ExitOK))
+		Expect(run(`modify 1 priority:high`)).To(Equal(ExitOK))
+		Expect(run(`info 1`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(MatchRegexp(`Title\s+fix build`))
+		Expect(stdout.String()).To(ContainSubstring(`priority: none -> high (modify 1 priority:high)`))
+		Expect(stderr.String()).To(BeEmpty())
+		Expect(run(`info 9`)).To(Equal(ExitRejected))
+		Expect(stderr.String()).To(ContainSubstring("Task 9 does not exist"))
+	})
+
+	It("should reject the info of a task deleted before it was read", func() {
+		Expect(writeInfo(service, 9, output.FormatTable, stdout, stderr)).To(Equal(ExitRejected))
+		Expect(stdout.String()).To(BeEmpty())
+		Expect(stderr.String()).To(Equal("Task 9 does not exist\n"))
+	})
+
+	It("should print the diagnostics of a rejected program", func() {
+		Expect(run(`add "fix build" priority:`)).To(Equal(ExitRejected))
+		Expect(stdout.String()).To(BeEmpty())
+		Expect(stderr.String()).To(ContainSubstring("^"))
+	})
+
+	It("should reject a task that does not exist", func() {
+		Expect(run(`start 9`)).To(Equal(ExitRejected))
+		Expect(stderr.String()).To(ContainSubstring("Task 9 does not exist"))
+	})
+
+	It("should explain a program without running it", func() {
+		Expect(run(`add "fix build" --dry-run`)).To(Equal(ExitOK))
+		Expect(stdout.String()).To(ContainSubstring("Rolled back, nothing was changed"))
+		Expect(run(`list`)).To(Equal(ExitOK))
+		Expect(stdout.String()).NotTo(ContainSubstring("fix build"))
+	})
+})
+
+var _ = Describe("Managing the database migrations", func() {
+	var store *db.Store
+	var stdout, stderr *bytes.Buffer
+
+	var run = func(args ...string) int {
+		stdout.Reset()
+		stderr.Reset()
+		return RunDatabaseCommand(store, args, output.FormatCsv, stdout, stderr)
+	}
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	})
+
+	It("should find the db command in the arguments", func() {
+		var args, ok = databaseArgs([]string{"taskninja", "db", "rollback", "2"})
+		Expect(ok).To(BeTrue())
+		Expect(args).To(Equal([]string{"rollback", "2"}))
+		_, ok = databaseArgs([]string{"taskninja", "list", "db"})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should roll back and migrate the database", func() {
+		var latest = db.Migrations[len(db.Migrations)-1]
+		Expect(run("rollback")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(Equal(fmt.Sprintf("Rolled back migration %d (%s)\n", latest.ID, latest.Name)))
+		Expect(run("status")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(ContainSubstring(fmt.Sprintf("%d,%s,pending,\n", latest.ID, latest.Name)))
+		Expect(run("migrate")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(Equal(fmt.Sprintf("Applied 1 migrations, the database is at version %d\n", latest.ID)))
+		Expect(run("migrate")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(HavePrefix("The database is up to date"))
+	})
+
+	DescribeTable("should reject invalid arguments",
+		func(args ...string) {
+			Expect(run(args...)).To(Equal(ExitRejected))
+			Expect(stderr.String()).NotTo(BeEmpty())
+		},
+		Entry("no command"),
+		Entry("an unknown command", "upgrade"),
+		Entry("an invalid number of steps", "rollback", "zero"),
+		Entry("a negative number of steps", "rollback", "-1"),
+	)
+})
+
+var _ = Describe("Backing up the database", func() {
+	var conf *config.SqlConnectionConfig
+	var stdout, stderr *bytes.Buffer
+	var now = time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC)
+
+	var run = func(args ...string) int {
+		stdout.Reset()
+		stderr.Reset()
+		return RunBackupCommand(conf, args, output.FormatCsv, stdout, stderr)
+	}
+
+	BeforeEach(func() {
+		var dir = GinkgoT().TempDir()
+		conf = &config.SqlConnectionConfig{
+			Mode:      config.ConnectionModeFile,
+			Path:      filepath.Join(dir, "taskninja.db"),
+			Retention: config.BackupRetention{Daily: 7, Weekly: 4},
+		}
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	})
+
+	It("should not back up a new database", func() {
+		Expect(BackupOnStart(conf, now)).To(Succeed())
+		Expect(conf.Backups()).NotTo(BeADirectory())
+	})
+
+	It("should list and restore the backups taken on start", func() {
+		var store, err = db.NewStore(conf)
+		Expect(err).To(BeNil())
+		store.Close()
+		Expect(BackupOnStart(conf, now)).To(Succeed())
+
+		Expect(run("list")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(HavePrefix("Name,Created,Size\ntaskninja-20240129T090000.000Z.db,2024-01-29 09:00:00,"))
+		Expect(run("restore", "taskninja-20240129T090000.000Z.db")).To(Equal(ExitOK))
+		Expect(stdout.String()).To(HavePrefix("Backed up the current database to taskninja-"))
+		Expect(stdout.String()).To(HaveSuffix("Restored taskninja-20240129T090000.000Z.db\n"))
+	})
+
+	It("should back up at most once in the interval of the retention", func() {
+		var store, err = db.NewStore(conf)
+		Expect(err).To(BeNil())
+		store.Close()
+		Expect(BackupOnStart(conf, now)).To(Succeed())
+		Expect(BackupOnStart(conf, now.Add(time.Hour))).To(Succeed())
+		var backups []db.Backup
+		backups, err = db.ListBackups(conf.Backups())
+		Expect(err).To(BeNil())
+		Expect(backups).To(HaveLen(1))
+
+		Expect(BackupOnStart(conf, now.Add(25*time.Hour))).To(Succeed())
+		backups, err = db.ListBackups(conf.Backups())
+		Expect(err).To(BeNil())
+		Expect(backups).To(HaveLen(2))
+	})
+
+	It("should back up a recently backed up database before migrating it", func() {
+		var store, err = db.NewStore(conf)
+		Expect(err).To(BeNil())
+		Expect(BackupOnStart(conf, now)).To(Succeed())
+		_, err = store.Rollback(1)
+		Expect(err).To(BeNil())
+		store.Close()
+
+		Expect(BackupOnStart(conf, now.Add(time.Hour))).To(Succeed())
+		var backups []db.Backup
+		backups, err = db.ListBackups(conf.Backups())
+		Expect(err).To(BeNil())
+		Expect(backups[0].CreatedUtc).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("should keep the backups in the directory of the deprecated backup path", func() {
+		var dir = filepath.Dir(conf.Path)
+		conf.BackupPath = filepath.Join(dir, "old", "taskninja.db.bk")
+		Expect(conf.Backups()).To(Equal(filepath.Join(dir, "old")))
+		conf.BackupDir = filepath.Join(dir, "new")
+		Expect(conf.Backups()).To(Equal(conf.BackupDir))
+	})
+
+	DescribeTable("should reject invalid arguments",
+		func(args ...string) {
+			Expect(run(args...)).To(Equal(ExitRejected))
+			Expect(stderr.String()).NotTo(BeEmpty())
+		},
+		Entry("no command"),
+		Entry("an unknown command", "create"),
+		Entry("a restore without a backup", "restore"),
+		Entry("a backup that does not exist", "restore", "taskninja-20240129T090000.000Z.db"),
+	)
+
+	It("should reject an in-memory database", func() {
+		conf.Mode = config.ConnectionModeInMemory
+		Expect(run("list")).To(Equal(ExitRejected))
+		Expect(BackupOnStart(conf, now)).To(Succeed())
+	})
+})
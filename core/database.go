@@ -0,0 +1,99 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/output"
+)
+
+// DatabaseCommand manages the schema of the database instead of running a program
+// e.g taskninja db status
+const DatabaseCommand = "db"
+
+const databaseUsage = "Usage: taskninja db migrate | rollback [steps] | status"
+
+// databaseArgs returns the arguments after the db command, false is returned when the
+// arguments are a program e.g taskninja list
+func databaseArgs(args []string) ([]string, bool) {
+	if len(args) < 2 || args[1] != DatabaseCommand {
+		return nil, false
+	}
+	return args[2:], true
+}
+
+// RunDatabaseCommand runs a command that manages the migrations of the store e.g migrate,
+// rollback 2 or status. The store must not have been migrated, see db.OpenStore
+func RunDatabaseCommand(
+	store *db.Store,
+	args []string,
+	format output.Format,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, databaseUsage)
+		return ExitRejected
+	}
+	switch args[0] {
+	case "migrate":
+		if len(args) != 1 {
+			break
+		}
+		var applied, err = store.Migrate()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		if applied == 0 {
+			fmt.Fprintf(stdout, "The database is up to date at version %d\n", store.SchemaVersion())
+			return ExitOK
+		}
+		fmt.Fprintf(stdout, "Applied %d migrations, the database is at version %d\n", applied, store.SchemaVersion())
+		return ExitOK
+	case "rollback":
+		if len(args) > 2 {
+			break
+		}
+		var steps = 1
+		if len(args) == 2 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintf(stderr, "Expected the number of migrations to roll back e.g 2, got %s\n", args[1])
+				return ExitRejected
+			}
+		}
+		var reverted, err = store.Rollback(steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(stdout, "Rolled back migration %d (%s)\n", migration.ID, migration.Name)
+		}
+		if errors.Is(err, db.ErrNothingToRollback) {
+			fmt.Fprintln(stderr, err)
+			return ExitRejected
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		return ExitOK
+	case "status":
+		if len(args) != 1 {
+			break
+		}
+		var statuses, err = store.MigrationStatuses()
+		if err == nil {
+			err = output.Write(stdout, format, output.Migrations(statuses))
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+	fmt.Fprintln(stderr, databaseUsage)
+	return ExitRejected
+}
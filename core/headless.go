@@ -0,0 +1,155 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/output"
+	"github.com/luke-goddard/taskninja/services"
+)
+
+// Exit codes returned by Run so that taskninja can be used in scripts
+const (
+	ExitOK       = 0 // The program ran e.g taskninja add "fix build" +ci
+	ExitRejected = 1 // The program was rejected e.g a syntax error or a task that does not exist
+	ExitFailure  = 2 // The program could not be run e.g the database could not be opened
+)
+
+// FormatFlag chooses how the tasks are printed e.g taskninja list --format json
+const FormatFlag = "--format"
+
+// RunHeadless runs the program without starting the TUI, the tasks listed by list and
+// report are written to stdout in the format and the diagnostics are written to stderr.
+// The format key of the program replaces the format e.g list +work format:json
+func RunHeadless(
+	service *services.ServiceHandler,
+	program string,
+	format output.Format,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	if explain, ok := interpreter.ParseExplain(program); ok {
+		var explanation, err = service.ExplainProgram(explain)
+		if explanation == nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		fmt.Fprintln(stdout, explanation.String())
+		if err != nil {
+			return ExitRejected
+		}
+		return ExitOK
+	}
+
+	var cmd, err = service.RunProgram(program)
+	var diagnostics *manager.Diagnostics
+	if errors.As(err, &diagnostics) {
+		fmt.Fprintln(stderr, diagnostics.Render())
+		return ExitRejected
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	if warnings := service.Interprete.Diagnostics(); warnings != nil {
+		fmt.Fprintln(stderr, warnings.Render())
+	}
+
+	if id, ok := cmd.InfoTaskId(); ok {
+		return writeInfo(service, id, format, stdout, stderr)
+	}
+	if cmd.Kind == ast.CommandKindTags {
+		if err = output.Write(stdout, format, output.Tags(service.Tags())); err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+	if !cmd.ListsTasks() {
+		if id := service.Interprete.CreatedTaskId(); id != 0 {
+			fmt.Fprintf(stdout, "Created task %d\n", id)
+			return ExitOK
+		}
+		for _, operation := range service.Interprete.Operations() {
+			fmt.Fprintln(stdout, operation)
+		}
+		return ExitOK
+	}
+	if chosen, _ := cmd.Format(); chosen != "" {
+		format = chosen
+	}
+	var tasks []db.TaskDetailed
+	tasks, err = service.ListTasks()
+	if err == nil {
+		err = output.Write(stdout, format, output.Tasks(tasks))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// writeInfo writes everything about the task e.g its notes and the changes made to it,
+// the program is rejected when the task was deleted before it could be read
+func writeInfo(
+	service *services.ServiceHandler,
+	id int64,
+	format output.Format,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	var task, err = service.GetTaskDetailed(id)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	if task == nil {
+		fmt.Fprintf(stderr, "Task %d does not exist\n", id)
+		return ExitRejected
+	}
+	var annotations []db.TaskAnnotation
+	annotations, err = service.ListTaskAnnotations(id)
+	var history []db.TaskChange
+	if err == nil {
+		history, err = service.ListTaskHistory(id)
+	}
+	if err == nil {
+		err = output.Write(stdout, format, output.Info(task, annotations, history))
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// formatFlag removes the --format flag from the arguments e.g --format json or
+// --format=json, an empty format is returned when the flag is not given
+func formatFlag(args []string) (string, []string, error) {
+	var format = ""
+	var remaining = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		var arg = args[i]
+		if value, found := strings.CutPrefix(arg, FormatFlag+"="); found {
+			format = value
+			continue
+		}
+		if arg != FormatFlag {
+			remaining = append(remaining, arg)
+			continue
+		}
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("Expected a format after %s e.g %s json", FormatFlag, FormatFlag)
+		}
+		format = args[i+1]
+		i++
+	}
+	return format, remaining, nil
+}
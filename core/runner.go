@@ -1,8 +1,10 @@
 package core
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/luke-goddard/taskninja/assert"
@@ -11,6 +13,7 @@ import (
 	"github.com/luke-goddard/taskninja/config"
 	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/interpreter"
+	"github.com/luke-goddard/taskninja/output"
 	"github.com/luke-goddard/taskninja/services"
 	"github.com/luke-goddard/taskninja/tui"
 	"github.com/rs/zerolog"
@@ -23,6 +26,10 @@ type Runner struct {
 	service     *services.ServiceHandler // service handler
 	handler     *handler.EventHandler // event handler
 	args        string // command line arguments
+	database    []string // set to the arguments of the db command, see DatabaseCommand
+	backup      []string // set to the arguments of the backup command, see BackupCommand
+	format      string // set by the --format flag, see FormatFlag
+	argsErr     error  // set when the command line arguments are invalid
 	config      *config.Config // configuration
 	interpreter *interpreter.Interpreter // interpreter
 	store       *db.Store // database store
@@ -30,30 +37,65 @@ type Runner struct {
 
 // NewRunner will create a new runner
 func NewRunner(args []string) *Runner {
+	var format, remaining, err = formatFlag(args)
+	var database, _ = databaseArgs(remaining)
+	var backup, _ = backupArgs(remaining)
 	return &Runner{
-		bus:  bus.NewBus(),
-		args: normalizeArgs(args),
+		bus:      bus.NewBus(),
+		args:     normalizeArgs(remaining),
+		database: database,
+		backup:   backup,
+		format:   format,
+		argsErr:  err,
 	}
 }
 
-// Run the application
-func (r *Runner) Run() {
+// Run the application, the TUI is started when there are no arguments otherwise the arguments
+// are run as a program e.g taskninja add "fix build" +ci. Returns the exit code, see ExitOK
+func (r *Runner) Run() int {
 	assert.NotNil(r.bus, "Bus is nil")
 	assert.NotNil(r.args, "Args is nil")
 
 	var err error
 	var store *db.Store
 	var program *tea.Program
+	var format output.Format
+
+	if r.argsErr != nil {
+		fmt.Fprintln(os.Stderr, r.argsErr)
+		return ExitRejected
+	}
 
 	r.loadConfigOrFail()
 	r.configDefaultLogger()
 	r.config.InitLogger()
 
-	err = db.BackupDatabase(r.config.Connection.Path, r.config.Connection.BackupPath)
+	if r.backup != nil {
+		if format, err = r.outputFormat(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitRejected
+		}
+		return RunBackupCommand(&r.config.Connection, r.backup, format, os.Stdout, os.Stderr)
+	}
+
+	err = BackupOnStart(&r.config.Connection, time.Now())
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to backup database")
 		log.Error().Msg("Halting program to prevent accidental data loss")
-		return
+		return ExitFailure
+	}
+
+	if r.database != nil {
+		if format, err = r.outputFormat(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitRejected
+		}
+		if store, err = db.OpenStore(&r.config.Connection); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitFailure
+		}
+		defer store.Close()
+		return RunDatabaseCommand(store, r.database, format, os.Stdout, os.Stderr)
 	}
 
 	store, err = db.NewStore(&r.config.Connection)
@@ -63,12 +105,36 @@ func (r *Runner) Run() {
 	assert.True(store.IsConnected(), "Store is not connected")
 
 	r.store = store
-	r.interpreter = interpreter.NewInterpreter(r.store)
+	r.interpreter = interpreter.NewInterpreter(r.store).
+		SetAliases(r.config.Aliases).
+		SetReports(r.config.Reports)
+	if err = r.interpreter.SetUDAs(r.config.UDAs); err != nil {
+		log.Error().Err(err).Msg("Invalid user defined attributes in the config")
+		fmt.Fprintf(os.Stderr, "Invalid user defined attributes in the config: %v\n", err)
+		return ExitFailure
+	}
 	r.service = services.NewServiceHandler(r.interpreter, r.store)
+
+	var created int
+	created, err = r.service.GenerateMissedRecurrences(time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to generate missed recurring tasks")
+	} else if created > 0 {
+		log.Info().Int("created", created).Msg("Generated missed recurring tasks")
+	}
+
+	if strings.TrimSpace(r.args) != "" {
+		if format, err = r.outputFormat(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitRejected
+		}
+		return RunHeadless(r.service, r.args, format, os.Stdout, os.Stderr)
+	}
+
 	r.handler = handler.NewEventHandler(r.service, r.bus)
 	r.bus.Subscribe(r.handler)
 
-	program, err = tui.NewTui(r.bus)
+	program, err = tui.NewTui(r.bus, r.interpreter.ReportNames())
 
 	assert.NoError(err, "Failed to create TUI")
 	assert.NotNil(program, "Program is nil")
@@ -80,7 +146,17 @@ func (r *Runner) Run() {
 	_, err = program.Run()
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to run program")
+		return ExitFailure
 	}
+	return ExitOK
+}
+
+// outputFormat returns the format chosen by the --format flag or the config
+func (r *Runner) outputFormat() (output.Format, error) {
+	if r.format != "" {
+		return output.ParseFormat(r.format)
+	}
+	return output.ParseFormat(r.config.Format)
 }
 
 func (r *Runner) configDefaultLogger() {
@@ -96,14 +172,43 @@ func (r *Runner) loadConfigOrFail() {
 	r.config = conf
 }
 
+// normalizeArgs joins the arguments after the executable into a program, the shell removes
+// the quotes so arguments with whitespace are quoted again e.g add "fix build" project:"the ci".
+// A single argument is already a program e.g taskninja 'add "fix build"'
 func normalizeArgs(args []string) string {
+	if len(args) == 2 {
+		return args[1]
+	}
 	var sb = strings.Builder{}
 	for i, arg := range args {
 		if i == 0 {
 			continue
 		}
-		sb.WriteString(" ")
-		sb.WriteString(arg)
+		if i > 1 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(quoteArg(arg))
 	}
 	return sb.String()
 }
+
+// quoteArg quotes the argument when it contains whitespace, only the value is quoted
+// for a key e.g project:the ci becomes project:"the ci"
+func quoteArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\n") {
+		return arg
+	}
+	var key, value, found = strings.Cut(arg, ":")
+	if !found || strings.ContainsAny(key, " \t\n\"'") {
+		key, value = "", arg
+	} else {
+		key += ":"
+	}
+	if !strings.Contains(value, `"`) {
+		return key + `"` + value + `"`
+	}
+	if !strings.Contains(value, "'") {
+		return key + "'" + value + "'"
+	}
+	return key + `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
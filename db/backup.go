@@ -0,0 +1,203 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/config"
+)
+
+const (
+	backupPrefix = "taskninja-"
+	backupSuffix = ".db"
+	backupLayout = "20060102T150405.000Z" // e.g taskninja-20240131T090000.000Z.db
+)
+
+// Backup is a snapshot of the database in the backup directory
+type Backup struct {
+	Path       string    // e.g ~/taskninja.db.backups/taskninja-20240131T090000.000Z.db
+	CreatedUtc time.Time // When the snapshot was taken
+	Size       int64     // The size of the file in bytes
+}
+
+// Name returns the file name of the backup, used to choose a backup to restore
+func (backup *Backup) Name() string {
+	return filepath.Base(backup.Path)
+}
+
+// CreateBackup writes a consistent snapshot of the database to a timestamped file in the
+// directory using VACUUM INTO, the snapshot is removed if it does not pass VerifyBackup
+func (store *Store) CreateBackup(dir string, now time.Time) (*Backup, error) {
+	var err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the backup directory: %w", err)
+	}
+	var created = now.UTC()
+	var path = filepath.Join(dir, backupPrefix+created.Format(backupLayout)+backupSuffix)
+	_, err = store.Con.Exec(`VACUUM INTO ?`, path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to backup the database to %s: %w", path, err)
+	}
+	err = VerifyBackup(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	var info os.FileInfo
+	info, err = os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the backup %s: %w", path, err)
+	}
+	return &Backup{Path: path, CreatedUtc: created, Size: info.Size()}, nil
+}
+
+// VerifyBackup checks the backup with PRAGMA integrity_check
+func VerifyBackup(path string) error {
+	var con, err = openReadOnly(path)
+	if err != nil {
+		return err
+	}
+	defer con.Close()
+	var problems = []string{}
+	err = con.Select(&problems, `PRAGMA integrity_check`)
+	if err != nil {
+		return fmt.Errorf("Failed to check the integrity of %s: %w", path, err)
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return fmt.Errorf("The backup %s is corrupt: %s", path, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// ListBackups returns the backups in the directory, the newest first.
+// A directory that does not exist has no backups
+func ListBackups(dir string) ([]Backup, error) {
+	var entries, err = os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the backups in %s: %w", dir, err)
+	}
+	var backups = []Backup{}
+	for _, entry := range entries {
+		var name = entry.Name()
+		var stamp, found = strings.CutPrefix(name, backupPrefix)
+		stamp, _ = strings.CutSuffix(stamp, backupSuffix)
+		if !found || !entry.Type().IsRegular() {
+			continue
+		}
+		var created time.Time
+		created, err = time.Parse(backupLayout, stamp)
+		if err != nil {
+			continue // Not written by CreateBackup
+		}
+		var info os.FileInfo
+		info, err = entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the backup %s: %w", name, err)
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), CreatedUtc: created, Size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedUtc.After(backups[j].CreatedUtc)
+	})
+	return backups, nil
+}
+
+// PruneBackups removes the backups that are not kept by the retention, returns the
+// backups that were removed
+func PruneBackups(dir string, retention config.BackupRetention) ([]Backup, error) {
+	var backups, err = ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed = []Backup{}
+	for i, keep := range retainedBackups(backups, retention) {
+		if keep {
+			continue
+		}
+		err = os.Remove(backups[i].Path)
+		if err != nil {
+			return removed, fmt.Errorf("Failed to remove the backup %s: %w", backups[i].Path, err)
+		}
+		removed = append(removed, backups[i])
+	}
+	return removed, nil
+}
+
+// retainedBackups returns whether each backup is kept, the backups must be the newest first
+func retainedBackups(backups []Backup, retention config.BackupRetention) []bool {
+	var keep = make([]bool, len(backups))
+	var days = map[string]bool{}
+	var weeks = map[string]bool{}
+	for i, backup := range backups {
+		keep[i] = i == 0
+		var day = backup.CreatedUtc.Format(time.DateOnly)
+		if !days[day] && len(days) < retention.Daily {
+			days[day] = true
+			keep[i] = true
+		}
+		var year, number = backup.CreatedUtc.ISOWeek()
+		var week = fmt.Sprintf("%d-%d", year, number)
+		if !weeks[week] && len(weeks) < retention.Weekly {
+			weeks[week] = true
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// RestoreBackup replaces the database at path with the backup, the backup is verified and
+// copied next to the database before it is moved into place. The database must be closed
+func RestoreBackup(backup string, path string) error {
+	var err = VerifyBackup(backup)
+	if err != nil {
+		return err
+	}
+	var restoring = path + ".restore"
+	err = os.Remove(restoring)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Failed to remove the previous restore %s: %w", restoring, err)
+	}
+	var con *sqlx.DB
+	con, err = openReadOnly(backup)
+	if err != nil {
+		return err
+	}
+	_, err = con.Exec(`VACUUM INTO ?`, restoring)
+	con.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to copy the backup %s: %w", backup, err)
+	}
+	// The journal of the replaced database would be applied to the backup
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		err = os.Remove(path + suffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Failed to remove the journal %s: %w", path+suffix, err)
+		}
+	}
+	err = os.Rename(restoring, path)
+	if err != nil {
+		return fmt.Errorf("Failed to replace the database with the backup: %w", err)
+	}
+	return nil
+}
+
+// openReadOnly opens the database file without creating it
+func openReadOnly(path string) (*sqlx.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("Failed to open the backup %s: %w", path, err)
+	}
+	var con, err = sqlx.Connect("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the backup %s: %w", path, err)
+	}
+	return con, nil
+}
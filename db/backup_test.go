@@ -0,0 +1,120 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/luke-goddard/taskninja/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backups", func() {
+	var dir, path string
+	var store *Store
+	var monday = time.Date(2024, 1, 29, 9, 0, 0, 0, time.UTC)
+
+	var titles = func(path string) []string {
+		var store, err = OpenStore(&config.SqlConnectionConfig{Mode: config.ConnectionModeFile, Path: path})
+		Expect(err).To(BeNil())
+		defer store.Close()
+		var titles = []string{}
+		Expect(store.Con.Select(&titles, `SELECT title FROM tasks ORDER BY id`)).To(Succeed())
+		return titles
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		path = filepath.Join(dir, "taskninja.db")
+		var err error
+		store, err = NewStore(&config.SqlConnectionConfig{Mode: config.ConnectionModeFile, Path: path})
+		Expect(err).To(BeNil())
+		_, err = store.Con.Exec(`INSERT INTO tasks (title) VALUES ('buy milk')`)
+		Expect(err).To(BeNil())
+		DeferCleanup(store.Close)
+	})
+
+	It("should write a verified snapshot", func() {
+		var backup, err = store.CreateBackup(filepath.Join(dir, "backups"), monday)
+		Expect(err).To(BeNil())
+		Expect(backup.Name()).To(Equal("taskninja-20240129T090000.000Z.db"))
+		Expect(backup.Size).To(BeNumerically(">", 0))
+		Expect(VerifyBackup(backup.Path)).To(Succeed())
+		Expect(titles(backup.Path)).To(Equal([]string{"buy milk"}))
+	})
+
+	It("should reject a corrupt backup", func() {
+		var corrupt = filepath.Join(dir, "taskninja-20240129T090000.000Z.db")
+		Expect(os.WriteFile(corrupt, []byte("not a database"), 0600)).To(Succeed())
+		Expect(VerifyBackup(corrupt)).NotTo(Succeed())
+		Expect(VerifyBackup(filepath.Join(dir, "missing.db"))).NotTo(Succeed())
+	})
+
+	It("should list the backups with the newest first", func() {
+		var backups, err = ListBackups(filepath.Join(dir, "backups"))
+		Expect(err).To(BeNil())
+		Expect(backups).To(BeEmpty())
+
+		for _, offset := range []time.Duration{0, 2 * time.Hour, time.Hour} {
+			_, err = store.CreateBackup(filepath.Join(dir, "backups"), monday.Add(offset))
+			Expect(err).To(BeNil())
+		}
+		Expect(os.WriteFile(filepath.Join(dir, "backups", "notes.txt"), nil, 0600)).To(Succeed())
+		backups, err = ListBackups(filepath.Join(dir, "backups"))
+		Expect(err).To(BeNil())
+		Expect(backups).To(HaveLen(3))
+		Expect(backups[0].CreatedUtc).To(Equal(monday.Add(2 * time.Hour)))
+		Expect(backups[2].CreatedUtc).To(Equal(monday))
+	})
+
+	It("should keep the newest backup of each day and week", func() {
+		var backups = filepath.Join(dir, "backups")
+		// Two backups a day for three weeks
+		for day := 0; day < 21; day++ {
+			for _, hour := range []time.Duration{0, time.Hour} {
+				var _, err = store.CreateBackup(backups, monday.AddDate(0, 0, -day).Add(hour))
+				Expect(err).To(BeNil())
+			}
+		}
+		var removed, err = PruneBackups(backups, config.BackupRetention{Daily: 3, Weekly: 3})
+		Expect(err).To(BeNil())
+		Expect(removed).To(HaveLen(42 - 4))
+
+		var kept []Backup
+		kept, err = ListBackups(backups)
+		Expect(err).To(BeNil())
+		var created = []time.Time{}
+		for _, backup := range kept {
+			created = append(created, backup.CreatedUtc)
+		}
+		Expect(created).To(Equal([]time.Time{
+			monday.Add(time.Hour),                   // Monday, the newest week
+			monday.AddDate(0, 0, -1).Add(time.Hour), // Sunday, the week before
+			monday.AddDate(0, 0, -2).Add(time.Hour), // Saturday, the last daily backup
+			monday.AddDate(0, 0, -8).Add(time.Hour), // Sunday, two weeks before
+		}))
+	})
+
+	It("should always keep the newest backup", func() {
+		var backups = filepath.Join(dir, "backups")
+		var _, err = store.CreateBackup(backups, monday)
+		Expect(err).To(BeNil())
+		var removed []Backup
+		removed, err = PruneBackups(backups, config.BackupRetention{})
+		Expect(err).To(BeNil())
+		Expect(removed).To(BeEmpty())
+	})
+
+	It("should restore a backup over the database", func() {
+		var backup, err = store.CreateBackup(filepath.Join(dir, "backups"), monday)
+		Expect(err).To(BeNil())
+		_, err = store.Con.Exec(`INSERT INTO tasks (title) VALUES ('file taxes')`)
+		Expect(err).To(BeNil())
+		Expect(titles(path)).To(HaveLen(2))
+
+		Expect(RestoreBackup(backup.Path, path)).To(Succeed())
+		Expect(titles(path)).To(Equal([]string{"buy milk"}))
+		Expect(filepath.Join(dir, "taskninja.db.restore")).NotTo(BeAnExistingFile())
+	})
+})
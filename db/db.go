@@ -1,10 +1,7 @@
 package db
 
 import (
-	"errors"
 	"fmt"
-	"io"
-	"os"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/luke-goddard/taskninja/assert"
@@ -15,12 +12,13 @@ import (
 
 // Store is a wrapper around the database connection
 type Store struct {
-	Con *sqlx.DB // The database connection
+	Con  *sqlx.DB              // The database connection
+	udas map[string]config.UDA // User defined attributes, see SetUDAs
 }
 
 // NewInMemoryStore creates a new in-memory store, useful for testing
 func NewInMemoryStore() *Store {
-	var con, err = sqlx.Connect("sqlite3", ":memory:")
+	var con, err = sqlx.Connect(driverName, ":memory:")
 	assert.Nil(err, "failed to connect to in-memory database")
 	var store = &Store{Con: con}
 	err = store.RunMigrations()
@@ -28,15 +26,12 @@ func NewInMemoryStore() *Store {
 	return store
 }
 
-// NewStore creates a new store with the given configuration
+// NewStore creates a new store with the given configuration and applies the migrations
 func NewStore(conf *config.SqlConnectionConfig) (*Store, error) {
-	var dsn = conf.DSN()
-	log.Debug().Str("dsn", dsn).Msg("connecting to database")
-	var con, err = sqlx.Connect("sqlite3", dsn)
+	var store, err = OpenStore(conf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
-	var store = &Store{Con: con}
 	err = store.RunMigrations()
 	if err != nil {
 		log.Error().Err(err).Msg("failed to run migrations")
@@ -45,6 +40,18 @@ func NewStore(conf *config.SqlConnectionConfig) (*Store, error) {
 	return store, nil
 }
 
+// OpenStore connects to the database without applying the migrations,
+// used by the commands that manage the migrations e.g taskninja db status
+func OpenStore(conf *config.SqlConnectionConfig) (*Store, error) {
+	var dsn = conf.DSN()
+	log.Debug().Str("dsn", dsn).Msg("connecting to database")
+	var con, err = sqlx.Connect(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return &Store{Con: con}, nil
+}
+
 // Close the database connection
 func (store *Store) Close() {
 	assert.True(store.IsConnected(), "store is not connected")
@@ -59,44 +66,6 @@ func (store *Store) IsConnected() bool {
 	return store.Con != nil
 }
 
-// BackupDatabase copies the SQLite database file from input to output.
-// This will overwrite any previous backups
-func BackupDatabase(input, output string) error {
-
-	if _, err := os.Stat(input); errors.Is(err, os.ErrNotExist) {
-		// Nothing to backup
-		return nil
-	}
-
-	var sourceFileStat, err = os.Stat(input)
-	if err != nil {
-		return err
-	}
-
-	if !sourceFileStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file", input)
-	}
-
-	source, err := os.Open(input)
-	if err != nil {
-		return err
-	}
-	defer source.Close()
-
-	var destination = output
-	if destination == "" {
-		destination = input + ".bk"
-	}
-	var bk *os.File
-	bk, err = os.Create(destination)
-	if err != nil {
-		return err
-	}
-	defer bk.Close()
-	_, err = io.Copy(bk, source)
-	return err
-}
-
 func (store *Store) MustCreateTxTodo() *sqlx.Tx {
 	tx, err := store.Con.Beginx()
 	assert.Nil(err, "failed to start transaction")
@@ -1,60 +1,337 @@
 package db
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 
-	"github.com/luke-goddard/taskninja/assert"
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/zerolog/log"
 )
 
-// Migrations contains all the migrations that need to be run.
-// Each migration is a SQL statement. The migrations are run in order.
-var Migrations = []string{
-	M000_TaskSchema,
-	M001_TagSchema,
-	M002_TaskTagsSchema,
-	M003_TaskSchema,
-	M004_TaskSchema,
-	M005_TaskSchema,
-	M006_ProjectSchema,
-	M007_TaskProjectsSchema,
-	M008_TimeTrackingSchema,
-	M009_TaskSchema,
-	M010_TaskDependenciesSchema,
-	M011_TaskSchema,
-	"PRAGMA foreign_keys = ON",
-}
-
-// RunMigrations runs all migrations that have not been run.
-// The PRAGMA user_version is used to determine the current schema version.
-// If the schema version is less than the migration index, the migration is run.
-// If the schema version is greater than the migration index,
-// the migration is skipped.
+const schemaMigrationsSchema = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	appliedAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+`
+
+// ErrNothingToRollback is returned by Rollback when no migration has been applied
+var ErrNothingToRollback = errors.New("No migration has been applied")
+
+// Migration is a change to the schema, the ID of each migration is one more than the migration
+// before it. The checksum is recorded when the migration is applied, so that a migration that
+// is changed afterwards is reported rather than silently skipped
+type Migration struct {
+	ID       int                     // e.g 7, applied in ascending order
+	Name     string                  // e.g task projects
+	Checksum string                  // Hash of the changes made by Up
+	Up       func(tx *sqlx.Tx) error // Applies the change
+	Down     func(tx *sqlx.Tx) error // Reverts the change
+}
+
+// sqlMigration creates a migration that runs the up and down statements,
+// the checksum is the hash of the up statements
+func sqlMigration(id int, name string, up string, down string) Migration {
+	var sum = sha256.Sum256([]byte(up))
+	return Migration{
+		ID:       id,
+		Name:     name,
+		Checksum: hex.EncodeToString(sum[:]),
+		Up: func(tx *sqlx.Tx) error {
+			var _, err = tx.Exec(up)
+			return err
+		},
+		Down: func(tx *sqlx.Tx) error {
+			var _, err = tx.Exec(down)
+			return err
+		},
+	}
+}
+
+// Migrations contains every migration in the order they are applied.
+// NEVER change a migration that has been released, add a new one instead
+var Migrations = []Migration{
+	sqlMigration(0, "tasks", M000_TaskSchema, M000_TaskSchemaDown),
+	sqlMigration(1, "tags", M001_TagSchema, M001_TagSchemaDown),
+	sqlMigration(2, "task tags", M002_TaskTagsSchema, M002_TaskTagsSchemaDown),
+	sqlMigration(3, "task started at", M003_TaskSchema, M003_TaskSchemaDown),
+	sqlMigration(4, "task state", M004_TaskSchema, M004_TaskSchemaDown),
+	sqlMigration(5, "drop task completed", M005_TaskSchema, M005_TaskSchemaDown),
+	sqlMigration(6, "projects", M006_ProjectSchema, M006_ProjectSchemaDown),
+	sqlMigration(7, "task projects", M007_TaskProjectsSchema, M007_TaskProjectsSchemaDown),
+	sqlMigration(8, "time tracking", M008_TimeTrackingSchema, M008_TimeTrackingSchemaDown),
+	sqlMigration(9, "drop task started at", M009_TaskSchema, M009_TaskSchemaDown),
+	sqlMigration(10, "task dependencies", M010_TaskDependenciesSchema, M010_TaskDependenciesSchemaDown),
+	sqlMigration(11, "task next", M011_TaskSchema, M011_TaskSchemaDown),
+	sqlMigration(12, "task templates", M012_TaskTemplateSchema, M012_TaskTemplateSchemaDown),
+	sqlMigration(13, "task attributes", M013_TaskAttributesSchema, M013_TaskAttributesSchemaDown),
+	sqlMigration(14, "undo journal", M014_UndoJournalSchema, M014_UndoJournalSchemaDown),
+	sqlMigration(15, "task annotations", M015_TaskAnnotationsSchema, M015_TaskAnnotationsSchemaDown),
+	searchMigration(16),
+	sqlMigration(17, "task history", M017_TaskHistorySchema, M017_TaskHistorySchemaDown),
+}
+
+// MigrationStatus is a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration  *Migration
+	Applied    bool   // True once Up has been committed
+	AppliedUtc string // When the migration was applied, empty if it has not been
+	Changed    bool   // True if the migration was changed after it was applied
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	ID         int    `db:"id"`
+	Name       string `db:"name"`
+	Checksum   string `db:"checksum"`
+	AppliedUtc string `db:"appliedAtUtc"`
+}
+
+// RunMigrations applies every migration that has not been applied, see Migrate
 func (store *Store) RunMigrations() error {
-	var version = store.SchemaVersion()
-	for i, migration := range Migrations {
-		if i <= version && version != 0 {
-			continue
+	var _, err = store.Migrate()
+	return err
+}
+
+// Migrate applies every migration that has not been applied in order, each migration is applied
+// in its own transaction. A database created before the schema_migrations table existed is
+// adopted using its PRAGMA user_version. Returns the number of migrations that were applied
+func (store *Store) Migrate() (int, error) {
+	var applied = 0
+	var err = store.withMigrationConn(func(conn *sqlx.Conn) error {
+		var done, err = store.appliedMigrations(conn)
+		if err != nil {
+			return err
 		}
-		_, err := store.Con.Exec(migration)
+		for i := range Migrations {
+			var migration = &Migrations[i]
+			if previous, ok := done[migration.ID]; ok {
+				if previous.Checksum != migration.Checksum {
+					return fmt.Errorf("Migration %d (%s) was changed after it was applied", migration.ID, migration.Name)
+				}
+				continue
+			}
+			err = migrateTx(conn, migration, migration.Up, func(tx *sqlx.Tx) error {
+				var _, err = tx.Exec(
+					`INSERT INTO schema_migrations (id, name, checksum) VALUES (?, ?, ?)`,
+					migration.ID, migration.Name, migration.Checksum,
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("Failed to apply migration %d (%s): %w", migration.ID, migration.Name, err)
+			}
+			log.Info().Int("id", migration.ID).Str("name", migration.Name).Msg("applied migration")
+			applied++
+		}
+		return createMissingSearchIndex(conn)
+	})
+	return applied, err
+}
+
+// Rollback reverts the most recently applied migrations, each migration is reverted in its
+// own transaction. Returns the migrations that were reverted, the most recent first
+func (store *Store) Rollback(steps int) ([]*Migration, error) {
+	var reverted = []*Migration{}
+	var err = store.withMigrationConn(func(conn *sqlx.Conn) error {
+		var done, err = store.appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			return ErrNothingToRollback
+		}
+		for i := len(Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			var migration = &Migrations[i]
+			if _, ok := done[migration.ID]; !ok {
+				continue
+			}
+			err = migrateTx(conn, migration, migration.Down, func(tx *sqlx.Tx) error {
+				var _, err = tx.Exec(`DELETE FROM schema_migrations WHERE id = ?`, migration.ID)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("Failed to roll back migration %d (%s): %w", migration.ID, migration.Name, err)
+			}
+			log.Info().Int("id", migration.ID).Str("name", migration.Name).Msg("rolled back migration")
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatuses returns every migration and whether it has been applied
+func (store *Store) MigrationStatuses() ([]MigrationStatus, error) {
+	var statuses = make([]MigrationStatus, 0, len(Migrations))
+	var err = store.withMigrationConn(func(conn *sqlx.Conn) error {
+		var done, err = store.appliedMigrations(conn)
 		if err != nil {
-			log.Error().Err(err).Msg(migration)
-			return fmt.Errorf("failed to run migration (%d): %w", i, err)
+			return err
+		}
+		for i := range Migrations {
+			var migration = &Migrations[i]
+			var previous, ok = done[migration.ID]
+			statuses = append(statuses, MigrationStatus{
+				Migration:  migration,
+				Applied:    ok,
+				AppliedUtc: previous.AppliedUtc,
+				Changed:    ok && previous.Checksum != migration.Checksum,
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// PendingMigrations returns the number of migrations that have not been applied
+func (store *Store) PendingMigrations() (int, error) {
+	var statuses, err = store.MigrationStatuses()
+	if err != nil {
+		return 0, err
+	}
+	var pending = 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
 		}
 	}
-	return nil
+	return pending, nil
 }
 
-// SchemaVersion returns the current schema version.
-// The schema version is stored in the PRAGMA user_version.
+// SchemaVersion returns the ID of the most recently applied migration, -1 if there is none
 func (store *Store) SchemaVersion() int {
-	var row, err = store.Con.Query("PRAGMA user_version")
-	assert.Nil(err, "failed to get schema version")
-	var version int
-	row.Next()
-	err = row.Scan(&version)
-	row.Close()
-	assert.Nil(err, "failed to scan schema version")
-	log.Debug().Int("version", version).Msg("schema version")
+	var version = -1
+	var err = store.Con.Get(&version, `SELECT COALESCE(MAX(id), -1) FROM schema_migrations`)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get the schema version")
+		return -1
+	}
 	return version
 }
+
+// withMigrationConn runs fn on a single connection with the foreign keys turned off, so that a
+// migration can rebuild a table without cascading to its children. The foreign keys are
+// turned back on afterwards, see migrateTx for how violations are caught
+func (store *Store) withMigrationConn(fn func(conn *sqlx.Conn) error) error {
+	var ctx = context.Background()
+	var conn, err = store.Con.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get a connection to migrate the database: %w", err)
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`)
+	if err != nil {
+		return fmt.Errorf("Failed to turn off the foreign keys: %w", err)
+	}
+	var fnErr = fn(conn)
+	_, err = conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	if err != nil && fnErr == nil {
+		return fmt.Errorf("Failed to turn on the foreign keys: %w", err)
+	}
+	return fnErr
+}
+
+// migrateTx runs the change and records it in one transaction, the transaction is rolled
+// back if the change leaves a row that violates a foreign key
+func migrateTx(conn *sqlx.Conn, migration *Migration, change func(tx *sqlx.Tx) error, record func(tx *sqlx.Tx) error) error {
+	var tx, err = conn.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = change(tx); err != nil {
+		return err
+	}
+	if err = record(tx); err != nil {
+		return err
+	}
+	var violations = 0
+	err = tx.Get(&violations, `SELECT COUNT(*) FROM pragma_foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d rows would violate a foreign key", violations)
+	}
+	// Kept for tools that read the version of the schema from the header
+	_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, migration.ID))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedMigrations creates the schema_migrations table if it does not exist and returns the
+// migrations that have been applied by ID
+func (store *Store) appliedMigrations(conn *sqlx.Conn) (map[int]appliedMigration, error) {
+	var ctx = context.Background()
+	var exists = 0
+	var err = conn.GetContext(ctx, &exists, `
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the schema_migrations table: %w", err)
+	}
+	if exists == 0 {
+		err = adoptLegacySchema(conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rows = []appliedMigration{}
+	err = conn.SelectContext(ctx, &rows, `SELECT * FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the schema_migrations table: %w", err)
+	}
+	var applied = make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row
+	}
+	return applied, nil
+}
+
+// adoptLegacySchema creates the schema_migrations table. Databases created before the table
+// existed recorded the last migration in PRAGMA user_version, every migration up to and
+// including it is recorded as applied. A new database does not have a tasks table
+func adoptLegacySchema(conn *sqlx.Conn) error {
+	var ctx = context.Background()
+	var tx, err = conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(schemaMigrationsSchema)
+	if err != nil {
+		return fmt.Errorf("Failed to create the schema_migrations table: %w", err)
+	}
+	var legacy, version = 0, 0
+	err = tx.Get(&legacy, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks'`)
+	if err == nil {
+		err = tx.Get(&version, `PRAGMA user_version`)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read the legacy schema version: %w", err)
+	}
+	for i := range Migrations {
+		var migration = &Migrations[i]
+		if legacy == 0 || migration.ID > version {
+			break
+		}
+		_, err = tx.Exec(
+			`INSERT INTO schema_migrations (id, name, checksum) VALUES (?, ?, ?)`,
+			migration.ID, migration.Name, migration.Checksum,
+		)
+		if err != nil {
+			return fmt.Errorf("Failed to adopt migration %d: %w", migration.ID, err)
+		}
+	}
+	if legacy != 0 {
+		log.Info().Int("version", version).Msg("adopted the legacy schema version")
+	}
+	return tx.Commit()
+}
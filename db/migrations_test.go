@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrations", func() {
+	var ctx = context.Background()
+
+	// fixture loads a database that was created by an older release of taskninja
+	var fixture = func(name string) *Store {
+		var con, err = sqlx.Connect("sqlite3", ":memory:")
+		Expect(err).To(BeNil())
+		con.SetMaxOpenConns(1)
+		var sql []byte
+		sql, err = os.ReadFile("testdata/" + name)
+		Expect(err).To(BeNil())
+		_, err = con.Exec(string(sql))
+		Expect(err).To(BeNil())
+		return &Store{Con: con}
+	}
+
+	var allTasks = func(store *Store) []TaskDetailed {
+		var tasks, err = store.ListTasksQuery(ctx, &TaskQuery{
+			Sort:             []TaskSortKey{{Field: "id"}},
+			IncludeCompleted: true,
+		})
+		Expect(err).To(BeNil())
+		return tasks
+	}
+
+	It("should give every migration the next ID", func() {
+		for i, migration := range Migrations {
+			Expect(migration.ID).To(Equal(i))
+			Expect(migration.Checksum).To(HaveLen(64))
+		}
+	})
+
+	It("should not change a released migration", func() {
+		var golden, err = os.ReadFile("testdata/migrations.golden")
+		Expect(err).To(BeNil())
+		var released = make([]string, 0, len(Migrations))
+		for _, line := range strings.Split(string(golden), "\n") {
+			if line != "" && !strings.HasPrefix(line, "#") {
+				released = append(released, line)
+			}
+		}
+		Expect(len(Migrations)).To(BeNumerically(">=", len(released)))
+		for i, line := range released {
+			var migration = Migrations[i]
+			var current = fmt.Sprintf("%d %s %s", migration.ID, migration.Checksum, migration.Name)
+			Expect(current).To(Equal(line), "migration %d was changed after it was released, add a new migration instead", i)
+		}
+		Expect(len(released)).To(Equal(len(Migrations)), "add the checksum of the new migration to testdata/migrations.golden")
+	})
+
+	It("should apply every migration to a new database", func() {
+		var store = NewInMemoryStore()
+		Expect(store.SchemaVersion()).To(Equal(len(Migrations) - 1))
+		var applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(0))
+	})
+
+	It("should migrate a version 2 database forward", func() {
+		var store = fixture("legacy_v2.sql")
+		var applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(len(Migrations) - 3))
+		Expect(store.SchemaVersion()).To(Equal(len(Migrations) - 1))
+
+		var tasks = allTasks(store)
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].Title).To(Equal("buy milk"))
+		Expect(tasks[0].TagNames.String).To(Equal("home"))
+		Expect(tasks[1].Title).To(Equal("file taxes"))
+		Expect(tasks[1].TagNames.String).To(Equal("money"))
+	})
+
+	It("should migrate a version 11 database forward", func() {
+		var store = fixture("legacy_v11.sql")
+		var applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(len(Migrations) - 12))
+
+		var tasks = allTasks(store)
+		Expect(tasks).To(HaveLen(3))
+		Expect(tasks[2].Title).To(Equal("paint fence"))
+		Expect(tasks[2].ProjectNames.String).To(Equal("house"))
+		Expect(tasks[2].Dependencies.String).To(Equal("1"))
+		Expect(tasks[2].Inprogress).To(BeTrue())
+		Expect(tasks[0].Blocking).To(Equal(1))
+
+		var tx = store.MustCreateTxTodo()
+		defer tx.Rollback()
+		Expect(store.SetTaskAttributeTx(tx, 1, "estimate", "2")).To(Succeed())
+		Expect(store.AnnotateTaskTx(tx, 1, "pinged vendor")).To(BeTrue())
+	})
+
+	It("should adopt a version 15 database without changing its data", func() {
+		var store = fixture("legacy_v15.sql")
+		var applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(len(Migrations) - 16))
+
+		var tasks = allTasks(store)
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].AttributeValues()).To(HaveKeyWithValue("estimate", "3"))
+		Expect(tasks[0].AnnotationCount).To(Equal(1))
+
+		var operations []UndoOperation
+		operations, err = store.ListUndoOperations(ctx)
+		Expect(err).To(BeNil())
+		Expect(operations).To(HaveLen(1))
+	})
+
+	It("should roll back every migration and migrate forward again", func() {
+		var store = fixture("legacy_v15.sql")
+		var _, err = store.Migrate()
+		Expect(err).To(BeNil())
+		var reverted []*Migration
+		reverted, err = store.Rollback(len(Migrations))
+		Expect(err).To(BeNil())
+		Expect(reverted).To(HaveLen(len(Migrations)))
+		Expect(reverted[0].ID).To(Equal(len(Migrations) - 1))
+		Expect(store.SchemaVersion()).To(Equal(-1))
+
+		_, err = store.Rollback(1)
+		Expect(err).To(MatchError(ErrNothingToRollback))
+
+		var applied int
+		applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(len(Migrations)))
+		Expect(allTasks(store)).To(BeEmpty())
+	})
+
+	It("should keep the data when rolling back the templates", func() {
+		var store = fixture("legacy_v15.sql")
+		var _, err = store.Rollback(4)
+		Expect(err).To(BeNil())
+		Expect(store.SchemaVersion()).To(Equal(11))
+
+		var titles []string
+		err = store.Con.Select(&titles, `SELECT title FROM tasks ORDER BY id`)
+		Expect(err).To(BeNil())
+		Expect(titles).To(Equal([]string{"buy milk", "file taxes"}))
+
+		_, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(allTasks(store)[0].TagNames.String).To(Equal("home"))
+	})
+
+	It("should report the migrations that have been applied", func() {
+		var store = fixture("legacy_v11.sql")
+		var statuses, err = store.MigrationStatuses()
+		Expect(err).To(BeNil())
+		Expect(statuses).To(HaveLen(len(Migrations)))
+		Expect(statuses[11].Applied).To(BeTrue())
+		Expect(statuses[11].AppliedUtc).ToNot(BeEmpty())
+		Expect(statuses[12].Applied).To(BeFalse())
+		Expect(statuses[12].AppliedUtc).To(BeEmpty())
+	})
+
+	It("should reject a migration that was changed after it was applied", func() {
+		var store = NewInMemoryStore()
+		var _, err = store.Con.Exec(`UPDATE schema_migrations SET checksum = 'changed' WHERE id = 3`)
+		Expect(err).To(BeNil())
+
+		_, err = store.Migrate()
+		Expect(err).To(MatchError(ContainSubstring("Migration 3 (task started at) was changed")))
+		var statuses []MigrationStatus
+		statuses, err = store.MigrationStatuses()
+		Expect(err).To(BeNil())
+		Expect(statuses[3].Changed).To(BeTrue())
+		Expect(statuses[4].Changed).To(BeFalse())
+	})
+})
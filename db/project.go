@@ -13,7 +13,10 @@ CREATE TABLE IF NOT EXISTS projects (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	title TEXT NOT NULL
 );
-PRAGMA user_version = 6;
+`
+
+const M006_ProjectSchemaDown = `
+DROP TABLE IF EXISTS projects;
 `
 
 // A project may be assigned to a task, and that project may be multiple words.
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/assert"
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the sqlite3 driver wrapped so that the statements run by a transaction
+// can be recorded, see RecordStatements
+const driverName = "sqlite3_recorded"
+
+func init() {
+	sql.Register(driverName, &recordingDriver{driver: &sqlite3.SQLiteDriver{}})
+}
+
+// RecordedStatement is a statement that was run inside of a recorded transaction
+type RecordedStatement struct {
+	Sql  string
+	Args []interface{}
+}
+
+// Recorder keeps every statement run by a transaction that was started with RecordStatements,
+// this is used to explain a command without committing it
+type Recorder struct {
+	statements []RecordedStatement
+}
+
+// NewRecorder creates a recorder without any statements
+func NewRecorder() *Recorder {
+	return &Recorder{statements: make([]RecordedStatement, 0)}
+}
+
+// Statements returns the recorded statements, the oldest first
+func (recorder *Recorder) Statements() []RecordedStatement {
+	return recorder.statements
+}
+
+// Len returns the number of recorded statements
+func (recorder *Recorder) Len() int {
+	return len(recorder.statements)
+}
+
+// Since returns the statements recorded after the first n statements
+func (recorder *Recorder) Since(n int) []RecordedStatement {
+	return recorder.statements[min(n, len(recorder.statements)):]
+}
+
+func (recorder *Recorder) record(query string, args []driver.NamedValue) {
+	var values = make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	recorder.statements = append(recorder.statements, RecordedStatement{Sql: query, Args: values})
+}
+
+type recorderKey struct{}
+
+// RecordStatements returns a context that records every statement of a transaction
+// started with it e.g store.Con.BeginTxx(RecordStatements(ctx, recorder), nil)
+func RecordStatements(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// MustCreateRecordedTx starts a transaction that keeps its statements in the recorder
+func (store *Store) MustCreateRecordedTx(recorder *Recorder) *sqlx.Tx {
+	var tx, err = store.Con.BeginTxx(RecordStatements(context.Background(), recorder), nil)
+	assert.Nil(err, "failed to start transaction")
+	return tx
+}
+
+type recordingDriver struct {
+	driver *sqlite3.SQLiteDriver
+}
+
+func (recordingDriver *recordingDriver) Open(dsn string) (driver.Conn, error) {
+	var conn, err = recordingDriver.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{SQLiteConn: conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// recordingConn passes every statement to the recorder of the open transaction
+type recordingConn struct {
+	*sqlite3.SQLiteConn
+	recorder *Recorder // Set while a recorded transaction is open, nil otherwise
+}
+
+func (conn *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx, err = conn.SQLiteConn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	var recorder, ok = ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return tx, nil
+	}
+	conn.recorder = recorder
+	return &recordingTx{Tx: tx, conn: conn}, nil
+}
+
+func (conn *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if conn.recorder != nil {
+		conn.recorder.record(query, args)
+	}
+	return conn.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (conn *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if conn.recorder != nil {
+		conn.recorder.record(query, args)
+	}
+	return conn.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+// recordingTx stops recording once the transaction has finished
+type recordingTx struct {
+	driver.Tx
+	conn *recordingConn
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.conn.recorder = nil
+	return tx.Tx.Commit()
+}
+
+func (tx *recordingTx) Rollback() error {
+	tx.conn.recorder = nil
+	return tx.Tx.Rollback()
+}
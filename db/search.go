@@ -0,0 +1,300 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// The columns of the full text index, see SearchQuery
+const (
+	SearchColumnTitle       = "title"       // The title of the task
+	SearchColumnDescription = "description" // The description of the task
+	SearchColumnNotes       = "notes"       // Every annotation of the task
+)
+
+// SearchColumns contains every column of the full text index
+var SearchColumns = []string{SearchColumnTitle, SearchColumnDescription, SearchColumnNotes}
+
+// The words of a snippet that matched the search are wrapped in these markers e.g \x02milk\x03
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// searchRefresh returns the statements that replace the row of the task in the full text index,
+// nothing is inserted when the task does not exist e.g an annotation restored before its task
+func searchRefresh(taskId string) string {
+	return fmt.Sprintf(`
+	DELETE FROM taskSearch WHERE rowid = %[1]s;
+	INSERT INTO taskSearch (rowid, title, description, notes)
+	SELECT tasks.id, tasks.title, COALESCE(tasks.description, ''), COALESCE((
+		SELECT group_concat(taskAnnotations.text, char(10)) FROM taskAnnotations
+		WHERE taskAnnotations.taskId = tasks.id
+	), '')
+	FROM tasks WHERE tasks.id = %[1]s;`, taskId)
+}
+
+var M016_TaskSearchSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS taskSearch USING fts5(
+	title, description, notes, tokenize = 'porter unicode61'
+);
+DELETE FROM taskSearch;
+INSERT INTO taskSearch (rowid, title, description, notes)
+SELECT tasks.id, tasks.title, COALESCE(tasks.description, ''), COALESCE((
+	SELECT group_concat(taskAnnotations.text, char(10)) FROM taskAnnotations
+	WHERE taskAnnotations.taskId = tasks.id
+), '')
+FROM tasks;
+CREATE TRIGGER IF NOT EXISTS search_tasks_insert AFTER INSERT ON tasks
+BEGIN` + searchRefresh("NEW.id") + `
+END;
+CREATE TRIGGER IF NOT EXISTS search_tasks_update AFTER UPDATE OF id, title, description ON tasks
+BEGIN
+	DELETE FROM taskSearch WHERE rowid = OLD.id;` + searchRefresh("NEW.id") + `
+END;
+CREATE TRIGGER IF NOT EXISTS search_tasks_delete AFTER DELETE ON tasks
+BEGIN
+	DELETE FROM taskSearch WHERE rowid = OLD.id;
+END;
+CREATE TRIGGER IF NOT EXISTS search_taskAnnotations_insert AFTER INSERT ON taskAnnotations
+BEGIN` + searchRefresh("NEW.taskId") + `
+END;
+CREATE TRIGGER IF NOT EXISTS search_taskAnnotations_update AFTER UPDATE ON taskAnnotations
+BEGIN` + searchRefresh("OLD.taskId") + searchRefresh("NEW.taskId") + `
+END;
+CREATE TRIGGER IF NOT EXISTS search_taskAnnotations_delete AFTER DELETE ON taskAnnotations
+BEGIN` + searchRefresh("OLD.taskId") + `
+END;
+`
+
+const M016_TaskSearchSchemaDown = `
+DROP TRIGGER IF EXISTS search_tasks_insert;
+DROP TRIGGER IF EXISTS search_tasks_update;
+DROP TRIGGER IF EXISTS search_tasks_delete;
+DROP TRIGGER IF EXISTS search_taskAnnotations_insert;
+DROP TRIGGER IF EXISTS search_taskAnnotations_update;
+DROP TRIGGER IF EXISTS search_taskAnnotations_delete;
+DROP TABLE IF EXISTS taskSearch;
+`
+
+// searchMigration creates the full text index when SQLite was built with FTS5 e.g go build
+// -tags sqlite_fts5, otherwise the migration is recorded without creating the index and
+// searches fall back to LIKE. The index is created by a later Migrate once FTS5 is
+// available, see createMissingSearchIndex
+func searchMigration(id int) Migration {
+	var migration = sqlMigration(id, "task search", M016_TaskSearchSchema, M016_TaskSearchSchemaDown)
+	var up = migration.Up
+	migration.Up = func(tx *sqlx.Tx) error {
+		var compiled, err = fullTextCompiledTx(tx)
+		if err != nil {
+			return err
+		}
+		if !compiled {
+			log.Warn().Msg("SQLite was built without FTS5, searches will not use a full text index")
+			return nil
+		}
+		return up(tx)
+	}
+	return migration
+}
+
+// fullTextCompiledTx returns true if SQLite was built with FTS5
+func fullTextCompiledTx(tx *sqlx.Tx) (bool, error) {
+	var compiled = false
+	var err = tx.Get(&compiled, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`)
+	if err != nil {
+		return false, fmt.Errorf("Failed to check if SQLite was built with FTS5: %w", err)
+	}
+	return compiled, nil
+}
+
+// createMissingSearchIndex creates the full text index when the task search migration was
+// applied by a build without FTS5 and this build has it, the index is filled from the tasks
+func createMissingSearchIndex(conn *sqlx.Conn) error {
+	var tx, err = conn.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var applied = 0
+	err = tx.Get(&applied, `SELECT COUNT(*) FROM schema_migrations WHERE name = 'task search'`)
+	if err != nil {
+		return fmt.Errorf("Failed to find the task search migration: %w", err)
+	}
+	if applied == 0 || fullTextIndexTx(tx) {
+		return nil
+	}
+	var compiled bool
+	compiled, err = fullTextCompiledTx(tx)
+	if err != nil || !compiled {
+		return err
+	}
+	_, err = tx.Exec(M016_TaskSearchSchema)
+	if err != nil {
+		return fmt.Errorf("Failed to create the full text index: %w", err)
+	}
+	log.Info().Msg("created the full text index")
+	return tx.Commit()
+}
+
+// FullTextSearchTx returns true if the full text index exists, see searchMigration
+func (store *Store) FullTextSearchTx(tx *sqlx.Tx) bool {
+	return fullTextIndexTx(tx)
+}
+
+// fullTextIndexTx returns true if the taskSearch table exists
+func fullTextIndexTx(tx *sqlx.Tx) bool {
+	var exists = 0
+	var err = tx.Get(&exists, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'taskSearch'`)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find the full text index")
+		return false
+	}
+	return exists > 0
+}
+
+// SearchTerms returns the lower case words of the text that are searched for
+// e.g "Old-ticket!" returns old and ticket
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// SearchQuery returns the FTS5 query that matches every word of the text as a prefix in the
+// columns e.g {title notes} : ("old"* AND "ticket"*), the syntax of the text is not interpreted
+func SearchQuery(columns []string, text string) string {
+	var terms = SearchTerms(text)
+	var phrases = make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = `"` + term + `"*`
+	}
+	return fmt.Sprintf("{%s} : (%s)", strings.Join(columns, " "), strings.Join(phrases, " AND "))
+}
+
+// searchRank is the relevance and snippet of a task that matched a search
+type searchRank struct {
+	ID        int64   `db:"id"`
+	Relevance float64 `db:"relevance"`
+	Snippet   string  `db:"snippet"`
+}
+
+// rankTasks sets the relevance and snippet of every task that matches the search,
+// the full text index ranks with bm25 where a match in the title counts the most
+func (store *Store) rankTasks(ctx context.Context, tasks []TaskDetailed, text string) error {
+	var tx, err = store.Con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to rank the search results: %w", err)
+	}
+	defer tx.Rollback()
+	var ranks = []searchRank{}
+	if store.FullTextSearchTx(tx) {
+		err = tx.Select(&ranks, `
+			SELECT
+				rowid AS id,
+				-bm25(taskSearch, 10.0, 5.0, 1.0) AS relevance,
+				snippet(taskSearch, -1, ?, ?, '…', 12) AS snippet
+			FROM taskSearch
+			WHERE taskSearch MATCH ?`,
+			SnippetMatchStart, SnippetMatchEnd, SearchQuery(SearchColumns, text),
+		)
+	} else {
+		ranks, err = rankTasksWithoutIndex(tx, tasks, text)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to rank the search results: %w", err)
+	}
+	var byId = make(map[int64]*searchRank, len(ranks))
+	for i := range ranks {
+		byId[ranks[i].ID] = &ranks[i]
+	}
+	for i := range tasks {
+		if rank, ok := byId[tasks[i].ID]; ok {
+			tasks[i].Relevance = rank.Relevance
+			tasks[i].Snippet = rank.Snippet
+		}
+	}
+	return nil
+}
+
+// rankTasksWithoutIndex ranks the tasks by the number of words found in each column,
+// used when SQLite was built without FTS5
+func rankTasksWithoutIndex(tx *sqlx.Tx, tasks []TaskDetailed, text string) ([]searchRank, error) {
+	var notes = []struct {
+		TaskID int64  `db:"taskId"`
+		Text   string `db:"text"`
+	}{}
+	var err = tx.Select(&notes, `
+		SELECT taskId, group_concat(text, char(10)) AS text FROM taskAnnotations GROUP BY taskId`)
+	if err != nil {
+		return nil, err
+	}
+	var notesById = make(map[int64]string, len(notes))
+	for _, note := range notes {
+		notesById[note.TaskID] = note.Text
+	}
+	var terms = SearchTerms(text)
+	var ranks = make([]searchRank, 0, len(tasks))
+	for i := range tasks {
+		var rank = searchRank{ID: tasks[i].ID}
+		var columns = []struct {
+			text   string
+			weight float64
+		}{
+			{tasks[i].Title, 10},
+			{tasks[i].Description.String, 5},
+			{notesById[tasks[i].ID], 1},
+		}
+		for _, column := range columns {
+			var lower = strings.ToLower(column.text)
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					rank.Relevance += column.weight
+				}
+			}
+			if rank.Snippet == "" {
+				rank.Snippet = highlightTerms(column.text, terms)
+			}
+		}
+		ranks = append(ranks, rank)
+	}
+	return ranks, nil
+}
+
+// highlightTerms wraps every occurrence of the terms in the snippet markers, an empty
+// snippet is returned when none of the terms are found
+func highlightTerms(text string, terms []string) string {
+	var found = false
+	var snippet strings.Builder
+	for i := 0; i < len(text); {
+		var matched = ""
+		for _, term := range terms {
+			var end = i + len(term)
+			if end <= len(text) && len(term) > len(matched) && strings.EqualFold(text[i:end], term) {
+				matched = term
+			}
+		}
+		if matched == "" {
+			snippet.WriteByte(text[i])
+			i++
+			continue
+		}
+		found = true
+		snippet.WriteString(SnippetMatchStart + text[i:i+len(matched)] + SnippetMatchEnd)
+		i += len(matched)
+	}
+	if !found {
+		return ""
+	}
+	return strings.ReplaceAll(snippet.String(), "\n", " ")
+}
+
+// PlainSnippet returns the snippet without the match markers
+func PlainSnippet(snippet string) string {
+	return strings.NewReplacer(SnippetMatchStart, "", SnippetMatchEnd, "").Replace(snippet)
+}
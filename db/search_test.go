@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Searching tasks", func() {
+	var store *Store
+	var ctx = context.Background()
+
+	var search = func(text string) []TaskDetailed {
+		var tasks, err = store.ListTasksQuery(ctx, &TaskQuery{
+			Sort:             SearchTaskSort,
+			IncludeCompleted: true,
+			Search:           text,
+		})
+		Expect(err).To(BeNil())
+		return tasks
+	}
+
+	BeforeEach(func() {
+		store = NewInMemoryStore()
+		DeferCleanup(store.Close)
+		var _, err = store.Con.Exec(`
+			INSERT INTO tasks (title, description) VALUES ('pay the invoice', NULL);
+			INSERT INTO tasks (title, description) VALUES ('call the bank', 'ask about the invoice');
+			INSERT INTO tasks (title, description, state) VALUES ('walk the dog', NULL, 2);
+			INSERT INTO taskAnnotations (taskId, text) VALUES (3, 'the old ticket was closed');
+		`)
+		Expect(err).To(BeNil())
+	})
+
+	DescribeTable("should split the text into words",
+		func(text string, expected []string) {
+			Expect(SearchTerms(text)).To(Equal(expected))
+		},
+		Entry("plain words", "old ticket", []string{"old", "ticket"}),
+		Entry("punctuation", `Old-ticket! "x"`, []string{"old", "ticket", "x"}),
+		Entry("no words", `" -`, []string{}),
+	)
+
+	It("should quote every word of the full text query", func() {
+		Expect(SearchQuery(SearchColumns, `old "ticket OR`)).To(Equal(`{title description notes} : ("old"* AND "ticket"* AND "or"*)`))
+		Expect(SearchQuery([]string{SearchColumnTitle}, "milk")).To(Equal(`{title} : ("milk"*)`))
+	})
+
+	It("should highlight the words that matched", func() {
+		Expect(highlightTerms("Pay the Invoice", []string{"invoice"})).To(Equal("Pay the \x02Invoice\x03"))
+		Expect(highlightTerms("walk the dog", []string{"invoice"})).To(Equal(""))
+		Expect(PlainSnippet("Pay the \x02Invoice\x03")).To(Equal("Pay the Invoice"))
+	})
+
+	It("should rank a match in the title above a match in the description", func() {
+		var tasks = search("invoice")
+		Expect(tasks[0].Title).To(Equal("pay the invoice"))
+		Expect(tasks[1].Title).To(Equal("call the bank"))
+		Expect(tasks[0].Relevance).To(BeNumerically(">", tasks[1].Relevance))
+		Expect(PlainSnippet(tasks[1].Snippet)).To(ContainSubstring("ask about the invoice"))
+		Expect(tasks[2].Snippet).To(BeEmpty())
+	})
+
+	It("should search the notes of completed tasks", func() {
+		var tasks = search("ticket")
+		Expect(tasks[0].Title).To(Equal("walk the dog"))
+		Expect(tasks[0].Snippet).To(ContainSubstring(SnippetMatchStart + "ticket" + SnippetMatchEnd))
+	})
+
+	It("should keep the full text index in sync with the tasks", func() {
+		var tx = store.MustCreateTxTodo()
+		defer tx.Rollback()
+		if !store.FullTextSearchTx(tx) {
+			Skip("SQLite was built without FTS5, run the tests with -tags sqlite_fts5")
+		}
+		var matches = func(text string) []int64 {
+			var ids = []int64{}
+			Expect(tx.Select(&ids, `SELECT rowid FROM taskSearch WHERE taskSearch MATCH ? ORDER BY rowid`,
+				SearchQuery(SearchColumns, text))).To(Succeed())
+			return ids
+		}
+		Expect(matches("invoice")).To(Equal([]int64{1, 2}))
+		Expect(matches("ticket")).To(Equal([]int64{3}))
+
+		var _, err = tx.Exec(`
+			UPDATE tasks SET title = 'pay the bill' WHERE id = 1;
+			UPDATE taskAnnotations SET text = 'invoice sent' WHERE taskId = 3;
+			DELETE FROM tasks WHERE id = 2;
+		`)
+		Expect(err).To(BeNil())
+		Expect(matches("invoice")).To(Equal([]int64{3}))
+		Expect(matches("bill")).To(Equal([]int64{1}))
+		Expect(matches("ticket")).To(BeEmpty())
+	})
+
+	It("should create the index skipped by a build without FTS5", func() {
+		// A single connection so that every migration sees the same in-memory database
+		var con = sqlx.MustConnect("sqlite3", ":memory:")
+		con.SetMaxOpenConns(1)
+		var store = &Store{Con: con}
+		DeferCleanup(store.Close)
+		var _, err = store.Migrate()
+		Expect(err).To(BeNil())
+		_, err = con.Exec(M016_TaskSearchSchemaDown + `INSERT INTO tasks (title) VALUES ('pay the invoice');`)
+		Expect(err).To(BeNil())
+
+		var applied int
+		applied, err = store.Migrate()
+		Expect(err).To(BeNil())
+		Expect(applied).To(Equal(0))
+
+		var tx = store.MustCreateTxTodo()
+		defer tx.Rollback()
+		var compiled bool
+		compiled, err = fullTextCompiledTx(tx)
+		Expect(err).To(BeNil())
+		Expect(store.FullTextSearchTx(tx)).To(Equal(compiled))
+		if compiled {
+			var count int
+			Expect(tx.Get(&count, `SELECT COUNT(*) FROM taskSearch WHERE taskSearch MATCH 'invoice'`)).To(Succeed())
+			Expect(count).To(Equal(1))
+		}
+	})
+})
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -12,7 +14,10 @@ CREATE TABLE IF NOT EXISTS tags (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	name TEXT NOT NULL UNIQUE
 );
-PRAGMA user_version = 1;
+`
+
+const M001_TagSchemaDown = `
+DROP TABLE IF EXISTS tags;
 `
 
 // Tag is a struct that represents a tag e.g "+work"
@@ -21,6 +26,12 @@ type Tag struct {
 	Name string `json:"name" db:"name"` // Name of the tag
 }
 
+// TagCount is a tag and the number of tasks it is linked to, see the tags command
+type TagCount struct {
+	Tag
+	Count int `json:"count" db:"count"` // The number of tasks with the tag
+}
+
 // CreateTagTx will create a new in the database (this should not exist)
 // the transaction is NOT rolled back on err
 func (store *Store) TagCreateTx(name string, tx *sqlx.Tx) (int64, error) {
@@ -36,6 +47,20 @@ func (store *Store) TagCreateTx(name string, tx *sqlx.Tx) (int64, error) {
 	return id, nil
 }
 
+// TagGetIDByNameOrCreateTx will get the tag ID by name or create it if it does not exist.
+// NOTE: the transaction is not rolled back on error
+func (store *Store) TagGetIDByNameOrCreateTx(tx *sqlx.Tx, name string) (int64, error) {
+	var id int64
+	var err = tx.Get(&id, "SELECT id FROM tags WHERE name = ?", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.TagCreateTx(name, tx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get tag: %w", err)
+	}
+	return id, nil
+}
+
 // CreateTag will create a new tag in the database (this should not exist)
 func (store *Store) TagCreate(ctx context.Context, name string) (int64, error) {
 	var res, err = store.Con.ExecContext(ctx, "INSERT INTO tags (name) VALUES (?)", name)
@@ -64,7 +89,7 @@ func (store *Store) TagGetByNameTx(name string, tx *sqlx.Tx) (*Tag, error) {
 // GetTagByName will get a single row for the tag with the name specified
 func (store *Store) TagGetByName(name string) (*Tag, error) {
 	var tag Tag
-	var err = store.Con.Get("SELECT * FROM tags WHERE name = ?", name)
+	var err = store.Con.Get(&tag, "SELECT * FROM tags WHERE name = ?", name)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get a tag by name: %w", err)
 	}
@@ -80,3 +105,23 @@ func (store *Store) TagList(ctx context.Context) ([]Tag, error) {
 	}
 	return tags, nil
 }
+
+// TagCountsQuery runs the query of the tags command, the query selects the id, name and count
+func (store *Store) TagCountsQuery(ctx context.Context, query string, args []interface{}) ([]TagCount, error) {
+	var tags = []TagCount{}
+	var err = store.Con.SelectContext(ctx, &tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to count the tasks of every tag: %w", err)
+	}
+	return tags, nil
+}
+
+// TagNamesTx returns the name of every tag in alphabetical order
+func (store *Store) TagNamesTx(tx *sqlx.Tx) ([]string, error) {
+	var names = make([]string, 0)
+	var err = tx.Select(&names, "SELECT name FROM tags ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list tag names: %w", err)
+	}
+	return names, nil
+}
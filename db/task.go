@@ -24,32 +24,50 @@ CREATE TABLE IF NOT EXISTS tasks (
 	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
 	completedAtUtc TEXT
 );
-PRAGMA user_version = 0;
+`
+
+const M000_TaskSchemaDown = `
+DROP TABLE IF EXISTS tasks;
 `
 
 const M003_TaskSchema = `
 ALTER TABLE tasks ADD COLUMN startedAtUtc TEXT;
-PRAGMA user_version = 3;
+`
+
+const M003_TaskSchemaDown = `
+ALTER TABLE tasks DROP COLUMN startedAtUtc;
 `
 
 const M004_TaskSchema = `
 ALTER TABLE tasks ADD COLUMN state INTEGER NOT NULL DEFAULT 0 CHECK (state >= 0 AND state <= 2);
-PRAGMA user_version = 4;
+`
+
+const M004_TaskSchemaDown = `
+ALTER TABLE tasks DROP COLUMN state;
 `
 
 const M005_TaskSchema = `
 ALTER TABLE tasks DROP COLUMN completed;
-PRAGMA user_version = 5;
+`
+
+const M005_TaskSchemaDown = `
+ALTER TABLE tasks ADD COLUMN completed INTEGER NOT NULL DEFAULT 0;
 `
 
 const M009_TaskSchema = `
 ALTER TABLE tasks DROP COLUMN startedAtUtc;
-PRAGMA user_version = 9;
+`
+
+const M009_TaskSchemaDown = `
+ALTER TABLE tasks ADD COLUMN startedAtUtc TEXT;
 `
 
 const M011_TaskSchema = `
 ALTER TABLE tasks ADD COLUMN next INTEGER NOT NULL DEFAULT 0 CHECK (next >= 0 AND next <= 1);
-PRAGMA user_version = 11;
+`
+
+const M011_TaskSchemaDown = `
+ALTER TABLE tasks DROP COLUMN next;
 `
 
 type TaskPriority int // Task priority levels
@@ -101,6 +119,7 @@ type Task struct {
 	UpdatedAtUtc sql.NullString `json:"updatedAtUtc" db:"updatedAtUtc"`   // Optional UpdatedAtUtc
 	CompletedUtc sql.NullString `json:"completedUtc" db:"completedAtUtc"` // Set once the task is marked as complete
 	Next         bool           `json:"next" db:"next"`                   // If the tasks is flaged as next to be started on
+	TemplateId   sql.NullInt64  `json:"templateId" db:"templateId"`       // Set when the task is an instance of a recurring task
 }
 
 // TaskDetailed represents a task with additional information from other tables
@@ -117,7 +136,14 @@ type TaskDetailed struct {
 	Dependencies    sql.NullString `json:"dependencies" db:"dependencies"`       // Comma serperated list of Dependencies
 	Blocked         bool           `json:"blocked" db:"blocked"`                 // If the current task has unmet Dependencies
 	Blocking        int            `json:"blocking" db:"blocking"`               // The total number of tasks that this task is blocking
+	Attributes      sql.NullString `json:"attributes" db:"attributes"`           // JSON object of the user defined attributes, see Attribute
+	AnnotationCount int            `json:"annotationCount" db:"annotationCount"` // The number of notes on the task, see ListTaskAnnotations
+	Relevance       float64        `json:"relevance,omitempty" db:"-"`           // How well the task matched the search, see TaskQuery.Search
+	Snippet         string         `json:"snippet,omitempty" db:"-"`             // The text that matched the search with the words marked, see SnippetMatchStart
 	urgencyComputed float64
+
+	attributes       map[string]string // Parsed Attributes
+	attributeUrgency float64           // Sum of the urgency coefficients of the user defined attributes
 }
 
 // PriorityStr returns the string version of the Priority Int
@@ -235,7 +261,8 @@ func (task *TaskDetailed) urgency() float64 {
 		task.urgencyBlocked() +
 		task.urgencyBlocking() +
 		task.urgencyMarkedAsNext() +
-		task.urgencyPriority()
+		task.urgencyPriority() +
+		task.attributeUrgency
 }
 
 func (task *TaskDetailed) urgencyProject() float64 {
@@ -349,9 +376,9 @@ func (store *Store) CountTasks(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
-// ListTasks returns a list of all tasks in the database
-func (store *Store) ListTasks(ctx context.Context) ([]TaskDetailed, error) {
-	var sql = `
+// listTasksSql selects every task along with the detailed columns,
+// callers are expected to append the WHERE clause and the GROUP BY
+const listTasksSql = `
 	SELECT
 		tasks.*,
 		COUNT(taskProjects.projectId) AS projectCount,
@@ -399,8 +426,24 @@ func (store *Store) ListTasks(ctx context.Context) ([]TaskDetailed, error) {
 
 		-- TagNames
 		-- ======================================================================
-		GROUP_CONCAT(tags.name ORDER BY tags.name ASC) AS tagNames,
-		COUNT(taskTags.tagId) AS tagCount,
+		-- sub queries so that multiple tags do not multiply the joined rows
+		(
+			SELECT GROUP_CONCAT(tags.name ORDER BY tags.name ASC)
+			FROM taskTags
+			JOIN tags ON tags.id = taskTags.tagId
+			WHERE taskTags.taskId = tasks.id
+		) AS tagNames,
+		(SELECT COUNT(*) FROM taskTags WHERE taskTags.taskId = tasks.id) AS tagCount,
+
+		-- ======================================================================
+		-- Attributes
+		-- ======================================================================
+		(
+			SELECT json_group_object(taskAttributes.name, taskAttributes.value)
+			FROM taskAttributes
+			WHERE taskAttributes.taskId = tasks.id
+		) AS attributes,
+		(SELECT COUNT(*) FROM taskAnnotations WHERE taskAnnotations.taskId = tasks.id) AS annotationCount,
 
 		COUNT(blockingTasks.taskId) AS blocking
 
@@ -410,17 +453,76 @@ func (store *Store) ListTasks(ctx context.Context) ([]TaskDetailed, error) {
 	LEFT JOIN taskTime ON taskTime.taskId = tasks.id
 	LEFT JOIN taskDependencies ON taskDependencies.taskId = tasks.id
 	LEFT JOIN taskDependencies AS blockingTasks ON blockingTasks.dependsOnId = tasks.id
-	LEFT JOIN taskTags ON taskTags.taskId = tasks.id
-	LEFT JOIN tags ON tags.id = taskTags.tagId
-	WHERE
-		tasks.state != 2 -- COMPLETED
-	GROUP BY tasks.id;
 	`
+
+// TaskQuery selects, orders and limits the tasks returned by ListTasksQuery
+type TaskQuery struct {
+	Filter           string        // Sub query selecting task IDs e.g SELECT tasks.id FROM tasks WHERE priority = ?
+	Args             []interface{} // Arguments for the placeholders in Filter
+	Sort             []TaskSortKey // The order is unspecified when empty
+	Limit            int           // The maximum number of tasks, 0 returns every task
+	IncludeCompleted bool          // Completed tasks are hidden unless this is set
+	Search           string        // The text of a search e.g old ticket, sets the relevance and snippet of every task
+}
+
+// ListTasks returns a list of all tasks in the database
+func (store *Store) ListTasks(ctx context.Context) ([]TaskDetailed, error) {
+	return store.ListTasksFiltered(ctx, "")
+}
+
+// ListTasksFiltered returns the tasks whose ID is returned by the filter,
+// the filter is a sub query e.g SELECT tasks.id FROM tasks WHERE priority = ?
+// An empty filter will return every task
+func (store *Store) ListTasksFiltered(ctx context.Context, filter string, args ...interface{}) ([]TaskDetailed, error) {
+	return store.ListTasksQuery(ctx, &TaskQuery{Filter: filter, Args: args})
+}
+
+// ListTasksQuery returns the tasks selected by the query, the sort is pushed down to
+// ORDER BY where possible and urgency is sorted in Go before the limit is applied
+func (store *Store) ListTasksQuery(ctx context.Context, query *TaskQuery) ([]TaskDetailed, error) {
+	var sql = strings.Builder{}
+	var sqlArgs = []interface{}{}
+	var conditions = []string{}
+	sql.WriteString(listTasksSql)
+	if !query.IncludeCompleted {
+		conditions = append(conditions, "tasks.state != ?")
+		sqlArgs = append(sqlArgs, TaskStateCompleted)
+	}
+	if query.Filter != "" {
+		conditions = append(conditions, "tasks.id IN ("+query.Filter+")")
+		sqlArgs = append(sqlArgs, query.Args...)
+	}
+	if len(conditions) > 0 {
+		sql.WriteString("\tWHERE " + strings.Join(conditions, "\n\tAND ") + "\n")
+	}
+	sql.WriteString("\tGROUP BY tasks.id")
+	if len(query.Sort) > 0 {
+		sql.WriteString("\n\t" + taskOrderBy(query.Sort))
+	}
+	var goSort = needsGoSort(query.Sort)
+	if query.Limit > 0 && !goSort {
+		sql.WriteString("\n\tLIMIT ?")
+		sqlArgs = append(sqlArgs, query.Limit)
+	}
+	sql.WriteString(";")
+
 	var tasks []TaskDetailed
-	err := store.Con.SelectContext(ctx, &tasks, sql, TaskStateCompleted)
+	err := store.Con.SelectContext(ctx, &tasks, sql.String(), sqlArgs...)
 	if err != nil {
 		return nil, err
 	}
+	store.applyAttributeUrgency(tasks)
+	if query.Search != "" {
+		if err = store.rankTasks(ctx, tasks, query.Search); err != nil {
+			return nil, err
+		}
+	}
+	if goSort {
+		SortTasks(tasks, query.Sort)
+	}
+	if query.Limit > 0 && len(tasks) > query.Limit {
+		tasks = tasks[:query.Limit]
+	}
 	return tasks, nil
 }
 
@@ -440,6 +542,21 @@ func (store *Store) DeleteTaskById(ctx context.Context, id int64) (bool, error)
 	return rowsAffected > 0, nil
 }
 
+// DeleteTaskByIdTx deletes a task by its ID inside of a transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) DeleteTaskByIdTx(tx *sqlx.Tx, id int64) (bool, error) {
+	var res, err = tx.Exec("DELETE FROM tasks WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	var rowsAffected int64
+	rowsAffected, err = res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
 // CreateTask creates a new task in the database
 func (store *Store) CreateTask(ctx context.Context, task *Task) (*Task, error) {
 	var sql = `
@@ -470,6 +587,22 @@ func (store *Store) CreateTask(ctx context.Context, task *Task) (*Task, error) {
 
 // CompleteTaskById marks a task as completed by its ID
 func (store *Store) CompleteTaskById(taskId int64) (bool, error) {
+	var tx, err = store.Con.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var affected bool
+	affected, err = store.CompleteTaskByIdTx(tx, taskId)
+	if err != nil {
+		return false, err
+	}
+	return affected, tx.Commit()
+}
+
+// CompleteTaskByIdTx marks a task as completed by its ID inside of a transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) CompleteTaskByIdTx(tx *sqlx.Tx, taskId int64) (bool, error) {
 	var sql = `
 	UPDATE tasks
 	SET
@@ -481,7 +614,7 @@ func (store *Store) CompleteTaskById(taskId int64) (bool, error) {
 		end
 	WHERE id = ?
 	`
-	var res, err = store.Con.Exec(sql, TaskStateCompleted, taskId)
+	var res, err = tx.Exec(sql, TaskStateCompleted, taskId)
 	if err != nil {
 		return false, err
 	}
@@ -496,9 +629,32 @@ func (store *Store) CompleteTaskById(taskId int64) (bool, error) {
 	return affected > 0, nil
 }
 
-// IncreasePriority increases the priority of a task by its ID (if possible)
-func (store *Store) IncreasePriority(ctx context.Context, id int64) (bool, error) {
-	var sql = `
+// CompleteTaskTx stops any running time tracking, removes the dependencies,
+// marks the task as completed and creates the next instance of a recurring task
+// inside of a single transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) CompleteTaskTx(tx *sqlx.Tx, taskId int64) (bool, error) {
+	var _, err = store.StopTrackingTaskTimeTx(tx, taskId)
+	if err != nil {
+		return false, fmt.Errorf("Error stopping task time: %w", err)
+	}
+	err = store.DeleteDependenciesForCompletedTaskTx(tx, taskId)
+	if err != nil {
+		return false, fmt.Errorf("Error deleting dependencies for completed task: %w", err)
+	}
+	var affected bool
+	affected, err = store.CompleteTaskByIdTx(tx, taskId)
+	if err != nil || !affected {
+		return affected, err
+	}
+	err = store.TemplateCompleteInstanceTx(tx, taskId)
+	if err != nil {
+		return false, fmt.Errorf("Error creating the next instance of a recurring task: %w", err)
+	}
+	return true, nil
+}
+
+const increasePrioritySql = `
 	UPDATE tasks
 	SET
 		priority = case
@@ -510,20 +666,8 @@ func (store *Store) IncreasePriority(ctx context.Context, id int64) (bool, error
 		end
 	WHERE id = ?
 	`
-	var res, err = store.Con.ExecContext(ctx, sql, id)
-	if err != nil {
-		return false, err
-	}
-
-	var affected int64
-	affected, err = res.RowsAffected()
-	assert.True(affected <= 1, "affected should be 0 or 1")
-	return affected == 1, err
-}
 
-// DecreasePriority decreases the priority of a task by its ID (if possible)
-func (store *Store) DecreasePriority(ctx context.Context, id int64) (bool, error) {
-	var sql = `
+const decreasePrioritySql = `
 	UPDATE tasks
 	SET
 		priority = case
@@ -535,21 +679,44 @@ func (store *Store) DecreasePriority(ctx context.Context, id int64) (bool, error
 		end
 	WHERE id = ?
 	`
-	var res, err = store.Con.ExecContext(ctx, sql, id)
-	if err != nil {
-		return false, err
-	}
 
-	var affected int64
-	affected, err = res.RowsAffected()
-	assert.True(affected <= 1, "affected should be 0 or 1")
-	return affected == 1, err
+const setPrioritySql = `UPDATE tasks SET priority = ? WHERE id = ?`
+
+// IncreasePriority increases the priority of a task by its ID (if possible)
+func (store *Store) IncreasePriority(ctx context.Context, id int64) (bool, error) {
+	return priorityUpdated(store.Con.ExecContext(ctx, increasePrioritySql, id))
+}
+
+// IncreasePriorityTx increases the priority of a task by its ID inside of a transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) IncreasePriorityTx(tx *sqlx.Tx, id int64) (bool, error) {
+	return priorityUpdated(tx.Exec(increasePrioritySql, id))
+}
+
+// DecreasePriority decreases the priority of a task by its ID (if possible)
+func (store *Store) DecreasePriority(ctx context.Context, id int64) (bool, error) {
+	return priorityUpdated(store.Con.ExecContext(ctx, decreasePrioritySql, id))
+}
+
+// DecreasePriorityTx decreases the priority of a task by its ID inside of a transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) DecreasePriorityTx(tx *sqlx.Tx, id int64) (bool, error) {
+	return priorityUpdated(tx.Exec(decreasePrioritySql, id))
 }
 
 // SetPriority sets the priority of a task by its ID
 func (store *Store) SetPriority(ctx context.Context, id int64, priority TaskPriority) (bool, error) {
-	var sql = `UPDATE tasks SET priority = ? WHERE id = ?`
-	var res, err = store.Con.ExecContext(ctx, sql, priority, id)
+	return priorityUpdated(store.Con.ExecContext(ctx, setPrioritySql, priority, id))
+}
+
+// SetPriorityTx sets the priority of a task by its ID inside of a transaction
+// NOTE: the transaction is not rolled back on error
+func (store *Store) SetPriorityTx(tx *sqlx.Tx, id int64, priority TaskPriority) (bool, error) {
+	return priorityUpdated(tx.Exec(setPrioritySql, priority, id))
+}
+
+// priorityUpdated returns true if the priority of the task was updated
+func priorityUpdated(res sql.Result, err error) (bool, error) {
 	if err != nil {
 		return false, err
 	}
@@ -617,6 +784,13 @@ func (store *Store) TaskIdExistsAndNotCompleted(tx *sqlx.Tx, taskId int64) bool
 	return err == nil
 }
 
+// TaskIdExistsTx returns true if a task with the ID exists
+func (store *Store) TaskIdExistsTx(tx *sqlx.Tx, taskId int64) bool {
+	var matched bool
+	var err = tx.Get(&matched, `SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ?)`, taskId)
+	return err == nil && matched
+}
+
 // FilterByTaskId returns a task by its ID
 func (store *Store) FilterByTaskId(taskId int64, tasks []TaskDetailed) *TaskDetailed {
 	for _, task := range tasks {
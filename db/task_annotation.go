@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const M015_TaskAnnotationsSchema = `
+CREATE TABLE IF NOT EXISTS taskAnnotations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taskId INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS taskAnnotationsTask ON taskAnnotations(taskId, createdAtUtc);
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAnnotations_insert AFTER INSERT ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'taskId', NEW.taskId, 'text', NEW.text, 'createdAtUtc', NEW.createdAtUtc));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAnnotations_update AFTER UPDATE ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'update', NEW.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'text', OLD.text, 'createdAtUtc', OLD.createdAtUtc), json_object('id', NEW.id, 'taskId', NEW.taskId, 'text', NEW.text, 'createdAtUtc', NEW.createdAtUtc));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAnnotations_delete AFTER DELETE ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'delete', OLD.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'text', OLD.text, 'createdAtUtc', OLD.createdAtUtc), NULL);
+END;
+`
+
+const M015_TaskAnnotationsSchemaDown = `
+DROP TABLE IF EXISTS taskAnnotations;
+`
+
+// TaskAnnotation is a timestamped note on a task e.g "pinged vendor"
+type TaskAnnotation struct {
+	ID         int64  `json:"id" db:"id"`                   // Unique identifier
+	TaskID     int64  `json:"taskId" db:"taskId"`           // The task that was annotated
+	Text       string `json:"text" db:"text"`               // e.g pinged vendor
+	CreatedUtc string `json:"createdUtc" db:"createdAtUtc"` // When the note was added
+}
+
+// AnnotateTaskTx adds a note to the task, returns false if the task does not exist
+// NOTE: the transaction is not rolled back on error
+func (store *Store) AnnotateTaskTx(tx *sqlx.Tx, taskId int64, text string) (bool, error) {
+	if !store.TaskIdExistsTx(tx, taskId) {
+		return false, nil
+	}
+	var _, err = tx.Exec(`INSERT INTO taskAnnotations (taskId, text) VALUES (?, ?)`, taskId, text)
+	if err != nil {
+		return false, fmt.Errorf("Failed to annotate task %d: %w", taskId, err)
+	}
+	return true, nil
+}
+
+// DenotateTaskTx removes a note from the task, the text is matched without case and the
+// most recent note is removed when several match. Returns false if no note matches
+// NOTE: the transaction is not rolled back on error
+func (store *Store) DenotateTaskTx(tx *sqlx.Tx, taskId int64, text string) (bool, error) {
+	var sql = `
+	DELETE FROM taskAnnotations WHERE id = (
+		SELECT id FROM taskAnnotations
+		WHERE taskId = ? AND lower(text) = lower(?)
+		ORDER BY createdAtUtc DESC, id DESC
+		LIMIT 1
+	)`
+	return store.denotateTx(tx, taskId, sql, taskId, strings.TrimSpace(text))
+}
+
+// DenotateLatestTaskTx removes the most recent note from the task.
+// Returns false if the task does not have any notes
+// NOTE: the transaction is not rolled back on error
+func (store *Store) DenotateLatestTaskTx(tx *sqlx.Tx, taskId int64) (bool, error) {
+	var sql = `
+	DELETE FROM taskAnnotations WHERE id = (
+		SELECT id FROM taskAnnotations
+		WHERE taskId = ?
+		ORDER BY createdAtUtc DESC, id DESC
+		LIMIT 1
+	)`
+	return store.denotateTx(tx, taskId, sql, taskId)
+}
+
+func (store *Store) denotateTx(tx *sqlx.Tx, taskId int64, sql string, args ...interface{}) (bool, error) {
+	var res, err = tx.Exec(sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("Failed to denotate task %d: %w", taskId, err)
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ListTaskAnnotations returns the notes on the task, the oldest first
+func (store *Store) ListTaskAnnotations(ctx context.Context, taskId int64) ([]TaskAnnotation, error) {
+	var annotations = []TaskAnnotation{}
+	var err = store.Con.SelectContext(ctx, &annotations, `
+		SELECT * FROM taskAnnotations
+		WHERE taskId = ?
+		ORDER BY createdAtUtc ASC, id ASC`,
+		taskId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the annotations of task %d: %w", taskId, err)
+	}
+	return annotations, nil
+}
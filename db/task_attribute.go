@@ -0,0 +1,231 @@
+package db
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/rs/zerolog/log"
+)
+
+const M013_TaskAttributesSchema = `
+CREATE TABLE IF NOT EXISTS taskAttributes (
+	taskId INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	PRIMARY KEY (taskId, name)
+);
+CREATE INDEX IF NOT EXISTS taskAttributesName ON taskAttributes(name, value);
+`
+
+const M013_TaskAttributesSchemaDown = `
+DROP INDEX IF EXISTS taskAttributesName;
+DROP TABLE IF EXISTS taskAttributes;
+`
+
+// udaName is the format of a user defined attribute name, it is used as a key e.g estimate:3
+var udaName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// SetUDAs replaces the user defined attributes that can be stored on tasks, names are case
+// insensitive and cannot be the same as a built in field e.g priority
+func (store *Store) SetUDAs(udas map[string]config.UDA) error {
+	var checked = make(map[string]config.UDA, len(udas))
+	for name, uda := range udas {
+		name = strings.ToLower(name)
+		if !udaName.MatchString(name) {
+			return fmt.Errorf("Invalid UDA name %q, expected letters, numbers and underscores e.g estimate", name)
+		}
+		if _, builtin := taskSortFields[name]; builtin {
+			return fmt.Errorf("The UDA %s has the same name as a built in field", name)
+		}
+		switch uda.Type {
+		case "":
+			uda.Type = config.UDATypeString
+		case config.UDATypeString, config.UDATypeNumber, config.UDATypeDate, config.UDATypeDuration:
+		default:
+			return fmt.Errorf("The UDA %s has an unknown type %q, expected string, number, date or duration", name, uda.Type)
+		}
+		checked[name] = uda
+	}
+	store.udas = checked
+	return nil
+}
+
+// UDA returns the definition of the user defined attribute
+func (store *Store) UDA(name string) (config.UDA, bool) {
+	var uda, ok = store.udas[strings.ToLower(name)]
+	return uda, ok
+}
+
+// UDAs returns every user defined attribute, the map must not be modified
+func (store *Store) UDAs() map[string]config.UDA {
+	return store.udas
+}
+
+// UDANames returns the name of every user defined attribute in alphabetical order
+func (store *Store) UDANames() []string {
+	var names = make([]string, 0, len(store.udas))
+	for name := range store.udas {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetTaskAttributeTx stores the value of a user defined attribute, replacing the previous value
+func (store *Store) SetTaskAttributeTx(tx *sqlx.Tx, taskId int64, name string, value string) error {
+	var _, err = tx.Exec(`
+		INSERT INTO taskAttributes (taskId, name, value) VALUES (?, ?, ?)
+		ON CONFLICT (taskId, name) DO UPDATE SET value = excluded.value`,
+		taskId, strings.ToLower(name), value,
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to set the %s of task %d: %w", name, taskId, err)
+	}
+	return nil
+}
+
+// RemoveTaskAttributeTx removes the value of a user defined attribute e.g estimate:none
+func (store *Store) RemoveTaskAttributeTx(tx *sqlx.Tx, taskId int64, name string) error {
+	var _, err = tx.Exec(
+		`DELETE FROM taskAttributes WHERE taskId = ? AND name = ?`,
+		taskId, strings.ToLower(name),
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to remove the %s of task %d: %w", name, taskId, err)
+	}
+	return nil
+}
+
+// ParseAttributeDuration parses the value of a duration attribute e.g 90m, 1h30m, 3d or 2w
+func ParseAttributeDuration(value string) (time.Duration, error) {
+	var lower = strings.ToLower(strings.TrimSpace(value))
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if amount, found := strings.CutSuffix(lower, suffix); found {
+			var count, err = strconv.ParseFloat(amount, 64)
+			if err == nil && count >= 0 {
+				return time.Duration(count * float64(unit)), nil
+			}
+		}
+	}
+	var duration, err = time.ParseDuration(lower)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("Invalid duration %q, expected e.g 90m, 1h30m, 3d or 2w", value)
+	}
+	return duration, nil
+}
+
+// NormalizeAttribute checks the value against the definition of the attribute and returns
+// the value that is stored, numbers are stored without trailing zeros and durations as seconds.
+// Dates must already be in SQLITE_TIME_FORMAT as they are resolved by the interpreter
+func NormalizeAttribute(name string, uda config.UDA, value string) (string, error) {
+	if len(uda.Values) > 0 {
+		var index = slices.IndexFunc(uda.Values, func(allowed string) bool {
+			return strings.EqualFold(allowed, value)
+		})
+		if index < 0 {
+			return "", fmt.Errorf("The %s must be one of %s", name, strings.Join(uda.Values, ", "))
+		}
+		value = uda.Values[index]
+	}
+	switch uda.Type {
+	case config.UDATypeNumber:
+		var number, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("The %s must be a number e.g %s:3", name, name)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case config.UDATypeDuration:
+		var duration, err = ParseAttributeDuration(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(int64(duration.Seconds()), 10), nil
+	case config.UDATypeDate:
+		if _, err := time.Parse(SQLITE_TIME_FORMAT, value); err != nil {
+			return "", fmt.Errorf("The %s must be a date e.g %s:tomorrow", name, name)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// Attribute returns the stored value of a user defined attribute e.g 3 for estimate:3
+func (task *TaskDetailed) Attribute(name string) (string, bool) {
+	var value, ok = task.parseAttributes()[strings.ToLower(name)]
+	return value, ok
+}
+
+// AttributeValues returns every user defined attribute of the task by name
+func (task *TaskDetailed) AttributeValues() map[string]string {
+	return task.parseAttributes()
+}
+
+// parseAttributes parses the JSON object of attributes once (will be cached)
+func (task *TaskDetailed) parseAttributes() map[string]string {
+	if task.attributes != nil {
+		return task.attributes
+	}
+	task.attributes = make(map[string]string)
+	if task.Attributes.Valid {
+		var err = json.Unmarshal([]byte(task.Attributes.String), &task.attributes)
+		if err != nil {
+			log.Error().Err(err).Int64("task", task.ID).Msg("failed to parse the task attributes")
+		}
+	}
+	return task.attributes
+}
+
+// applyAttributeUrgency adds the urgency coefficient of every attribute the tasks have a value for
+func (store *Store) applyAttributeUrgency(tasks []TaskDetailed) {
+	for name, uda := range store.udas {
+		if uda.Urgency == 0 {
+			continue
+		}
+		for i := range tasks {
+			if _, ok := tasks[i].Attribute(name); ok {
+				tasks[i].attributeUrgency += uda.Urgency
+			}
+		}
+	}
+}
+
+// attributeSortField sorts by the value of a user defined attribute, numbers and durations
+// are compared as numbers and every other type as text
+func attributeSortField(name string, kind config.UDAType) taskSortField {
+	var numeric = kind == config.UDATypeNumber || kind == config.UDATypeDuration
+	// The name is safe to inline, ParseTaskSort only accepts names that match udaName
+	var value = fmt.Sprintf(
+		"(SELECT taskAttributes.value FROM taskAttributes WHERE taskAttributes.taskId = tasks.id AND taskAttributes.name = '%s')",
+		name,
+	)
+	if numeric {
+		value = fmt.Sprintf("CAST(%s AS REAL)", value)
+	}
+	return taskSortField{
+		sql: value,
+		nulls: func(task *TaskDetailed) bool {
+			var _, ok = task.Attribute(name)
+			return !ok
+		},
+		compare: func(a, b *TaskDetailed) int {
+			var aValue, _ = a.Attribute(name)
+			var bValue, _ = b.Attribute(name)
+			if numeric {
+				var aNumber, _ = strconv.ParseFloat(aValue, 64)
+				var bNumber, _ = strconv.ParseFloat(bValue, 64)
+				return cmp.Compare(aNumber, bNumber)
+			}
+			return strings.Compare(aValue, bValue)
+		},
+	}
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -14,7 +16,10 @@ CREATE TABLE IF NOT EXISTS taskDependencies (
 	FOREIGN KEY (dependsOnId) REFERENCES tasks(id) ON DELETE CASCADE,
 	UNIQUE (taskId, dependsOnId)
 );
-PRAGMA user_version = 10;
+`
+
+const M010_TaskDependenciesSchemaDown = `
+DROP TABLE IF EXISTS taskDependencies;
 `
 
 type TaskDependency struct {
@@ -22,15 +27,89 @@ type TaskDependency struct {
 	DependsOnID int64 `db:"dependsOnId"` // Unique identifier of the task that this task depends on
 }
 
-// TaskDependsOn creates a dependency between two tasks
+// DependencyCycleError is returned when a dependency would make a task depend on itself
+type DependencyCycleError struct {
+	Path []int64 // e.g 1, 2, 5, 1 when 2 depends on 5 and 5 depends on 1
+}
+
+// Error returns the message e.g Task 1 cannot depend on task 2, it would create a cycle: 1 → 2 → 5 → 1
+func (e *DependencyCycleError) Error() string {
+	var ids = make([]string, 0, len(e.Path))
+	for _, id := range e.Path {
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+	return fmt.Sprintf(
+		"Task %d cannot depend on task %d, it would create a cycle: %s",
+		e.Path[0], e.Path[1], strings.Join(ids, " → "),
+	)
+}
+
+// TaskDependsOn creates a dependency between two tasks, a *DependencyCycleError is
+// returned if the dependency would create a cycle
 func (store *Store) TaskDependsOnTx(tx *sqlx.Tx, taskId int64, dependsOnId int64) error {
-	var _, err = tx.Exec(`INSERT INTO taskDependencies (taskId, dependsOnId) VALUES (?, ?)`, taskId, dependsOnId)
+	var path, err = store.DependencyPathTx(tx, dependsOnId, taskId)
+	if err != nil {
+		return err
+	}
+	if path != nil {
+		return &DependencyCycleError{Path: append([]int64{taskId}, path...)}
+	}
+	_, err = tx.Exec(`INSERT INTO taskDependencies (taskId, dependsOnId) VALUES (?, ?)`, taskId, dependsOnId)
 	if err != nil {
 		return fmt.Errorf("Failed to insert task dependency: %w", err)
 	}
 	return nil
 }
 
+// DependencyPathTx returns the shortest chain of dependencies from one task to another
+// e.g 2, 5, 1 when 2 depends on 5 and 5 depends on 1, nil is returned if there is not one
+func (store *Store) DependencyPathTx(tx *sqlx.Tx, fromId int64, toId int64) ([]int64, error) {
+	var paths []string
+	var err = tx.Select(&paths, `
+		WITH RECURSIVE reachable(id, path) AS (
+			SELECT ?, CAST(? AS TEXT)
+			UNION ALL
+			SELECT taskDependencies.dependsOnId, reachable.path || ',' || taskDependencies.dependsOnId
+			FROM taskDependencies
+			JOIN reachable ON taskDependencies.taskId = reachable.id
+			WHERE reachable.id != ?
+			AND instr(',' || reachable.path || ',', ',' || taskDependencies.dependsOnId || ',') = 0
+		)
+		SELECT path FROM reachable WHERE id = ? ORDER BY length(path) LIMIT 1`,
+		fromId, fromId, toId, toId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the dependency path: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	var path = make([]int64, 0)
+	for _, id := range strings.Split(paths[0], ",") {
+		var parsed, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse the dependency path: %w", err)
+		}
+		path = append(path, parsed)
+	}
+	return path, nil
+}
+
+// TaskUndependsOnTx removes the dependency between two tasks, false is returned
+// if the task did not depend on the other task
+func (store *Store) TaskUndependsOnTx(tx *sqlx.Tx, taskId int64, dependsOnId int64) (bool, error) {
+	var res, err = tx.Exec(`DELETE FROM taskDependencies WHERE taskId = ? AND dependsOnId = ?`, taskId, dependsOnId)
+	if err != nil {
+		return false, fmt.Errorf("Failed to delete task dependency: %w", err)
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("Failed to count the deleted task dependencies: %w", err)
+	}
+	return affected > 0, nil
+}
+
 // GetDependenciesForTask returns all the dependencies for a task
 func (store *Store) GetDependenciesForTask(taskId int64) ([]TaskDependency, error) {
 	var deps []TaskDependency
@@ -48,3 +127,9 @@ func (store *Store) DeleteDependenciesForCompletedTask(completedTaskId int64) er
 	}
 	return err
 }
+
+// DeleteDependenciesForCompletedTaskTx deletes all dependencies for a task inside of a transaction
+func (store *Store) DeleteDependenciesForCompletedTaskTx(tx *sqlx.Tx, completedTaskId int64) error {
+	_, err := tx.Exec(`DELETE FROM taskDependencies WHERE taskId = ? OR dependsOnId = ?`, completedTaskId, completedTaskId)
+	return err
+}
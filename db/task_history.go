@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The history is kept after a task is deleted, so taskId does not reference tasks.
+// The source is the program that made the change e.g modify 1 priority:high, it is NULL
+// when the change was not made by a journaled operation.
+// A field is added to the history by a new migration that creates its triggers
+const M017_TaskHistorySchema = `
+CREATE TABLE IF NOT EXISTS taskHistory (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taskId INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	oldValue TEXT,
+	newValue TEXT,
+	source TEXT,
+	changedAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS taskHistoryTask ON taskHistory(taskId, id);
+INSERT INTO taskHistory (taskId, field, newValue, changedAtUtc)
+SELECT id, 'created', title, createdAtUtc FROM tasks;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_insert AFTER INSERT ON tasks
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'created', NULL, NEW.title, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+CREATE TRIGGER IF NOT EXISTS history_tasks_delete AFTER DELETE ON tasks
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (OLD.id, 'deleted', OLD.title, NULL, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_title AFTER UPDATE OF title ON tasks
+WHEN OLD.title IS NOT NEW.title
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'title', OLD.title, NEW.title, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_description AFTER UPDATE OF description ON tasks
+WHEN OLD.description IS NOT NEW.description
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'description', OLD.description, NEW.description, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_priority AFTER UPDATE OF priority ON tasks
+WHEN OLD.priority IS NOT NEW.priority
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'priority', CASE OLD.priority WHEN 1 THEN 'low' WHEN 2 THEN 'medium' WHEN 3 THEN 'high' ELSE 'none' END, CASE NEW.priority WHEN 1 THEN 'low' WHEN 2 THEN 'medium' WHEN 3 THEN 'high' ELSE 'none' END, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_dueUtc AFTER UPDATE OF dueUtc ON tasks
+WHEN OLD.dueUtc IS NOT NEW.dueUtc
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'due', OLD.dueUtc, NEW.dueUtc, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_state AFTER UPDATE OF state ON tasks
+WHEN OLD.state IS NOT NEW.state
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'status', CASE OLD.state WHEN 1 THEN 'started' WHEN 2 THEN 'completed' ELSE 'pending' END, CASE NEW.state WHEN 1 THEN 'started' WHEN 2 THEN 'completed' ELSE 'pending' END, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_tasks_next AFTER UPDATE OF next ON tasks
+WHEN OLD.next IS NOT NEW.next
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.id, 'next', CASE OLD.next WHEN 1 THEN 'yes' ELSE 'no' END, CASE NEW.next WHEN 1 THEN 'yes' ELSE 'no' END, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_taskTags_insert AFTER INSERT ON taskTags
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.taskID, 'tags', NULL, (SELECT name FROM tags WHERE id = NEW.tagID), (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+CREATE TRIGGER IF NOT EXISTS history_taskTags_delete AFTER DELETE ON taskTags
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.taskID)
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (OLD.taskID, 'tags', (SELECT name FROM tags WHERE id = OLD.tagID), NULL, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_taskProjects_insert AFTER INSERT ON taskProjects
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.taskId, 'project', NULL, (SELECT title FROM projects WHERE id = NEW.projectId), (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+CREATE TRIGGER IF NOT EXISTS history_taskProjects_delete AFTER DELETE ON taskProjects
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.taskId)
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (OLD.taskId, 'project', (SELECT title FROM projects WHERE id = OLD.projectId), NULL, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_taskDependencies_insert AFTER INSERT ON taskDependencies
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (NEW.taskId, 'depends', NULL, CAST(NEW.dependsOnId AS TEXT), (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+CREATE TRIGGER IF NOT EXISTS history_taskDependencies_delete AFTER DELETE ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.taskId)
+BEGIN
+	INSERT INTO taskHistory (taskId, field, oldValue, newValue, source)
+	VALUES (OLD.taskId, 'depends', CAST(OLD.dependsOnId AS TEXT), NULL, (SELECT description FROM undoOperations WHERE open = 1 ORDER BY id DESC LIMIT 1));
+END;
+`
+
+const M017_TaskHistorySchemaDown = `
+DROP TRIGGER IF EXISTS history_tasks_insert;
+DROP TRIGGER IF EXISTS history_tasks_delete;
+DROP TRIGGER IF EXISTS history_tasks_title;
+DROP TRIGGER IF EXISTS history_tasks_description;
+DROP TRIGGER IF EXISTS history_tasks_priority;
+DROP TRIGGER IF EXISTS history_tasks_dueUtc;
+DROP TRIGGER IF EXISTS history_tasks_state;
+DROP TRIGGER IF EXISTS history_tasks_next;
+DROP TRIGGER IF EXISTS history_taskTags_insert;
+DROP TRIGGER IF EXISTS history_taskTags_delete;
+DROP TRIGGER IF EXISTS history_taskProjects_insert;
+DROP TRIGGER IF EXISTS history_taskProjects_delete;
+DROP TRIGGER IF EXISTS history_taskDependencies_insert;
+DROP TRIGGER IF EXISTS history_taskDependencies_delete;
+
+DROP TABLE IF EXISTS taskHistory;
+DROP TABLE IF EXISTS taskHistory;
+`
+
+// TaskChange is a change to a single field of a task e.g the priority went from low to high
+type TaskChange struct {
+	ID         int64  `json:"id" db:"id"`                   // Unique identifier
+	TaskID     int64  `json:"taskId" db:"taskId"`           // The task that was changed
+	Field      string `json:"field" db:"field"`             // e.g priority, tags or created
+	OldValue   string `json:"oldValue" db:"oldValue"`       // e.g low, empty when a value was added
+	NewValue   string `json:"newValue" db:"newValue"`       // e.g high, empty when a value was removed
+	Source     string `json:"source" db:"source"`           // The program that made the change e.g modify 1 priority:high
+	ChangedUtc string `json:"changedUtc" db:"changedAtUtc"` // When the change was made
+}
+
+// String describes the change e.g priority: low -> high or tags: +home
+func (change *TaskChange) String() string {
+	switch change.Field {
+	case "created", "deleted":
+		return change.Field
+	case "tags", "project", "depends":
+		if change.OldValue == "" {
+			return fmt.Sprintf("%s: +%s", change.Field, change.NewValue)
+		}
+		return fmt.Sprintf("%s: -%s", change.Field, change.OldValue)
+	}
+	var value = func(value string) string {
+		if value == "" {
+			return "(none)"
+		}
+		return value
+	}
+	return fmt.Sprintf("%s: %s -> %s", change.Field, value(change.OldValue), value(change.NewValue))
+}
+
+// ListTaskHistory returns the changes made to the task, the oldest first
+func (store *Store) ListTaskHistory(ctx context.Context, taskId int64) ([]TaskChange, error) {
+	var changes = []TaskChange{}
+	var err = store.Con.SelectContext(ctx, &changes, `
+		SELECT
+			id, taskId, field,
+			COALESCE(oldValue, '') AS oldValue,
+			COALESCE(newValue, '') AS newValue,
+			COALESCE(source, '') AS source,
+			changedAtUtc
+		FROM taskHistory
+		WHERE taskId = ?
+		ORDER BY id ASC`,
+		taskId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the history of task %d: %w", taskId, err)
+	}
+	return changes, nil
+}
+
+// labelHistoryTx sets the source of the changes recorded after the change with the id,
+// used by UndoTx because the undo is not a journaled operation
+// NOTE: the transaction is not rolled back on error
+func (store *Store) labelHistoryTx(tx *sqlx.Tx, afterId int64, source string) error {
+	var _, err = tx.Exec(`UPDATE taskHistory SET source = ? WHERE id > ? AND source IS NULL`, source, afterId)
+	if err != nil {
+		return fmt.Errorf("Failed to label the task history: %w", err)
+	}
+	return nil
+}
+
+// lastHistoryIdTx returns the id of the most recent change, 0 when there are none
+func (store *Store) lastHistoryIdTx(tx *sqlx.Tx) (int64, error) {
+	var id int64
+	var err = tx.Get(&id, `SELECT COALESCE(MAX(id), 0) FROM taskHistory`)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to read the task history: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Task history", func() {
+	var store *Store
+	var ctx = context.Background()
+
+	// journaled runs the changes as an operation that can be undone e.g a program
+	var journaled = func(description string, changes func(tx *sqlx.Tx)) {
+		var tx = store.MustCreateTxTodo()
+		defer tx.Rollback()
+		Expect(store.BeginUndoOperationTx(tx, description)).To(Succeed())
+		changes(tx)
+		Expect(store.EndUndoOperationTx(tx)).To(Succeed())
+		Expect(tx.Commit()).To(Succeed())
+	}
+
+	var history = func(taskId int64) []string {
+		var changes, err = store.ListTaskHistory(ctx, taskId)
+		Expect(err).To(BeNil())
+		var described = make([]string, 0, len(changes))
+		for _, change := range changes {
+			described = append(described, change.String())
+		}
+		return described
+	}
+
+	BeforeEach(func() {
+		store = NewInMemoryStore()
+		DeferCleanup(store.Close)
+		journaled(`add "milk" +home`, func(tx *sqlx.Tx) {
+			var _, err = tx.Exec(`INSERT INTO tasks (title) VALUES ('milk'), ('bread')`)
+			Expect(err).To(BeNil())
+			var tagId int64
+			tagId, err = store.TagCreateTx("home", tx)
+			Expect(err).To(BeNil())
+			Expect(store.TagLinkTaskTx(tx, 1, tagId)).To(Succeed())
+		})
+	})
+
+	It("should record the changes to the fields of a task", func() {
+		journaled("modify 1 priority:high", func(tx *sqlx.Tx) {
+			var _, err = store.SetPriorityTx(tx, 1, TaskPriorityHigh)
+			Expect(err).To(BeNil())
+			_, err = tx.Exec(`UPDATE tasks SET title = 'oat milk' WHERE id = 1`)
+			Expect(err).To(BeNil())
+			_, err = tx.Exec(`UPDATE tasks SET dueUtc = '2024-01-31 00:00:00' WHERE id = 1`)
+			Expect(err).To(BeNil())
+		})
+		journaled("start 1", func(tx *sqlx.Tx) {
+			var _, err = store.StartTrackingTaskTimeTx(tx, 1)
+			Expect(err).To(BeNil())
+		})
+		journaled("depends 1 on 2", func(tx *sqlx.Tx) {
+			var _, err = tx.Exec(`INSERT INTO taskDependencies (taskId, dependsOnId) VALUES (1, 2)`)
+			Expect(err).To(BeNil())
+		})
+		Expect(history(1)).To(Equal([]string{
+			"created",
+			"tags: +home",
+			"priority: none -> high",
+			"title: milk -> oat milk",
+			"due: (none) -> 2024-01-31 00:00:00",
+			"status: pending -> started",
+			"depends: +2",
+		}))
+		Expect(history(2)).To(Equal([]string{"created"}))
+	})
+
+	It("should record the program that made the change", func() {
+		journaled("increase the priority of task 1", func(tx *sqlx.Tx) {
+			var _, err = store.IncreasePriorityTx(tx, 1)
+			Expect(err).To(BeNil())
+		})
+		var changes, err = store.ListTaskHistory(ctx, 1)
+		Expect(err).To(BeNil())
+		Expect(changes[0].Source).To(Equal(`add "milk" +home`))
+		Expect(changes[2].Source).To(Equal("increase the priority of task 1"))
+		Expect(changes[2].OldValue).To(Equal("none"))
+		Expect(changes[2].NewValue).To(Equal("low"))
+		Expect(changes[2].ChangedUtc).NotTo(BeEmpty())
+	})
+
+	It("should not record an update that does not change the value", func() {
+		journaled("modify 1 priority:none", func(tx *sqlx.Tx) {
+			var _, err = tx.Exec(`UPDATE tasks SET priority = 0 WHERE id = 1`)
+			Expect(err).To(BeNil())
+		})
+		Expect(history(1)).To(Equal([]string{"created", "tags: +home"}))
+	})
+
+	It("should keep the history of a deleted task without the removed links", func() {
+		journaled("delete task 1", func(tx *sqlx.Tx) {
+			var _, err = store.DeleteTaskByIdTx(tx, 1)
+			Expect(err).To(BeNil())
+		})
+		Expect(history(1)).To(Equal([]string{"created", "tags: +home", "deleted"}))
+	})
+
+	It("should record an undo as the source of the reverted changes", func() {
+		journaled("modify 1 priority:high", func(tx *sqlx.Tx) {
+			var _, err = store.SetPriorityTx(tx, 1, TaskPriorityHigh)
+			Expect(err).To(BeNil())
+		})
+		var tx = store.MustCreateTxTodo()
+		var _, err = store.UndoTx(tx)
+		Expect(err).To(BeNil())
+		Expect(tx.Commit()).To(Succeed())
+
+		var changes []TaskChange
+		changes, err = store.ListTaskHistory(ctx, 1)
+		Expect(err).To(BeNil())
+		var last = changes[len(changes)-1]
+		Expect(last.String()).To(Equal("priority: high -> none"))
+		Expect(last.Source).To(Equal("undo modify 1 priority:high"))
+	})
+})
@@ -11,7 +11,10 @@ CREATE TABLE IF NOT EXISTS taskProjects (
 	UNIQUE (taskId, projectId),
 	PRIMARY KEY (taskId, projectId)
 );
-PRAGMA user_version = 6;
+`
+
+const M007_TaskProjectsSchemaDown = `
+DROP TABLE IF EXISTS taskProjects;
 `
 
 // TaskProjectLink is a struct that represents a link between a task and a project
@@ -26,7 +29,13 @@ func (s *Store) ProjectLinkTaskTx(tx *sqlx.Tx, projectId, taskId int64) error {
 	return err
 }
 
-// ProjectUnlinkTaskTx will unlink a task from a project
+// ProjectUnlinkAllTx will unlink a task from every project it belongs to
+func (s *Store) ProjectUnlinkAllTx(tx *sqlx.Tx, taskId int64) error {
+	var _, err = tx.Exec(`DELETE FROM taskProjects WHERE taskId = ?`, taskId)
+	return err
+}
+
+// ProjectTasksList returns every link between tasks and projects
 func (s *Store) ProjectTasksList() ([]TaskProjectLink, error) {
 	var links []TaskProjectLink
 	err := s.Con.Select(&links, `SELECT * FROM taskProjects`)
@@ -0,0 +1,335 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const M012_TaskTemplateSchema = `
+CREATE TABLE IF NOT EXISTS taskTemplates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pattern TEXT NOT NULL,
+	untilUtc TEXT,
+	lastDueUtc TEXT NOT NULL,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+ALTER TABLE tasks ADD COLUMN templateId INTEGER REFERENCES taskTemplates(id) ON DELETE SET NULL;
+`
+
+// M012_TaskTemplateSchemaDown rebuilds the tasks table, SQLite cannot drop a column that
+// references another table
+const M012_TaskTemplateSchemaDown = `
+CREATE TABLE tasksWithoutTemplates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	description TEXT,
+	priority INTEGER NOT NULL DEFAULT 0 CHECK (priority >= 0 AND priority <= 3),
+	dueUtc TEXT,
+	updatedAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	completedAtUtc TEXT,
+	state INTEGER NOT NULL DEFAULT 0 CHECK (state >= 0 AND state <= 2),
+	next INTEGER NOT NULL DEFAULT 0 CHECK (next >= 0 AND next <= 1)
+);
+INSERT INTO tasksWithoutTemplates (
+	id, title, description, priority, dueUtc, updatedAtUtc, createdAtUtc, completedAtUtc, state, next
+)
+SELECT id, title, description, priority, dueUtc, updatedAtUtc, createdAtUtc, completedAtUtc, state, next
+FROM tasks;
+DROP TABLE tasks;
+ALTER TABLE tasksWithoutTemplates RENAME TO tasks;
+DROP TABLE IF EXISTS taskTemplates;
+`
+
+// TaskTemplate is used to generate the instances of a recurring task
+type TaskTemplate struct {
+	ID         int64          `db:"id"`           // Unique identifier
+	Pattern    string         `db:"pattern"`      // How often the task recurs e.g weekly or 2w
+	UntilUtc   sql.NullString `db:"untilUtc"`     // Optional, no instances are due after this date
+	LastDueUtc string         `db:"lastDueUtc"`   // Due date of the latest generated instance
+	CreatedUtc string         `db:"createdAtUtc"` // Created timestamp
+}
+
+// Recurrence is a parsed recurrence pattern e.g 2w is every two weeks
+type Recurrence struct {
+	Amount int    // e.g 2
+	Unit   string // h, d, w, m or y
+}
+
+var recurrenceNames = map[string]Recurrence{
+	"hourly":    {Amount: 1, Unit: "h"},
+	"daily":     {Amount: 1, Unit: "d"},
+	"weekly":    {Amount: 1, Unit: "w"},
+	"biweekly":  {Amount: 2, Unit: "w"},
+	"monthly":   {Amount: 1, Unit: "m"},
+	"quarterly": {Amount: 3, Unit: "m"},
+	"yearly":    {Amount: 1, Unit: "y"},
+	"annual":    {Amount: 1, Unit: "y"},
+}
+
+var recurrencePattern = regexp.MustCompile(`^(\d+)(h|d|w|m|y)$`)
+
+// ParseRecurrence parses a named (weekly) or a numeric (2w) recurrence pattern
+func ParseRecurrence(pattern string) (Recurrence, error) {
+	var lower = strings.ToLower(pattern)
+	if recurrence, ok := recurrenceNames[lower]; ok {
+		return recurrence, nil
+	}
+	var match = recurrencePattern.FindStringSubmatch(lower)
+	if match == nil {
+		return Recurrence{}, fmt.Errorf(
+			"Unknown recurrence: %s, options are: daily, weekly, biweekly, monthly, quarterly, yearly or a number followed by h, d, w, m or y e.g 2w",
+			pattern,
+		)
+	}
+	var amount, err = strconv.Atoi(match[1])
+	if err != nil || amount <= 0 {
+		return Recurrence{}, fmt.Errorf("Recurrence must be greater than zero: %s", pattern)
+	}
+	return Recurrence{Amount: amount, Unit: match[2]}, nil
+}
+
+// Next returns the date one period after t
+func (r Recurrence) Next(t time.Time) time.Time {
+	switch r.Unit {
+	case "h":
+		return t.Add(time.Duration(r.Amount) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, r.Amount)
+	case "w":
+		return t.AddDate(0, 0, r.Amount*7)
+	case "m":
+		return t.AddDate(0, r.Amount, 0)
+	default:
+		return t.AddDate(r.Amount, 0, 0)
+	}
+}
+
+// TemplateCreateTx creates a recurrence template and links the first instance to it
+// NOTE: the transaction is not rolled back on error
+func (store *Store) TemplateCreateTx(tx *sqlx.Tx, taskId int64, pattern string, until sql.NullString) (int64, error) {
+	var sql = `
+	INSERT INTO taskTemplates (pattern, untilUtc, lastDueUtc)
+	SELECT ?, ?, dueUtc FROM tasks WHERE id = ? AND dueUtc IS NOT NULL
+	`
+	var res, err = tx.Exec(sql, strings.ToLower(pattern), until, taskId)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create task template: %w", err)
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, fmt.Errorf("Recurring task %d requires a due date", taskId)
+	}
+	var templateId int64
+	templateId, err = res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(`UPDATE tasks SET templateId = ? WHERE id = ?`, templateId, taskId)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to link task to template: %w", err)
+	}
+	return templateId, nil
+}
+
+// TemplateGetByIdTx returns the recurrence template
+func (store *Store) TemplateGetByIdTx(tx *sqlx.Tx, templateId int64) (*TaskTemplate, error) {
+	var template TaskTemplate
+	var err = tx.Get(&template, `SELECT * FROM taskTemplates WHERE id = ?`, templateId)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get task template: %w", err)
+	}
+	return &template, nil
+}
+
+// TemplateListTx returns every recurrence template
+func (store *Store) TemplateListTx(tx *sqlx.Tx) ([]TaskTemplate, error) {
+	var templates []TaskTemplate
+	var err = tx.Select(&templates, `SELECT * FROM taskTemplates ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list task templates: %w", err)
+	}
+	return templates, nil
+}
+
+// NextDue returns the due date of the next instance, false is returned
+// once the template has passed its until date
+func (template *TaskTemplate) NextDue() (time.Time, bool, error) {
+	var recurrence, err = ParseRecurrence(template.Pattern)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	var lastDue time.Time
+	lastDue, err = time.Parse(SQLITE_TIME_FORMAT, template.LastDueUtc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Failed to parse the last due date of template %d: %w", template.ID, err)
+	}
+	var next = recurrence.Next(lastDue)
+	if template.UntilUtc.Valid {
+		var until time.Time
+		until, err = time.Parse(SQLITE_TIME_FORMAT, template.UntilUtc.String)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("Failed to parse the until date of template %d: %w", template.ID, err)
+		}
+		if next.After(until) {
+			return time.Time{}, false, nil
+		}
+	}
+	return next, true, nil
+}
+
+// TemplateCreateNextInstanceTx creates the next instance of the template, the title, description,
+// priority, tags and projects are copied from the latest instance.
+// Returns 0 if the template has ended or every instance has been deleted
+// NOTE: the transaction is not rolled back on error
+func (store *Store) TemplateCreateNextInstanceTx(tx *sqlx.Tx, template *TaskTemplate) (int64, error) {
+	var due, ok, err = template.NextDue()
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	var sourceId int64
+	err = tx.Get(&sourceId, `SELECT COALESCE(MAX(id), 0) FROM tasks WHERE templateId = ?`, template.ID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get the latest instance of template %d: %w", template.ID, err)
+	}
+	if sourceId == 0 {
+		return 0, nil
+	}
+
+	var dueUtc = due.Format(SQLITE_TIME_FORMAT)
+	var res sql.Result
+	res, err = tx.Exec(`
+	INSERT INTO tasks (title, description, priority, dueUtc, templateId)
+	SELECT title, description, priority, ?, templateId FROM tasks WHERE id = ?
+	`, dueUtc, sourceId)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to create the next instance of template %d: %w", template.ID, err)
+	}
+	var taskId int64
+	taskId, err = res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO taskTags (taskID, tagID) SELECT ?, tagID FROM taskTags WHERE taskID = ?`, taskId, sourceId)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to copy tags to the next instance: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO taskProjects (taskId, projectId) SELECT ?, projectId FROM taskProjects WHERE taskId = ?`, taskId, sourceId)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to copy projects to the next instance: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE taskTemplates SET lastDueUtc = ? WHERE id = ?`, dueUtc, template.ID)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to update template %d: %w", template.ID, err)
+	}
+	template.LastDueUtc = dueUtc
+	return taskId, nil
+}
+
+// TemplateCompleteInstanceTx creates the next instance once the completed task was the
+// last outstanding instance of its template, nothing happens for tasks that do not recur
+// NOTE: the transaction is not rolled back on error
+func (store *Store) TemplateCompleteInstanceTx(tx *sqlx.Tx, taskId int64) error {
+	var templateId sql.NullInt64
+	var err = tx.Get(&templateId, `SELECT templateId FROM tasks WHERE id = ?`, taskId)
+	if err != nil {
+		return fmt.Errorf("Failed to get the template of task %d: %w", taskId, err)
+	}
+	if !templateId.Valid {
+		return nil
+	}
+
+	var outstanding int
+	err = tx.Get(&outstanding, `SELECT COUNT(*) FROM tasks WHERE templateId = ? AND state != ?`, templateId.Int64, TaskStateCompleted)
+	if err != nil {
+		return fmt.Errorf("Failed to count outstanding instances: %w", err)
+	}
+	if outstanding > 0 {
+		return nil
+	}
+
+	var template *TaskTemplate
+	template, err = store.TemplateGetByIdTx(tx, templateId.Int64)
+	if err != nil {
+		return err
+	}
+	_, err = store.TemplateCreateNextInstanceTx(tx, template)
+	return err
+}
+
+// MaxMissedInstances is the most instances of a template created on startup, the older
+// periods are skipped e.g a daily task that was missed for a month creates a week of tasks
+const MaxMissedInstances = 7
+
+// TemplateGenerateMissedTx creates an instance for every period that was due before now,
+// this is run on startup so that no period is skipped while the app was closed. Only the
+// latest MaxMissedInstances periods of each template are created
+// NOTE: the transaction is not rolled back on error
+func (store *Store) TemplateGenerateMissedTx(tx *sqlx.Tx, now time.Time) (int, error) {
+	var templates, err = store.TemplateListTx(tx)
+	if err != nil {
+		return 0, err
+	}
+	var created = 0
+	for i := range templates {
+		var template = &templates[i]
+		err = template.skipMissed(now, MaxMissedInstances)
+		if err != nil {
+			return created, err
+		}
+		for {
+			var due, ok, dueErr = template.NextDue()
+			if dueErr != nil {
+				return created, dueErr
+			}
+			if !ok || due.After(now.UTC()) {
+				break
+			}
+			var taskId int64
+			taskId, err = store.TemplateCreateNextInstanceTx(tx, template)
+			if err != nil {
+				return created, err
+			}
+			if taskId == 0 {
+				break
+			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// skipMissed moves the last due date of the template forward so that at most limit
+// periods are due before now, the template is not saved
+func (template *TaskTemplate) skipMissed(now time.Time, limit int) error {
+	var missed = []string{template.LastDueUtc}
+	var original = template.LastDueUtc
+	for {
+		var due, ok, err = template.NextDue()
+		if err != nil {
+			template.LastDueUtc = original
+			return err
+		}
+		if !ok || due.After(now.UTC()) {
+			break
+		}
+		template.LastDueUtc = due.Format(SQLITE_TIME_FORMAT)
+		missed = append(missed, template.LastDueUtc)
+	}
+	// missed starts with the last instance that was created, the periods after it are due
+	var skipped = max(len(missed)-1-limit, 0)
+	template.LastDueUtc = missed[skipped]
+	return nil
+}
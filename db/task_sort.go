@@ -0,0 +1,236 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/config"
+)
+
+// TaskSortKey orders tasks by a single field e.g due+ or urgency-
+type TaskSortKey struct {
+	Field         string         // e.g due
+	Descending    bool           // true for urgency-, false for due+ or due
+	AttributeType config.UDAType // Set when the field is a user defined attribute e.g number
+}
+
+// String returns the key as it is written in a sort e.g due+
+func (key TaskSortKey) String() string {
+	if key.Descending {
+		return key.Field + "-"
+	}
+	return key.Field + "+"
+}
+
+// field returns how the tasks are sorted by the key, false is returned for unknown fields
+func (key TaskSortKey) field() (taskSortField, bool) {
+	if key.AttributeType != "" {
+		return attributeSortField(key.Field, key.AttributeType), true
+	}
+	var field, ok = taskSortFields[key.Field]
+	return field, ok
+}
+
+// DefaultTaskSort is the order of the task list when a sort is not given
+var DefaultTaskSort = []TaskSortKey{{Field: "urgency", Descending: true}}
+
+// SearchTaskSort is the order of the results of a search when a sort is not given
+var SearchTaskSort = []TaskSortKey{{Field: "relevance", Descending: true}, {Field: "urgency", Descending: true}}
+
+// taskSortField is a field that tasks can be sorted by, fields without an SQL
+// expression e.g urgency are derived in Go and can only be sorted in Go
+type taskSortField struct {
+	sql     string // Expression against listTasksSql e.g tasks.dueUtc
+	nulls   func(task *TaskDetailed) bool
+	compare func(a *TaskDetailed, b *TaskDetailed) int
+}
+
+// taskSortFields contains every field that can be used in a sort
+var taskSortFields = map[string]taskSortField{
+	"id": {
+		sql:     "tasks.id",
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.ID, b.ID) },
+	},
+	"title": {
+		sql: "tasks.title COLLATE NOCASE",
+		compare: func(a, b *TaskDetailed) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		},
+	},
+	"priority": {
+		sql:     "tasks.priority",
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.Priority, b.Priority) },
+	},
+	"due": {
+		sql:     "tasks.dueUtc",
+		nulls:   func(task *TaskDetailed) bool { return !task.Due.Valid },
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.Due.String, b.Due.String) },
+	},
+	"created": {
+		sql:     "tasks.createdAtUtc",
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.CreatedUtc, b.CreatedUtc) },
+	},
+	"updated": {
+		sql:     "tasks.updatedAtUtc",
+		nulls:   func(task *TaskDetailed) bool { return !task.UpdatedAtUtc.Valid },
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.UpdatedAtUtc.String, b.UpdatedAtUtc.String) },
+	},
+	"completed": {
+		sql:     "tasks.completedAtUtc",
+		nulls:   func(task *TaskDetailed) bool { return !task.CompletedUtc.Valid },
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.CompletedUtc.String, b.CompletedUtc.String) },
+	},
+	"state": {
+		sql:     "tasks.state",
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.State, b.State) },
+	},
+	"next": {
+		sql:     "tasks.next",
+		compare: func(a, b *TaskDetailed) int { return compareBool(a.Next, b.Next) },
+	},
+	"project": {
+		sql:     "projectNames",
+		nulls:   func(task *TaskDetailed) bool { return !task.ProjectNames.Valid },
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.ProjectNames.String, b.ProjectNames.String) },
+	},
+	"tags": {
+		sql:     "tagNames",
+		nulls:   func(task *TaskDetailed) bool { return !task.TagNames.Valid },
+		compare: func(a, b *TaskDetailed) int { return strings.Compare(a.TagNames.String, b.TagNames.String) },
+	},
+	"active": {
+		sql:     "inprogress",
+		compare: func(a, b *TaskDetailed) int { return compareBool(a.Inprogress, b.Inprogress) },
+	},
+	"blocked": {
+		sql:     "blocked",
+		compare: func(a, b *TaskDetailed) int { return compareBool(a.Blocked, b.Blocked) },
+	},
+	"blocking": {
+		sql:     "blocking",
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.Blocking, b.Blocking) },
+	},
+	"urgency": {
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.Urgency(), b.Urgency()) },
+	},
+	"relevance": {
+		compare: func(a, b *TaskDetailed) int { return cmp.Compare(a.Relevance, b.Relevance) },
+	},
+}
+
+// TaskSortFields returns the name of every field that can be used in a sort
+func TaskSortFields() []string {
+	var names = make([]string, 0, len(taskSortFields))
+	for name := range taskSortFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseTaskSort parses a comma separated sort e.g due+,priority-,urgency-
+// a field without a direction is sorted in ascending order, udas can also be sorted by
+func ParseTaskSort(spec string, udas map[string]config.UDA) ([]TaskSortKey, error) {
+	var keys = make([]TaskSortKey, 0)
+	for _, part := range strings.Split(spec, ",") {
+		var key = TaskSortKey{Field: strings.ToLower(strings.TrimSpace(part))}
+		if strings.HasSuffix(key.Field, "-") {
+			key.Descending = true
+		}
+		key.Field = strings.TrimRight(key.Field, "+-")
+		if key.Field == "" {
+			return nil, fmt.Errorf("Expected a field to sort by e.g sort:due+,priority-")
+		}
+		if uda, ok := udas[key.Field]; ok {
+			key.AttributeType = uda.Type
+		} else if _, ok := taskSortFields[key.Field]; !ok {
+			return nil, fmt.Errorf("Cannot sort by %s, expected one of %s", key.Field, strings.Join(TaskSortFields(), ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// taskOrderBy returns the ORDER BY clause for the keys up to the first Go only field
+// e.g urgency, ties are broken by the task id. SortTasks orders by the remaining keys
+func taskOrderBy(keys []TaskSortKey) string {
+	var terms = make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		var field, _ = key.field()
+		if field.sql == "" {
+			break
+		}
+		var direction = "ASC"
+		if key.Descending {
+			direction = "DESC"
+		}
+		if field.nulls != nil {
+			terms = append(terms, fmt.Sprintf("%s IS NULL ASC", field.sql))
+		}
+		terms = append(terms, fmt.Sprintf("%s %s", field.sql, direction))
+	}
+	terms = append(terms, "tasks.id ASC")
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// needsGoSort returns true if any of the keys cannot be sorted in SQL e.g urgency
+func needsGoSort(keys []TaskSortKey) bool {
+	for _, key := range keys {
+		if field, _ := key.field(); field.sql == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SortTasks stable sorts the tasks by the keys, missing values e.g tasks without
+// a due date are always placed last
+func SortTasks(tasks []TaskDetailed, keys []TaskSortKey) {
+	for i := range tasks {
+		tasks[i].Urgency() // cache before the tasks are copied by the comparison
+		tasks[i].parseAttributes()
+	}
+	var fields = make([]taskSortField, len(keys))
+	var known = make([]bool, len(keys))
+	for i, key := range keys {
+		fields[i], known[i] = key.field()
+	}
+	slices.SortStableFunc(tasks, func(a, b TaskDetailed) int {
+		for i, key := range keys {
+			var field = fields[i]
+			if !known[i] {
+				continue
+			}
+			if field.nulls != nil {
+				var aNull, bNull = field.nulls(&a), field.nulls(&b)
+				if aNull != bNull {
+					return compareBool(aNull, bNull)
+				}
+				if aNull {
+					continue
+				}
+			}
+			var result = field.compare(&a, &b)
+			if key.Descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+}
+
+func compareBool(a bool, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
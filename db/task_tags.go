@@ -16,7 +16,10 @@ CREATE TABLE IF NOT EXISTS taskTags (
 	FOREIGN KEY(taskID) REFERENCES tasks(id) ON DELETE CASCADE,
 	FOREIGN KEY(tagID) REFERENCES tags(id) ON DELETE CASCADE
 );
-PRAGMA user_version = 2;
+`
+
+const M002_TaskTagsSchemaDown = `
+DROP TABLE IF EXISTS taskTags;
 `
 
 // Used to link tasks and tags together
@@ -61,3 +64,16 @@ func (store *Store) TagUnlinkTaskTx(tx *sqlx.Tx, taskId, tagId int64) error {
 	}
 	return nil
 }
+
+// TagUnlinkTaskByNameTx will unlink the tag with the name specified inside of a transaction,
+// nothing happens if the tag does not exist
+func (store *Store) TagUnlinkTaskByNameTx(tx *sqlx.Tx, taskId int64, name string) error {
+	_, err := tx.Exec(
+		"DELETE FROM taskTags WHERE taskID = ? AND tagID IN (SELECT id FROM tags WHERE name = ?)",
+		taskId, name,
+	)
+	if err != nil {
+		return fmt.Errorf("Failed to unlink task and tag: %w", err)
+	}
+	return nil
+}
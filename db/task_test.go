@@ -1,6 +1,8 @@
 package db
 
 import (
+	"database/sql"
+	"github.com/luke-goddard/taskninja/config"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"testing"
@@ -31,3 +33,108 @@ var _ = Describe("Task Pretty Age", func() {
 		Entry("3w3d", time.Duration(1)*time.Hour*526, "3w3d"),
 	)
 })
+
+var _ = Describe("Sorting tasks", func() {
+	var task = func(id int64, due string, priority TaskPriority) TaskDetailed {
+		var detailed = TaskDetailed{}
+		detailed.ID = id
+		detailed.Priority = priority
+		detailed.Due.String = due
+		detailed.Due.Valid = due != ""
+		return detailed
+	}
+	var ids = func(tasks []TaskDetailed) []int64 {
+		var ids = []int64{}
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	It("should place tasks without a due date last in both directions", func() {
+		var tasks = []TaskDetailed{task(1, "", 0), task(2, "2024-02-01 00:00:00", 0), task(3, "2024-01-01 00:00:00", 0)}
+		SortTasks(tasks, []TaskSortKey{{Field: "due"}})
+		Expect(ids(tasks)).To(Equal([]int64{3, 2, 1}))
+		SortTasks(tasks, []TaskSortKey{{Field: "due", Descending: true}})
+		Expect(ids(tasks)).To(Equal([]int64{2, 3, 1}))
+	})
+
+	It("should keep the order of ties", func() {
+		var tasks = []TaskDetailed{task(3, "", 1), task(1, "", 3), task(2, "", 1)}
+		SortTasks(tasks, []TaskSortKey{{Field: "priority"}})
+		Expect(ids(tasks)).To(Equal([]int64{3, 2, 1}))
+	})
+
+	It("should push the keys before urgency down to ORDER BY", func() {
+		var keys, err = ParseTaskSort("due+,priority-,urgency-,id", nil)
+		Expect(err).To(BeNil())
+		Expect(taskOrderBy(keys)).To(Equal(
+			"ORDER BY tasks.dueUtc IS NULL ASC, tasks.dueUtc ASC, tasks.priority DESC, tasks.id ASC",
+		))
+		Expect(needsGoSort(keys)).To(BeTrue())
+	})
+})
+
+var _ = Describe("User defined attributes", func() {
+	DescribeTable("should normalize the value",
+		func(uda config.UDA, value string, expected string) {
+			var result, err = NormalizeAttribute("estimate", uda, value)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(expected))
+		},
+		Entry("a string", config.UDA{Type: config.UDATypeString}, "OPS-12", "OPS-12"),
+		Entry("an allowed value", config.UDA{Values: []string{"acme", "globex"}}, "ACME", "acme"),
+		Entry("a number", config.UDA{Type: config.UDATypeNumber}, "3.50", "3.5"),
+		Entry("a duration in minutes", config.UDA{Type: config.UDATypeDuration}, "90m", "5400"),
+		Entry("a duration in days", config.UDA{Type: config.UDATypeDuration}, "2d", "172800"),
+		Entry("a duration in weeks", config.UDA{Type: config.UDATypeDuration}, "1w", "604800"),
+		Entry("a date", config.UDA{Type: config.UDATypeDate}, "2024-01-02 00:00:00", "2024-01-02 00:00:00"),
+	)
+
+	DescribeTable("should reject an invalid value",
+		func(uda config.UDA, value string) {
+			var _, err = NormalizeAttribute("estimate", uda, value)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("a value that is not allowed", config.UDA{Values: []string{"acme"}}, "initech"),
+		Entry("a number", config.UDA{Type: config.UDATypeNumber}, "lots"),
+		Entry("a duration", config.UDA{Type: config.UDATypeDuration}, "soon"),
+		Entry("a negative duration", config.UDA{Type: config.UDATypeDuration}, "-1h"),
+		Entry("a date", config.UDA{Type: config.UDATypeDate}, "tomorrow"),
+	)
+
+	It("should reject attributes with the name of a built in field", func() {
+		var store = &Store{}
+		Expect(store.SetUDAs(map[string]config.UDA{"Priority": {}})).NotTo(Succeed())
+		Expect(store.SetUDAs(map[string]config.UDA{"my-field": {}})).NotTo(Succeed())
+		Expect(store.SetUDAs(map[string]config.UDA{"size": {Type: "colour"}})).NotTo(Succeed())
+	})
+
+	It("should default the type to string and lowercase the names", func() {
+		var store = &Store{}
+		Expect(store.SetUDAs(map[string]config.UDA{"Ticket": {}, "estimate": {Type: config.UDATypeNumber}})).To(Succeed())
+		Expect(store.UDANames()).To(Equal([]string{"estimate", "ticket"}))
+		var uda, ok = store.UDA("TICKET")
+		Expect(ok).To(BeTrue())
+		Expect(uda.Type).To(Equal(config.UDATypeString))
+	})
+
+	It("should sort by an attribute in SQL", func() {
+		var keys, err = ParseTaskSort("estimate-", map[string]config.UDA{"estimate": {Type: config.UDATypeNumber}})
+		Expect(err).To(BeNil())
+		Expect(keys).To(Equal([]TaskSortKey{{Field: "estimate", Descending: true, AttributeType: config.UDATypeNumber}}))
+		Expect(taskOrderBy(keys)).To(ContainSubstring("CAST((SELECT taskAttributes.value FROM taskAttributes"))
+		Expect(needsGoSort(keys)).To(BeFalse())
+	})
+
+	It("should sort by an attribute in Go with missing values last", func() {
+		var keys, _ = ParseTaskSort("estimate-", map[string]config.UDA{"estimate": {Type: config.UDATypeNumber}})
+		var tasks = []TaskDetailed{
+			{Task: Task{ID: 1}},
+			{Task: Task{ID: 2}, Attributes: sql.NullString{String: `{"estimate":"3"}`, Valid: true}},
+			{Task: Task{ID: 3}, Attributes: sql.NullString{String: `{"estimate":"12"}`, Valid: true}},
+		}
+		SortTasks(tasks, keys)
+		Expect([]int64{tasks[0].ID, tasks[1].ID, tasks[2].ID}).To(Equal([]int64{3, 2, 1}))
+	})
+})
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/jmoiron/sqlx"
 )
 
 const M008_TimeTrackingSchema = `
@@ -15,7 +17,10 @@ CREATE TABLE IF NOT EXISTS taskTime (
 	totalTime TEXT,
 	FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE
 );
-PRAGMA user_version = 8;
+`
+
+const M008_TimeTrackingSchemaDown = `
+DROP TABLE IF EXISTS taskTime;
 `
 
 // TaskTime represents the time tracking for a task
@@ -29,20 +34,36 @@ type TaskTime struct {
 
 // StartTrackingTaskTime will start tracking time for a task
 func (store *Store) StartTrackingTaskTime(ctx context.Context, taskId int64) error {
-	// 1. Set the task state to started
-	// 2. If there are no times for the task, insert a new time
-	// 3. If there are times for the task, do not insert a new time
-
-	var tx, err = store.Con.Beginx()
+	var tx, err = store.Con.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("error starting task: %w", err)
 	}
 	defer tx.Rollback()
 
+	_, err = store.StartTrackingTaskTimeTx(tx, taskId)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// StartTrackingTaskTimeTx will start tracking time for a task inside of a transaction,
+// returns false if the task does not exist
+// NOTE: the transaction is not rolled back on error
+func (store *Store) StartTrackingTaskTimeTx(tx *sqlx.Tx, taskId int64) (bool, error) {
+	// 1. Set the task state to started
+	// 2. If there are no times for the task, insert a new time
+	// 3. If there are times for the task, do not insert a new time
+
 	var sql = `UPDATE tasks SET state = ? WHERE id = ?;`
-	_, err = tx.ExecContext(ctx, sql, TaskStateStarted, taskId)
+	var res, err = tx.Exec(sql, TaskStateStarted, taskId)
 	if err != nil {
-		return fmt.Errorf("error updating task state while starting task: %w", err)
+		return false, fmt.Errorf("error updating task state while starting task: %w", err)
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
 	}
 
 	sql = `
@@ -50,26 +71,41 @@ func (store *Store) StartTrackingTaskTime(ctx context.Context, taskId int64) err
 	  SELECT ?
 	  WHERE NOT EXISTS (SELECT 1 FROM taskTime WHERE taskId = ? AND endTimeUtc IS NULL);
 	`
-	_, err = tx.ExecContext(ctx, sql, taskId, taskId)
+	_, err = tx.Exec(sql, taskId, taskId)
 	if err != nil {
-		return fmt.Errorf("error inserting task time while starting task: %w", err)
+		return false, fmt.Errorf("error inserting task time while starting task: %w", err)
 	}
-
-	return tx.Commit()
+	return true, nil
 }
 
 // StopTrackingTaskTime will stop tracking time for a task
 func (store *Store) StopTrackingTaskTime(ctx context.Context, id int64) error {
-	var sql = `UPDATE tasks SET state = 0 WHERE id = ?;`
-	var tx, err = store.Con.Beginx()
-	defer tx.Rollback()
+	var tx, err = store.Con.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = tx.Exec(sql, id)
+	_, err = store.StopTrackingTaskTimeTx(tx, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// StopTrackingTaskTimeTx will stop tracking time for a task inside of a transaction,
+// returns false if the task does not exist
+// NOTE: the transaction is not rolled back on error
+func (store *Store) StopTrackingTaskTimeTx(tx *sqlx.Tx, id int64) (bool, error) {
+	var sql = `UPDATE tasks SET state = 0 WHERE id = ?;`
+	var res, err = tx.Exec(sql, id)
 	if err != nil {
-		return fmt.Errorf("error updating task state while stopping task: %w", err)
+		return false, fmt.Errorf("error updating task state while stopping task: %w", err)
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
 	}
 
 	sql = `
@@ -80,12 +116,11 @@ func (store *Store) StopTrackingTaskTime(ctx context.Context, id int64) error {
 	WHERE
 		taskId = ? AND endTimeUtc IS NULL;
 	`
-	_, err = tx.ExecContext(ctx, sql, id)
+	_, err = tx.Exec(sql, id)
 	if err != nil {
-		return fmt.Errorf("error updating task time while stopping task: %w", err)
+		return false, fmt.Errorf("error updating task time while stopping task: %w", err)
 	}
-
-	return tx.Commit()
+	return true, nil
 }
 
 // GetTaskTimes will get all the times for a task
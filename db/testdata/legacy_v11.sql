@@ -0,0 +1,69 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	description TEXT,
+	priority INTEGER NOT NULL DEFAULT 0 CHECK (priority >= 0 AND priority <= 3),
+	dueUtc TEXT,
+	updatedAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	completedAtUtc TEXT
+, state INTEGER NOT NULL DEFAULT 0 CHECK (state >= 0 AND state <= 2), next INTEGER NOT NULL DEFAULT 0 CHECK (next >= 0 AND next <= 1));
+INSERT INTO tasks VALUES(1,'buy milk',NULL,1,NULL,'2026-10-18 10:55:35','2026-10-18 10:55:35',NULL,0,1);
+INSERT INTO tasks VALUES(2,'file taxes',NULL,3,NULL,'2026-10-18 10:55:35','2026-10-18 10:55:35',NULL,2,0);
+INSERT INTO tasks VALUES(3,'paint fence',NULL,0,NULL,'2026-10-18 10:55:35','2026-10-18 10:55:35',NULL,1,0);
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+INSERT INTO tags VALUES(1,'home');
+INSERT INTO tags VALUES(2,'money');
+CREATE TABLE taskTags (
+	taskID INTEGER NOT NULL,
+	tagID INTEGER NOT NULL,
+	UNIQUE (taskID, tagID),
+	PRIMARY KEY(taskID, tagID),
+	FOREIGN KEY(taskID) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY(tagID) REFERENCES tags(id) ON DELETE CASCADE
+);
+INSERT INTO taskTags VALUES(1,1);
+INSERT INTO taskTags VALUES(2,2);
+INSERT INTO taskTags VALUES(3,1);
+CREATE TABLE projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL
+);
+INSERT INTO projects VALUES(1,'house');
+CREATE TABLE taskProjects (
+	taskId INTEGER NOT NULL,
+	projectId INTEGER NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY (projectId) REFERENCES projects(id) ON DELETE CASCADE,
+	UNIQUE (taskId, projectId),
+	PRIMARY KEY (taskId, projectId)
+);
+INSERT INTO taskProjects VALUES(3,1);
+CREATE TABLE taskTime (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taskId INTEGER NOT NULL,
+	startTimeUtc TEXT NOT NULL DEFAULT current_timestamp,
+	endTimeUtc TEXT,
+	totalTime TEXT,
+	FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE
+);
+INSERT INTO taskTime VALUES(1,3,'2024-01-01 09:00:00',NULL,NULL);
+CREATE TABLE taskDependencies (
+	taskId INTEGER NOT NULL,
+	dependsOnId INTEGER NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY (dependsOnId) REFERENCES tasks(id) ON DELETE CASCADE,
+	UNIQUE (taskId, dependsOnId)
+);
+INSERT INTO taskDependencies VALUES(3,1);
+INSERT INTO sqlite_sequence VALUES('tasks',3);
+INSERT INTO sqlite_sequence VALUES('tags',2);
+INSERT INTO sqlite_sequence VALUES('projects',1);
+INSERT INTO sqlite_sequence VALUES('taskTime',1);
+COMMIT;
+PRAGMA user_version = 11;
@@ -0,0 +1,287 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	description TEXT,
+	priority INTEGER NOT NULL DEFAULT 0 CHECK (priority >= 0 AND priority <= 3),
+	dueUtc TEXT,
+	updatedAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	completedAtUtc TEXT
+, state INTEGER NOT NULL DEFAULT 0 CHECK (state >= 0 AND state <= 2), next INTEGER NOT NULL DEFAULT 0 CHECK (next >= 0 AND next <= 1), templateId INTEGER REFERENCES taskTemplates(id) ON DELETE SET NULL);
+INSERT INTO tasks VALUES(1,'buy milk',NULL,1,'2024-01-31 00:00:00','2026-10-18 10:55:35','2026-10-18 10:55:35',NULL,0,1,NULL);
+INSERT INTO tasks VALUES(2,'file taxes',NULL,3,NULL,'2026-10-18 10:55:35','2026-10-18 10:55:35',NULL,2,0,NULL);
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+INSERT INTO tags VALUES(1,'home');
+CREATE TABLE taskTags (
+	taskID INTEGER NOT NULL,
+	tagID INTEGER NOT NULL,
+	UNIQUE (taskID, tagID),
+	PRIMARY KEY(taskID, tagID),
+	FOREIGN KEY(taskID) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY(tagID) REFERENCES tags(id) ON DELETE CASCADE
+);
+INSERT INTO taskTags VALUES(1,1);
+INSERT INTO taskTags VALUES(2,1);
+CREATE TABLE projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL
+);
+CREATE TABLE taskProjects (
+	taskId INTEGER NOT NULL,
+	projectId INTEGER NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY (projectId) REFERENCES projects(id) ON DELETE CASCADE,
+	UNIQUE (taskId, projectId),
+	PRIMARY KEY (taskId, projectId)
+);
+CREATE TABLE taskTime (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taskId INTEGER NOT NULL,
+	startTimeUtc TEXT NOT NULL DEFAULT current_timestamp,
+	endTimeUtc TEXT,
+	totalTime TEXT,
+	FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE
+);
+CREATE TABLE taskDependencies (
+	taskId INTEGER NOT NULL,
+	dependsOnId INTEGER NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY (dependsOnId) REFERENCES tasks(id) ON DELETE CASCADE,
+	UNIQUE (taskId, dependsOnId)
+);
+CREATE TABLE taskTemplates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pattern TEXT NOT NULL,
+	untilUtc TEXT,
+	lastDueUtc TEXT NOT NULL,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+CREATE TABLE taskAttributes (
+	taskId INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+	PRIMARY KEY (taskId, name)
+);
+INSERT INTO taskAttributes VALUES(1,'estimate','3');
+CREATE TABLE undoOperations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	description TEXT NOT NULL,
+	open INTEGER NOT NULL DEFAULT 1 CHECK (open >= 0 AND open <= 1),
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+INSERT INTO undoOperations VALUES(1,'add "buy milk"',0,'2026-10-18 10:55:35');
+CREATE TABLE undoJournal (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	operationId INTEGER NOT NULL,
+	tableName TEXT NOT NULL,
+	action TEXT NOT NULL CHECK (action IN ('insert', 'update', 'delete')),
+	rowId INTEGER NOT NULL,
+	beforeRow TEXT,
+	afterRow TEXT,
+	FOREIGN KEY (operationId) REFERENCES undoOperations(id) ON DELETE CASCADE
+);
+INSERT INTO undoJournal VALUES(1,1,'taskTags','insert',2,NULL,'{"taskID":2,"tagID":1}');
+CREATE TABLE taskAnnotations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taskId INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE
+);
+INSERT INTO taskAnnotations VALUES(1,1,'pinged vendor','2024-01-02 10:00:00');
+INSERT INTO sqlite_sequence VALUES('undoJournal',1);
+INSERT INTO sqlite_sequence VALUES('tasks',2);
+INSERT INTO sqlite_sequence VALUES('tags',1);
+INSERT INTO sqlite_sequence VALUES('taskAnnotations',1);
+INSERT INTO sqlite_sequence VALUES('undoOperations',1);
+CREATE TRIGGER undo_tasks_insert AFTER INSERT ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'title', NEW.title, 'description', NEW.description, 'priority', NEW.priority, 'dueUtc', NEW.dueUtc, 'updatedAtUtc', NEW.updatedAtUtc, 'createdAtUtc', NEW.createdAtUtc, 'completedAtUtc', NEW.completedAtUtc, 'state', NEW.state, 'next', NEW.next, 'templateId', NEW.templateId));
+END;
+CREATE TRIGGER undo_tasks_update AFTER UPDATE ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'update', NEW.rowid, json_object('id', OLD.id, 'title', OLD.title, 'description', OLD.description, 'priority', OLD.priority, 'dueUtc', OLD.dueUtc, 'updatedAtUtc', OLD.updatedAtUtc, 'createdAtUtc', OLD.createdAtUtc, 'completedAtUtc', OLD.completedAtUtc, 'state', OLD.state, 'next', OLD.next, 'templateId', OLD.templateId), json_object('id', NEW.id, 'title', NEW.title, 'description', NEW.description, 'priority', NEW.priority, 'dueUtc', NEW.dueUtc, 'updatedAtUtc', NEW.updatedAtUtc, 'createdAtUtc', NEW.createdAtUtc, 'completedAtUtc', NEW.completedAtUtc, 'state', NEW.state, 'next', NEW.next, 'templateId', NEW.templateId));
+END;
+CREATE TRIGGER undo_tasks_delete AFTER DELETE ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'delete', OLD.rowid, json_object('id', OLD.id, 'title', OLD.title, 'description', OLD.description, 'priority', OLD.priority, 'dueUtc', OLD.dueUtc, 'updatedAtUtc', OLD.updatedAtUtc, 'createdAtUtc', OLD.createdAtUtc, 'completedAtUtc', OLD.completedAtUtc, 'state', OLD.state, 'next', OLD.next, 'templateId', OLD.templateId), NULL);
+END;
+CREATE TRIGGER undo_tags_insert AFTER INSERT ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'name', NEW.name));
+END;
+CREATE TRIGGER undo_tags_update AFTER UPDATE ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'update', NEW.rowid, json_object('id', OLD.id, 'name', OLD.name), json_object('id', NEW.id, 'name', NEW.name));
+END;
+CREATE TRIGGER undo_tags_delete AFTER DELETE ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'delete', OLD.rowid, json_object('id', OLD.id, 'name', OLD.name), NULL);
+END;
+CREATE TRIGGER undo_projects_insert AFTER INSERT ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'title', NEW.title));
+END;
+CREATE TRIGGER undo_projects_update AFTER UPDATE ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'update', NEW.rowid, json_object('id', OLD.id, 'title', OLD.title), json_object('id', NEW.id, 'title', NEW.title));
+END;
+CREATE TRIGGER undo_projects_delete AFTER DELETE ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'delete', OLD.rowid, json_object('id', OLD.id, 'title', OLD.title), NULL);
+END;
+CREATE TRIGGER undo_taskTags_insert AFTER INSERT ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'insert', NEW.rowid, NULL, json_object('taskID', NEW.taskID, 'tagID', NEW.tagID));
+END;
+CREATE TRIGGER undo_taskTags_update AFTER UPDATE ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'update', NEW.rowid, json_object('taskID', OLD.taskID, 'tagID', OLD.tagID), json_object('taskID', NEW.taskID, 'tagID', NEW.tagID));
+END;
+CREATE TRIGGER undo_taskTags_delete AFTER DELETE ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'delete', OLD.rowid, json_object('taskID', OLD.taskID, 'tagID', OLD.tagID), NULL);
+END;
+CREATE TRIGGER undo_taskProjects_insert AFTER INSERT ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'projectId', NEW.projectId));
+END;
+CREATE TRIGGER undo_taskProjects_update AFTER UPDATE ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'projectId', OLD.projectId), json_object('taskId', NEW.taskId, 'projectId', NEW.projectId));
+END;
+CREATE TRIGGER undo_taskProjects_delete AFTER DELETE ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'projectId', OLD.projectId), NULL);
+END;
+CREATE TRIGGER undo_taskTime_insert AFTER INSERT ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'taskId', NEW.taskId, 'startTimeUtc', NEW.startTimeUtc, 'endTimeUtc', NEW.endTimeUtc, 'totalTime', NEW.totalTime));
+END;
+CREATE TRIGGER undo_taskTime_update AFTER UPDATE ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'update', NEW.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'startTimeUtc', OLD.startTimeUtc, 'endTimeUtc', OLD.endTimeUtc, 'totalTime', OLD.totalTime), json_object('id', NEW.id, 'taskId', NEW.taskId, 'startTimeUtc', NEW.startTimeUtc, 'endTimeUtc', NEW.endTimeUtc, 'totalTime', NEW.totalTime));
+END;
+CREATE TRIGGER undo_taskTime_delete AFTER DELETE ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'delete', OLD.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'startTimeUtc', OLD.startTimeUtc, 'endTimeUtc', OLD.endTimeUtc, 'totalTime', OLD.totalTime), NULL);
+END;
+CREATE TRIGGER undo_taskDependencies_insert AFTER INSERT ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'dependsOnId', NEW.dependsOnId));
+END;
+CREATE TRIGGER undo_taskDependencies_update AFTER UPDATE ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'dependsOnId', OLD.dependsOnId), json_object('taskId', NEW.taskId, 'dependsOnId', NEW.dependsOnId));
+END;
+CREATE TRIGGER undo_taskDependencies_delete AFTER DELETE ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'dependsOnId', OLD.dependsOnId), NULL);
+END;
+CREATE TRIGGER undo_taskTemplates_insert AFTER INSERT ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'pattern', NEW.pattern, 'untilUtc', NEW.untilUtc, 'lastDueUtc', NEW.lastDueUtc, 'createdAtUtc', NEW.createdAtUtc));
+END;
+CREATE TRIGGER undo_taskTemplates_update AFTER UPDATE ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'update', NEW.rowid, json_object('id', OLD.id, 'pattern', OLD.pattern, 'untilUtc', OLD.untilUtc, 'lastDueUtc', OLD.lastDueUtc, 'createdAtUtc', OLD.createdAtUtc), json_object('id', NEW.id, 'pattern', NEW.pattern, 'untilUtc', NEW.untilUtc, 'lastDueUtc', NEW.lastDueUtc, 'createdAtUtc', NEW.createdAtUtc));
+END;
+CREATE TRIGGER undo_taskTemplates_delete AFTER DELETE ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'delete', OLD.rowid, json_object('id', OLD.id, 'pattern', OLD.pattern, 'untilUtc', OLD.untilUtc, 'lastDueUtc', OLD.lastDueUtc, 'createdAtUtc', OLD.createdAtUtc), NULL);
+END;
+CREATE TRIGGER undo_taskAttributes_insert AFTER INSERT ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'name', NEW.name, 'value', NEW.value));
+END;
+CREATE TRIGGER undo_taskAttributes_update AFTER UPDATE ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'name', OLD.name, 'value', OLD.value), json_object('taskId', NEW.taskId, 'name', NEW.name, 'value', NEW.value));
+END;
+CREATE TRIGGER undo_taskAttributes_delete AFTER DELETE ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'name', OLD.name, 'value', OLD.value), NULL);
+END;
+CREATE TRIGGER undo_taskAnnotations_insert AFTER INSERT ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'taskId', NEW.taskId, 'text', NEW.text, 'createdAtUtc', NEW.createdAtUtc));
+END;
+CREATE TRIGGER undo_taskAnnotations_update AFTER UPDATE ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'update', NEW.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'text', OLD.text, 'createdAtUtc', OLD.createdAtUtc), json_object('id', NEW.id, 'taskId', NEW.taskId, 'text', NEW.text, 'createdAtUtc', NEW.createdAtUtc));
+END;
+CREATE TRIGGER undo_taskAnnotations_delete AFTER DELETE ON taskAnnotations
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAnnotations', 'delete', OLD.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'text', OLD.text, 'createdAtUtc', OLD.createdAtUtc), NULL);
+END;
+CREATE INDEX taskAttributesName ON taskAttributes(name, value);
+CREATE INDEX undoJournalOperation ON undoJournal(operationId);
+CREATE INDEX taskAnnotationsTask ON taskAnnotations(taskId, createdAtUtc);
+COMMIT;
+PRAGMA user_version = 15;
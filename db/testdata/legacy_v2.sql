@@ -0,0 +1,35 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	description TEXT,
+	completed INTEGER NOT NULL DEFAULT 0,
+	priority INTEGER NOT NULL DEFAULT 0 CHECK (priority >= 0 AND priority <= 3),
+	dueUtc TEXT,
+	updatedAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp,
+	completedAtUtc TEXT
+);
+INSERT INTO tasks VALUES(1,'buy milk',NULL,0,1,NULL,'2026-10-18 10:55:29','2026-10-18 10:55:29',NULL);
+INSERT INTO tasks VALUES(2,'file taxes',NULL,1,3,NULL,'2026-10-18 10:55:29','2026-10-18 10:55:29',NULL);
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE
+);
+INSERT INTO tags VALUES(1,'home');
+INSERT INTO tags VALUES(2,'money');
+CREATE TABLE taskTags (
+	taskID INTEGER NOT NULL,
+	tagID INTEGER NOT NULL,
+	UNIQUE (taskID, tagID),
+	PRIMARY KEY(taskID, tagID),
+	FOREIGN KEY(taskID) REFERENCES tasks(id) ON DELETE CASCADE,
+	FOREIGN KEY(tagID) REFERENCES tags(id) ON DELETE CASCADE
+);
+INSERT INTO taskTags VALUES(1,1);
+INSERT INTO taskTags VALUES(2,2);
+INSERT INTO sqlite_sequence VALUES('tasks',2);
+INSERT INTO sqlite_sequence VALUES('tags',2);
+COMMIT;
+PRAGMA user_version = 2;
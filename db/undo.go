@@ -0,0 +1,444 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// journaledTables contains the columns of every table that is recorded in the undo journal,
+// it is used to revert the journal. When the columns of a table change add a migration that
+// drops and recreates the table's triggers, the released migrations must not be changed
+var journaledTables = map[string][]string{
+	"tasks": {
+		"id", "title", "description", "priority", "dueUtc", "updatedAtUtc",
+		"createdAtUtc", "completedAtUtc", "state", "next", "templateId",
+	},
+	"tags":             {"id", "name"},
+	"projects":         {"id", "title"},
+	"taskTags":         {"taskID", "tagID"},
+	"taskProjects":     {"taskId", "projectId"},
+	"taskTime":         {"id", "taskId", "startTimeUtc", "endTimeUtc", "totalTime"},
+	"taskDependencies": {"taskId", "dependsOnId"},
+	"taskTemplates":    {"id", "pattern", "untilUtc", "lastDueUtc", "createdAtUtc"},
+	"taskAttributes":   {"taskId", "name", "value"},
+	"taskAnnotations":  {"id", "taskId", "text", "createdAtUtc"},
+}
+
+const M014_UndoJournalSchema = `
+CREATE TABLE IF NOT EXISTS undoOperations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	description TEXT NOT NULL,
+	open INTEGER NOT NULL DEFAULT 1 CHECK (open >= 0 AND open <= 1),
+	createdAtUtc TEXT NOT NULL DEFAULT current_timestamp
+);
+CREATE TABLE IF NOT EXISTS undoJournal (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	operationId INTEGER NOT NULL,
+	tableName TEXT NOT NULL,
+	action TEXT NOT NULL CHECK (action IN ('insert', 'update', 'delete')),
+	rowId INTEGER NOT NULL,
+	beforeRow TEXT,
+	afterRow TEXT,
+	FOREIGN KEY (operationId) REFERENCES undoOperations(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS undoJournalOperation ON undoJournal(operationId);
+
+CREATE TRIGGER IF NOT EXISTS undo_tasks_insert AFTER INSERT ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'title', NEW.title, 'description', NEW.description, 'priority', NEW.priority, 'dueUtc', NEW.dueUtc, 'updatedAtUtc', NEW.updatedAtUtc, 'createdAtUtc', NEW.createdAtUtc, 'completedAtUtc', NEW.completedAtUtc, 'state', NEW.state, 'next', NEW.next, 'templateId', NEW.templateId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_tasks_update AFTER UPDATE ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'update', NEW.rowid, json_object('id', OLD.id, 'title', OLD.title, 'description', OLD.description, 'priority', OLD.priority, 'dueUtc', OLD.dueUtc, 'updatedAtUtc', OLD.updatedAtUtc, 'createdAtUtc', OLD.createdAtUtc, 'completedAtUtc', OLD.completedAtUtc, 'state', OLD.state, 'next', OLD.next, 'templateId', OLD.templateId), json_object('id', NEW.id, 'title', NEW.title, 'description', NEW.description, 'priority', NEW.priority, 'dueUtc', NEW.dueUtc, 'updatedAtUtc', NEW.updatedAtUtc, 'createdAtUtc', NEW.createdAtUtc, 'completedAtUtc', NEW.completedAtUtc, 'state', NEW.state, 'next', NEW.next, 'templateId', NEW.templateId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_tasks_delete AFTER DELETE ON tasks
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tasks', 'delete', OLD.rowid, json_object('id', OLD.id, 'title', OLD.title, 'description', OLD.description, 'priority', OLD.priority, 'dueUtc', OLD.dueUtc, 'updatedAtUtc', OLD.updatedAtUtc, 'createdAtUtc', OLD.createdAtUtc, 'completedAtUtc', OLD.completedAtUtc, 'state', OLD.state, 'next', OLD.next, 'templateId', OLD.templateId), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_tags_insert AFTER INSERT ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'name', NEW.name));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_tags_update AFTER UPDATE ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'update', NEW.rowid, json_object('id', OLD.id, 'name', OLD.name), json_object('id', NEW.id, 'name', NEW.name));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_tags_delete AFTER DELETE ON tags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'tags', 'delete', OLD.rowid, json_object('id', OLD.id, 'name', OLD.name), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_projects_insert AFTER INSERT ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'title', NEW.title));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_projects_update AFTER UPDATE ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'update', NEW.rowid, json_object('id', OLD.id, 'title', OLD.title), json_object('id', NEW.id, 'title', NEW.title));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_projects_delete AFTER DELETE ON projects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'projects', 'delete', OLD.rowid, json_object('id', OLD.id, 'title', OLD.title), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTags_insert AFTER INSERT ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'insert', NEW.rowid, NULL, json_object('taskID', NEW.taskID, 'tagID', NEW.tagID));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTags_update AFTER UPDATE ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'update', NEW.rowid, json_object('taskID', OLD.taskID, 'tagID', OLD.tagID), json_object('taskID', NEW.taskID, 'tagID', NEW.tagID));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTags_delete AFTER DELETE ON taskTags
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTags', 'delete', OLD.rowid, json_object('taskID', OLD.taskID, 'tagID', OLD.tagID), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskProjects_insert AFTER INSERT ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'projectId', NEW.projectId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskProjects_update AFTER UPDATE ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'projectId', OLD.projectId), json_object('taskId', NEW.taskId, 'projectId', NEW.projectId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskProjects_delete AFTER DELETE ON taskProjects
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskProjects', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'projectId', OLD.projectId), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTime_insert AFTER INSERT ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'taskId', NEW.taskId, 'startTimeUtc', NEW.startTimeUtc, 'endTimeUtc', NEW.endTimeUtc, 'totalTime', NEW.totalTime));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTime_update AFTER UPDATE ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'update', NEW.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'startTimeUtc', OLD.startTimeUtc, 'endTimeUtc', OLD.endTimeUtc, 'totalTime', OLD.totalTime), json_object('id', NEW.id, 'taskId', NEW.taskId, 'startTimeUtc', NEW.startTimeUtc, 'endTimeUtc', NEW.endTimeUtc, 'totalTime', NEW.totalTime));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTime_delete AFTER DELETE ON taskTime
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTime', 'delete', OLD.rowid, json_object('id', OLD.id, 'taskId', OLD.taskId, 'startTimeUtc', OLD.startTimeUtc, 'endTimeUtc', OLD.endTimeUtc, 'totalTime', OLD.totalTime), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskDependencies_insert AFTER INSERT ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'dependsOnId', NEW.dependsOnId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskDependencies_update AFTER UPDATE ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'dependsOnId', OLD.dependsOnId), json_object('taskId', NEW.taskId, 'dependsOnId', NEW.dependsOnId));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskDependencies_delete AFTER DELETE ON taskDependencies
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskDependencies', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'dependsOnId', OLD.dependsOnId), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTemplates_insert AFTER INSERT ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'insert', NEW.rowid, NULL, json_object('id', NEW.id, 'pattern', NEW.pattern, 'untilUtc', NEW.untilUtc, 'lastDueUtc', NEW.lastDueUtc, 'createdAtUtc', NEW.createdAtUtc));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTemplates_update AFTER UPDATE ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'update', NEW.rowid, json_object('id', OLD.id, 'pattern', OLD.pattern, 'untilUtc', OLD.untilUtc, 'lastDueUtc', OLD.lastDueUtc, 'createdAtUtc', OLD.createdAtUtc), json_object('id', NEW.id, 'pattern', NEW.pattern, 'untilUtc', NEW.untilUtc, 'lastDueUtc', NEW.lastDueUtc, 'createdAtUtc', NEW.createdAtUtc));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskTemplates_delete AFTER DELETE ON taskTemplates
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskTemplates', 'delete', OLD.rowid, json_object('id', OLD.id, 'pattern', OLD.pattern, 'untilUtc', OLD.untilUtc, 'lastDueUtc', OLD.lastDueUtc, 'createdAtUtc', OLD.createdAtUtc), NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAttributes_insert AFTER INSERT ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'insert', NEW.rowid, NULL, json_object('taskId', NEW.taskId, 'name', NEW.name, 'value', NEW.value));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAttributes_update AFTER UPDATE ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'update', NEW.rowid, json_object('taskId', OLD.taskId, 'name', OLD.name, 'value', OLD.value), json_object('taskId', NEW.taskId, 'name', NEW.name, 'value', NEW.value));
+END;
+
+CREATE TRIGGER IF NOT EXISTS undo_taskAttributes_delete AFTER DELETE ON taskAttributes
+WHEN EXISTS (SELECT 1 FROM undoOperations WHERE open = 1)
+BEGIN
+	INSERT INTO undoJournal (operationId, tableName, action, rowId, beforeRow, afterRow)
+	VALUES ((SELECT MAX(id) FROM undoOperations WHERE open = 1), 'taskAttributes', 'delete', OLD.rowid, json_object('taskId', OLD.taskId, 'name', OLD.name, 'value', OLD.value), NULL);
+END;
+`
+
+const M014_UndoJournalSchemaDown = `
+DROP TRIGGER IF EXISTS undo_tasks_insert;
+DROP TRIGGER IF EXISTS undo_tasks_update;
+DROP TRIGGER IF EXISTS undo_tasks_delete;
+DROP TRIGGER IF EXISTS undo_tags_insert;
+DROP TRIGGER IF EXISTS undo_tags_update;
+DROP TRIGGER IF EXISTS undo_tags_delete;
+DROP TRIGGER IF EXISTS undo_projects_insert;
+DROP TRIGGER IF EXISTS undo_projects_update;
+DROP TRIGGER IF EXISTS undo_projects_delete;
+DROP TRIGGER IF EXISTS undo_taskTags_insert;
+DROP TRIGGER IF EXISTS undo_taskTags_update;
+DROP TRIGGER IF EXISTS undo_taskTags_delete;
+DROP TRIGGER IF EXISTS undo_taskProjects_insert;
+DROP TRIGGER IF EXISTS undo_taskProjects_update;
+DROP TRIGGER IF EXISTS undo_taskProjects_delete;
+DROP TRIGGER IF EXISTS undo_taskTime_insert;
+DROP TRIGGER IF EXISTS undo_taskTime_update;
+DROP TRIGGER IF EXISTS undo_taskTime_delete;
+DROP TRIGGER IF EXISTS undo_taskDependencies_insert;
+DROP TRIGGER IF EXISTS undo_taskDependencies_update;
+DROP TRIGGER IF EXISTS undo_taskDependencies_delete;
+DROP TRIGGER IF EXISTS undo_taskTemplates_insert;
+DROP TRIGGER IF EXISTS undo_taskTemplates_update;
+DROP TRIGGER IF EXISTS undo_taskTemplates_delete;
+DROP TRIGGER IF EXISTS undo_taskAttributes_insert;
+DROP TRIGGER IF EXISTS undo_taskAttributes_update;
+DROP TRIGGER IF EXISTS undo_taskAttributes_delete;
+
+DROP TABLE IF EXISTS undoJournal;
+DROP TABLE IF EXISTS undoOperations;
+`
+
+// UndoLimit is the number of operations that are kept in the undo journal
+const UndoLimit = 100
+
+// ErrNothingToUndo is returned by UndoTx when the journal is empty
+var ErrNothingToUndo = errors.New("Nothing to undo")
+
+// UndoOperation is a group of changes that are undone together e.g add "milk" +home
+type UndoOperation struct {
+	ID          int64  `db:"id"`           // Unique identifier
+	Description string `db:"description"`  // What made the changes e.g the program or delete task 3
+	Open        bool   `db:"open"`         // True while the changes are being recorded
+	CreatedUtc  string `db:"createdAtUtc"` // When the operation started
+}
+
+// undoEntry is a single row that was inserted, updated or deleted by an operation
+type undoEntry struct {
+	ID        int64  `db:"id"`
+	TableName string `db:"tableName"`
+	Action    string `db:"action"`
+	RowID     int64  `db:"rowId"`
+}
+
+// BeginUndoOperationTx starts recording the changes made by the transaction,
+// EndUndoOperationTx must be called before the transaction is committed
+// NOTE: the transaction is not rolled back on error
+func (store *Store) BeginUndoOperationTx(tx *sqlx.Tx, description string) error {
+	var _, err = tx.Exec(`INSERT INTO undoOperations (description) VALUES (?)`, description)
+	if err != nil {
+		return fmt.Errorf("Failed to start recording the undo journal: %w", err)
+	}
+	return nil
+}
+
+// EndUndoOperationTx stops recording the changes of the open operation, an operation
+// that did not change anything e.g list is removed, only the last UndoLimit operations are kept
+// NOTE: the transaction is not rolled back on error
+func (store *Store) EndUndoOperationTx(tx *sqlx.Tx) error {
+	var _, err = tx.Exec(`
+		DELETE FROM undoOperations
+		WHERE open = 1 AND NOT EXISTS (
+			SELECT 1 FROM undoJournal WHERE undoJournal.operationId = undoOperations.id
+		)`)
+	if err != nil {
+		return fmt.Errorf("Failed to remove an empty undo operation: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE undoOperations SET open = 0 WHERE open = 1`)
+	if err != nil {
+		return fmt.Errorf("Failed to stop recording the undo journal: %w", err)
+	}
+	return store.pruneUndoJournalTx(tx)
+}
+
+// pruneUndoJournalTx removes every operation older than the last UndoLimit operations
+func (store *Store) pruneUndoJournalTx(tx *sqlx.Tx) error {
+	var sql = `
+	DELETE FROM %s WHERE %s < (
+		SELECT MIN(id) FROM (SELECT id FROM undoOperations ORDER BY id DESC LIMIT ?)
+	)`
+	var _, err = tx.Exec(fmt.Sprintf(sql, "undoJournal", "operationId"), UndoLimit)
+	if err != nil {
+		return fmt.Errorf("Failed to prune the undo journal: %w", err)
+	}
+	_, err = tx.Exec(fmt.Sprintf(sql, "undoOperations", "id"), UndoLimit)
+	if err != nil {
+		return fmt.Errorf("Failed to prune the undo operations: %w", err)
+	}
+	return nil
+}
+
+// UndoTx reverts every change of the most recent operation, including the rows removed
+// by a cascade e.g the tags of a deleted task. The undo itself is not recorded, so that
+// undoing again reverts the operation before it. ErrNothingToUndo is returned when the
+// journal is empty
+// NOTE: the transaction is not rolled back on error
+func (store *Store) UndoTx(tx *sqlx.Tx) (*UndoOperation, error) {
+	// The caller may have started recording the program that asked for the undo
+	var _, err = tx.Exec(`DELETE FROM undoOperations WHERE open = 1`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to stop recording the undo journal: %w", err)
+	}
+	var operation = &UndoOperation{}
+	err = tx.Get(operation, `SELECT * FROM undoOperations ORDER BY id DESC LIMIT 1`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the last undo operation: %w", err)
+	}
+
+	var entries = []undoEntry{}
+	err = tx.Select(&entries, `
+		SELECT id, tableName, action, rowId FROM undoJournal
+		WHERE operationId = ? ORDER BY id DESC`,
+		operation.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the undo journal: %w", err)
+	}
+	var lastChange int64
+	lastChange, err = store.lastHistoryIdTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	// Rows are restored in reverse, children may be restored before their parent
+	_, err = tx.Exec(`PRAGMA defer_foreign_keys = ON`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to defer the foreign keys: %w", err)
+	}
+	for _, entry := range entries {
+		err = store.revertUndoEntryTx(tx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to undo %s: %w", operation.Description, err)
+		}
+	}
+
+	err = store.labelHistoryTx(tx, lastChange, "undo "+operation.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM undoJournal WHERE operationId = ?`, operation.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to remove the undo journal: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM undoOperations WHERE id = ?`, operation.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to remove the undo operation: %w", err)
+	}
+	return operation, nil
+}
+
+// revertUndoEntryTx restores the row to its before image, the rowid is kept so that
+// older entries still refer to the same row
+func (store *Store) revertUndoEntryTx(tx *sqlx.Tx, entry undoEntry) error {
+	var columns, ok = journaledTables[entry.TableName]
+	if !ok {
+		return fmt.Errorf("Unknown table in the undo journal: %s", entry.TableName)
+	}
+	var values = make([]string, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, fmt.Sprintf("json_extract(beforeRow, '$.%s')", column))
+	}
+	var err error
+	switch entry.Action {
+	case "insert":
+		_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE rowid = ?`, entry.TableName), entry.RowID)
+	case "update":
+		_, err = tx.Exec(fmt.Sprintf(
+			`UPDATE %s SET (%s) = (SELECT %s FROM undoJournal WHERE id = ?) WHERE rowid = ?`,
+			entry.TableName, strings.Join(columns, ", "), strings.Join(values, ", "),
+		), entry.ID, entry.RowID)
+	case "delete":
+		_, err = tx.Exec(fmt.Sprintf(
+			`INSERT INTO %s (rowid, %s) SELECT rowId, %s FROM undoJournal WHERE id = ?`,
+			entry.TableName, strings.Join(columns, ", "), strings.Join(values, ", "),
+		), entry.ID)
+	default:
+		err = fmt.Errorf("Unknown action in the undo journal: %s", entry.Action)
+	}
+	return err
+}
+
+// ListUndoOperations returns the operations that can be undone, the most recent first
+func (store *Store) ListUndoOperations(ctx context.Context) ([]UndoOperation, error) {
+	var operations = []UndoOperation{}
+	var err = store.Con.SelectContext(ctx, &operations, `SELECT * FROM undoOperations ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the undo operations: %w", err)
+	}
+	return operations, nil
+}
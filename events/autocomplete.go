@@ -0,0 +1,63 @@
+package events
+
+// ============================================================================
+// AUTOCOMPLETE
+// ============================================================================
+
+// Autocomplete asks for the completions of the word under the cursor
+type Autocomplete struct {
+	Input  string // e.g add "milk" +ho
+	Cursor int    // Byte offset of the cursor in the input
+}
+
+// DecodeAutocompleteEvent will decode the event to complete the input
+func DecodeAutocompleteEvent(e *Event) *Autocomplete { return e.Data.(*Autocomplete) }
+
+// NewAutocompleteEvent will create a new event to complete the input
+func NewAutocompleteEvent(input string, cursor int) *Event {
+	return &Event{
+		Type: EventAutocomplete,
+		Data: &Autocomplete{Input: input, Cursor: cursor},
+	}
+}
+
+// ============================================================================
+// AUTOCOMPLETE RESPONSE
+// ============================================================================
+
+// Completion is a proposal for the word under the cursor e.g priority:
+type Completion struct {
+	Text        string // Replaces the word under the cursor
+	Description string // Shown next to the text e.g the title of a task
+}
+
+// AutocompleteResponse are the completions for the word that starts at Start
+// and ends at the cursor of the input that was sent
+type AutocompleteResponse struct {
+	Input string
+	Start int // Byte offset of the word under the cursor
+	End   int // Byte offset of the cursor
+	Items []Completion
+}
+
+// Apply replaces the word under the cursor with the completion at the index,
+// the byte offset of the end of the completion is returned as the new cursor
+func (response *AutocompleteResponse) Apply(index int) (string, int) {
+	var item = response.Items[index]
+	var output = response.Input[:response.Start] + item.Text + response.Input[response.End:]
+	return output, response.Start + len(item.Text)
+}
+
+// DecodeAutocompleteResponseEvent will decode the completions
+func DecodeAutocompleteResponseEvent(e *Event) *AutocompleteResponse {
+	return e.Data.(*AutocompleteResponse)
+}
+
+// NewAutocompleteResponseEvent will create a new event with the completions for
+// the word between start and end of the input
+func NewAutocompleteResponseEvent(input string, start int, end int, items []Completion) *Event {
+	return &Event{
+		Type: EventAutocompleteResponse,
+		Data: &AutocompleteResponse{Input: input, Start: start, End: end, Items: items},
+	}
+}
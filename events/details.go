@@ -0,0 +1,69 @@
+package events
+
+import "github.com/luke-goddard/taskninja/db"
+
+// ============================================================================
+// TASK DETAILS
+// ============================================================================
+
+// TaskDetails is an event to show everything about a single task
+type TaskDetails struct{ ID int64 }
+
+// DecodeTaskDetailsEvent will decode the event to show the details of a task
+func DecodeTaskDetailsEvent(e *Event) *TaskDetails { return e.Data.(*TaskDetails) }
+
+// NewTaskDetailsEvent will create a new event to show the details of a task
+func NewTaskDetailsEvent(id int64) *Event {
+	return &Event{
+		Type: EventTaskDetails,
+		Data: &TaskDetails{ID: id},
+	}
+}
+
+// ============================================================================
+// OPEN TASK DETAILS
+// ============================================================================
+
+// OpenTaskDetails is an event to open the details panel on a task e.g info 3
+type OpenTaskDetails struct{ ID int64 }
+
+// DecodeOpenTaskDetailsEvent will decode the event to open the details panel
+func DecodeOpenTaskDetailsEvent(e *Event) *OpenTaskDetails { return e.Data.(*OpenTaskDetails) }
+
+// NewOpenTaskDetailsEvent will create a new event to open the details panel on a task
+func NewOpenTaskDetailsEvent(id int64) *Event {
+	return &Event{
+		Type: EventOpenTaskDetails,
+		Data: &OpenTaskDetails{ID: id},
+	}
+}
+
+// ============================================================================
+// TASK DETAILS RESPONSE
+// ============================================================================
+
+// TaskDetailsResponse is the response to the task details event
+type TaskDetailsResponse struct {
+	ID          int64               // The task that was asked for
+	Task        *db.TaskDetailed    // nil if the task no longer exists
+	Annotations []db.TaskAnnotation // The notes on the task, the oldest first
+	History     []db.TaskChange     // The changes made to the task, the oldest first
+}
+
+// DecodeTaskDetailsResponseEvent will decode the response to the task details event
+func DecodeTaskDetailsResponseEvent(e *Event) *TaskDetailsResponse {
+	return e.Data.(*TaskDetailsResponse)
+}
+
+// NewTaskDetailsResponseEvent will create a new event with the details of a task
+func NewTaskDetailsResponseEvent(
+	id int64,
+	task *db.TaskDetailed,
+	annotations []db.TaskAnnotation,
+	history []db.TaskChange,
+) *Event {
+	return &Event{
+		Type: EventTaskDetailsResponse,
+		Data: &TaskDetailsResponse{ID: id, Task: task, Annotations: annotations, History: history},
+	}
+}
@@ -3,18 +3,25 @@ package events
 type EventType string // EventType is a type of event
 
 const (
-	EventError            EventType = "Error"                // Error event
-	EventRunProgram       EventType = "RunProgram"           // RunProgram e.g 'add task'
-	EventListTasks        EventType = "ListTasks"            // ListTasks event
-	EventCompleteTaskById EventType = "CompleteTaskByID"     // Mark a task as complete
-	EventTableFuzzySearch EventType = "TableFuzzySearch"     // Fuzzy search for a task
-	EventDeleteTaskById   EventType = "DeleteTaskByID"       // Delete a task
-	EventStartTaskById    EventType = "StartTask"            // Start a task
-	EventStopTaskById     EventType = "StopTask"             // Stop a task
-	EventListTaskResponse EventType = "ListTaskResponse"     // List tasks responses to be consumed by the UI
-	EventIncreasePriority EventType = "IncreaseTaskPriority" // Increase the priority of a task
-	EventDecreasePriority EventType = "DecreaseTaskPriority" // Decrease the priority of a task
-	EventSetPriority      EventType = "SetTaskPriority"      // Set the priority of a task
+	EventError                EventType = "Error"                // Error event
+	EventRunProgram           EventType = "RunProgram"           // RunProgram e.g 'add task'
+	EventExplainProgram       EventType = "ExplainProgram"       // What a program would do e.g 'explain add task'
+	EventListTasks            EventType = "ListTasks"            // ListTasks event
+	EventCompleteTaskById     EventType = "CompleteTaskByID"     // Mark a task as complete
+	EventTableFuzzySearch     EventType = "TableFuzzySearch"     // Fuzzy search for a task
+	EventDeleteTaskById       EventType = "DeleteTaskByID"       // Delete a task
+	EventStartTaskById        EventType = "StartTask"            // Start a task
+	EventStopTaskById         EventType = "StopTask"             // Stop a task
+	EventListTaskResponse     EventType = "ListTaskResponse"     // List tasks responses to be consumed by the UI
+	EventIncreasePriority     EventType = "IncreaseTaskPriority" // Increase the priority of a task
+	EventDecreasePriority     EventType = "DecreaseTaskPriority" // Decrease the priority of a task
+	EventSetPriority          EventType = "SetTaskPriority"      // Set the priority of a task
+	EventAutocomplete         EventType = "Autocomplete"         // Complete the word under the cursor
+	EventAutocompleteResponse EventType = "AutocompleteResponse" // Completions to be consumed by the UI
+	EventTaskDetails          EventType = "TaskDetails"          // Show everything about a single task
+	EventTaskDetailsResponse  EventType = "TaskDetailsResponse"  // Task details to be consumed by the UI
+	EventOpenTaskDetails      EventType = "OpenTaskDetails"      // Open the details panel on a task e.g info 3
+	EventListTagsResponse     EventType = "ListTagsResponse"     // Every tag and its task count e.g tags
 )
 
 type Event struct {
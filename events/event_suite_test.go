@@ -69,6 +69,17 @@ var _ = Describe("NewListResponseEvent", func() {
 	})
 })
 
+var _ = Describe("NewListReportResponse", func() {
+	var event = NewListReportResponse(nil, "next", []string{"id", "title"})
+
+	It("should create", func() {
+		Expect(event.Type).To(Equal(EventListTaskResponse))
+		var response = DecodeListTasksResponseEvent(event)
+		Expect(response.Report).To(Equal("next"))
+		Expect(response.Columns).To(Equal([]string{"id", "title"}))
+	})
+})
+
 // ============================================================================
 // PRIORITY.go
 // ============================================================================
@@ -109,6 +120,39 @@ var _ = Describe("Run", func() {
 	})
 })
 
+var _ = Describe("Autocomplete", func() {
+	var event = NewAutocompleteEvent("add", 3)
+
+	It("should decode", func() {
+		Expect(DecodeAutocompleteEvent(event)).To(Equal(&Autocomplete{Input: "add", Cursor: 3}))
+	})
+})
+
+var _ = Describe("Autocomplete response", func() {
+	var event = NewAutocompleteResponseEvent(`add "milk" +ho`, 11, 14, []Completion{{Text: "+home"}})
+
+	It("should decode", func() {
+		var response = DecodeAutocompleteResponseEvent(event)
+		Expect(event.Type).To(Equal(EventAutocompleteResponse))
+		Expect(response.Items).To(Equal([]Completion{{Text: "+home"}}))
+	})
+
+	It("should replace the word under the cursor", func() {
+		var output, cursor = DecodeAutocompleteResponseEvent(event).Apply(0)
+		Expect(output).To(Equal(`add "milk" +home`))
+		Expect(cursor).To(Equal(16))
+	})
+})
+
+var _ = Describe("Explain", func() {
+	var event = NewExplainProgramEvent("Tokens:")
+
+	It("should decode", func() {
+		Expect(event.Type).To(Equal(EventExplainProgram))
+		Expect(DecodeExplainProgramEvent(event).Explanation).To(Equal("Tokens:"))
+	})
+})
+
 // ============================================================================
 // START.go
 // ============================================================================
@@ -25,7 +25,11 @@ func NewListTasksEvent() *Event {
 // ============================================================================
 
 // ListTasksResponse is the response to list all tasks event
-type ListTasksResponse struct{ Tasks []db.TaskDetailed }
+type ListTasksResponse struct {
+	Tasks   []db.TaskDetailed
+	Report  string   // The report that selected the tasks e.g next, empty if no report is active
+	Columns []string // The columns the report shows, nil shows the default columns
+}
 
 // DecodeListTasksResponseEvent will decode the event to list all tasks response
 func DecodeListTasksResponseEvent(e *Event) *ListTasksResponse { return e.Data.(*ListTasksResponse) }
@@ -37,3 +41,11 @@ func NewListTasksResponse(tasks []db.TaskDetailed) *Event {
 		Data: &ListTasksResponse{Tasks: tasks},
 	}
 }
+
+// NewListReportResponse will create a new event to list the tasks selected by a report
+func NewListReportResponse(tasks []db.TaskDetailed, report string, columns []string) *Event {
+	return &Event{
+		Type: EventListTaskResponse,
+		Data: &ListTasksResponse{Tasks: tasks, Report: report, Columns: columns},
+	}
+}
@@ -19,3 +19,23 @@ func NewRunProgramEvent(program string) *Event {
 		},
 	}
 }
+
+// ============================================================================
+// EXPLAIN PROGRAM
+// ============================================================================
+
+// ExplainProgram is the response to a program that was explained e.g "explain add 'task'"
+type ExplainProgram struct{ Explanation string }
+
+// DecodeExplainProgramEvent will decode the event with the explanation of a program
+func DecodeExplainProgramEvent(e *Event) *ExplainProgram { return e.Data.(*ExplainProgram) }
+
+// NewExplainProgramEvent will create a new event with the explanation of a program
+func NewExplainProgramEvent(explanation string) *Event {
+	return &Event{
+		Type: EventExplainProgram,
+		Data: &ExplainProgram{
+			Explanation: explanation,
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package events
+
+import "github.com/luke-goddard/taskninja/db"
+
+// ============================================================================
+// LIST TAGS RESPONSE
+// ============================================================================
+
+// ListTagsResponse is the response to the tags command
+type ListTagsResponse struct{ Tags []db.TagCount }
+
+// DecodeListTagsResponseEvent will decode the event with the tags
+func DecodeListTagsResponseEvent(e *Event) *ListTagsResponse { return e.Data.(*ListTagsResponse) }
+
+// NewListTagsResponseEvent will create a new event with every tag and the number of its tasks
+func NewListTagsResponseEvent(tags []db.TagCount) *Event {
+	return &Event{
+		Type: EventListTagsResponse,
+		Data: &ListTagsResponse{Tags: tags},
+	}
+}
@@ -0,0 +1,215 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/interpreter/lex"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+)
+
+// MAX_ALIAS_DEPTH is how many aliases can expand into each other before giving up
+const MAX_ALIAS_DEPTH = 16
+
+// Aliases maps a name to the command it expands to. $1 to $9 are replaced with the
+// arguments after the name and $* with every argument, arguments that are not used
+// by a placeholder are appended when the template does not contain $* e.g
+//
+//	aliases:
+//	  today: list due:today
+//	  buy: add $1 +shopping
+type Aliases map[string]string
+
+// sourceSpan is the range of the user's input that a byte of the expansion came from
+type sourceSpan struct {
+	start int
+	end   int // exclusive
+}
+
+// expansion is the input after every alias was expanded, origins maps each byte of
+// the output back to the text the user typed so diagnostics can point at it
+type expansion struct {
+	input   string
+	output  string
+	origins []sourceSpan
+}
+
+func newExpansion(input string) *expansion {
+	var origins = make([]sourceSpan, len(input))
+	for i := range origins {
+		origins[i] = sourceSpan{start: i, end: i + 1}
+	}
+	return &expansion{input: input, output: input, origins: origins}
+}
+
+// expanded returns true if at least one alias was expanded
+func (exp *expansion) expanded() bool {
+	return exp.output != exp.input
+}
+
+// origin returns the columns of the user's input that produced output[start:end]
+func (exp *expansion) origin(start int, end int) (int, int) {
+	var length = len(exp.origins)
+	if length == 0 || start >= length {
+		return len(exp.input), len(exp.input)
+	}
+	start = max(start, 0)
+	end = min(max(end, start+1), length)
+	var from, to = exp.origins[start].start, exp.origins[start].end
+	for _, origin := range exp.origins[start:end] {
+		from = min(from, origin.start)
+		to = max(to, origin.end)
+	}
+	return from, to
+}
+
+// remap moves the span of every diagnostic from the expanded text to the user's input
+func (exp *expansion) remap(items []manager.ErrorTranspiler) []manager.ErrorTranspiler {
+	if !exp.expanded() {
+		return items
+	}
+	for i := range items {
+		if start, end, ok := items[i].Span(); ok {
+			items[i].SetSpan(exp.origin(start, end))
+		}
+	}
+	return items
+}
+
+// builder appends text to the next expansion while keeping track of where it came from
+type expansionBuilder struct {
+	output  strings.Builder
+	origins []sourceSpan
+}
+
+func (builder *expansionBuilder) write(text string, origin sourceSpan) {
+	builder.output.WriteString(text)
+	for i := 0; i < len(text); i++ {
+		builder.origins = append(builder.origins, origin)
+	}
+}
+
+// copy appends output[start:end] of the previous expansion with its original positions
+func (builder *expansionBuilder) copy(previous *expansion, start int, end int) {
+	builder.output.WriteString(previous.output[start:end])
+	builder.origins = append(builder.origins, previous.origins[start:end]...)
+}
+
+// expand replaces the alias at the start of the input with its template until the first
+// word is a command. Built in commands cannot be shadowed and an alias that expands to
+// itself is reported instead of looping forever
+func (aliases Aliases) expand(input string) (*expansion, *manager.ErrorTranspiler) {
+	var current = newExpansion(input)
+	var seen = make(map[string]bool)
+	for depth := 0; ; depth++ {
+		var nameStart, nameEnd = firstWord(current.output, 0)
+		var name = strings.ToLower(current.output[nameStart:nameEnd])
+		var template, ok = aliases[name]
+		if !ok || lex.IsCommand(name) {
+			return current, nil
+		}
+
+		var origin = sourceSpan{}
+		origin.start, origin.end = current.origin(nameStart, nameEnd)
+		if seen[name] {
+			var err = manager.NewAliasError(fmt.Sprintf("The alias %s expands to itself", name))
+			return current, err.SetSpan(origin.start, origin.end)
+		}
+		if depth >= MAX_ALIAS_DEPTH {
+			var message = fmt.Sprintf("The alias %s expands into more than %d aliases", name, MAX_ALIAS_DEPTH)
+			return current, manager.NewAliasError(message).SetSpan(origin.start, origin.end)
+		}
+		seen[name] = true
+
+		var next, err = current.substitute(template, origin, splitArguments(current.output, nameEnd))
+		if err != nil {
+			var message = fmt.Sprintf("The alias %s %s", name, err.Error())
+			return current, manager.NewAliasError(message).SetSpan(origin.start, origin.end)
+		}
+		current = next
+	}
+}
+
+// substitute builds the next expansion from the template, text from the template
+// points at the alias name and arguments keep their original positions
+func (current *expansion) substitute(template string, origin sourceSpan, args []sourceSpan) (*expansion, error) {
+	var builder = expansionBuilder{}
+	var highest = 0
+	var everything = false
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' || i+1 == len(template) {
+			builder.write(template[i:i+1], origin)
+			continue
+		}
+		var next = template[i+1]
+		switch {
+		case next == '*':
+			everything = true
+			if len(args) > 0 {
+				builder.copy(current, args[0].start, args[len(args)-1].end)
+			}
+			i++
+		case next >= '1' && next <= '9':
+			var position = int(next - '0')
+			if position > len(args) {
+				return nil, fmt.Errorf("expects at least %d arguments", position)
+			}
+			highest = max(highest, position)
+			builder.copy(current, args[position-1].start, args[position-1].end)
+			i++
+		default:
+			builder.write(template[i:i+1], origin)
+		}
+	}
+	if !everything {
+		for _, arg := range args[highest:] {
+			builder.write(" ", origin)
+			builder.copy(current, arg.start, arg.end)
+		}
+	}
+	return &expansion{input: current.input, output: builder.output.String(), origins: builder.origins}, nil
+}
+
+// replace returns an expansion of the output, every byte points at the whole of the
+// current expansion e.g report next is replaced with the list command of the report
+func (current *expansion) replace(output string) *expansion {
+	var origin = sourceSpan{}
+	origin.start, origin.end = current.origin(0, len(current.output))
+	var builder = expansionBuilder{}
+	builder.write(output, origin)
+	return &expansion{input: current.input, output: builder.output.String(), origins: builder.origins}
+}
+
+// firstWord returns the bounds of the first word at or after offset, quotes are kept
+// together so "buy milk" is a single word
+func firstWord(input string, offset int) (int, int) {
+	var start = offset
+	for start < len(input) && lex.IsWhitespace(rune(input[start])) {
+		start++
+	}
+	var end = start
+	for end < len(input) && !lex.IsWhitespace(rune(input[end])) {
+		if isQuote(input[end]) {
+			var closing = strings.IndexByte(input[end+1:], input[end])
+			if closing < 0 {
+				return start, len(input)
+			}
+			end += closing + 1
+		}
+		end++
+	}
+	return start, end
+}
+
+// splitArguments returns the bounds of every word after the offset
+func splitArguments(input string, offset int) []sourceSpan {
+	var args = make([]sourceSpan, 0)
+	for {
+		var start, end = firstWord(input, offset)
+		if start == end {
+			return args
+		}
+		args = append(args, sourceSpan{start: start, end: end})
+		offset = end
+	}
+}
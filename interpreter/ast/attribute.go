@@ -0,0 +1,101 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/date"
+)
+
+// attributeValue is the checked value of a user defined attribute e.g estimate:3
+type attributeValue struct {
+	name  string // e.g estimate
+	value string // The value that is stored e.g 3, empty when none is set
+	none  bool   // e.g estimate:none removes the value
+}
+
+// attributeResolver checks the value of every user defined attribute in a command,
+// the values are kept on the keys so that they can be used to filter and insert
+type attributeResolver struct {
+	transpiler *Transpiler
+}
+
+// resolveAttributes checks every key of the command that is a user defined attribute
+func (transpiler *Transpiler) resolveAttributes(command *Command) {
+	if len(transpiler.store.UDAs()) == 0 {
+		return
+	}
+	Walk(&attributeResolver{transpiler: transpiler}, command)
+}
+
+func (resolver *attributeResolver) Visit(node Node) Visitor {
+	var key, ok = node.(*Key)
+	if !ok {
+		return resolver
+	}
+	var uda, found = resolver.transpiler.store.UDA(key.Key)
+	if !found {
+		return resolver
+	}
+	var lit, isLiteral = key.Expr.(*Literal)
+	if !isLiteral {
+		resolver.transpiler.AddError(fmt.Errorf("Expected a value for the %s key", key.Key), key)
+		return resolver
+	}
+	var name = strings.ToLower(key.Key)
+	if strings.ToLower(lit.Value) == "none" {
+		key.attribute = &attributeValue{name: name, none: true}
+		return resolver
+	}
+	var value, err = resolver.resolve(name, uda, lit.Value)
+	if err != nil {
+		resolver.transpiler.AddError(err, lit)
+		return resolver
+	}
+	key.attribute = &attributeValue{name: name, value: value}
+	return resolver
+}
+
+// resolve returns the value that is stored, dates are resolved against the transpiler's clock
+func (resolver *attributeResolver) resolve(name string, uda config.UDA, value string) (string, error) {
+	if uda.Type == config.UDATypeDate {
+		var resolved, err = date.Parse(value, resolver.transpiler.now())
+		if err != nil {
+			return "", err
+		}
+		value = resolved.UTC().Format(db.SQLITE_TIME_FORMAT)
+	}
+	return db.NormalizeAttribute(name, uda, value)
+}
+
+// evalSelect filters tasks on the value of the attribute, none selects the tasks without a value
+func (attribute *attributeValue) evalSelect(builder *sqlbuilder.SelectBuilder) string {
+	var sb = sqlbuilder.Select("taskAttributes.taskId").From("taskAttributes")
+	if attribute.none {
+		sb.Where(sb.Equal("taskAttributes.name", attribute.name))
+		return builder.NotIn("tasks.id", sb)
+	}
+	sb.Where(
+		sb.Equal("taskAttributes.name", attribute.name),
+		sb.Equal("taskAttributes.value", attribute.value),
+	)
+	return builder.In("tasks.id", sb)
+}
+
+// evalInsert stores the value once the task exists, none removes the value
+func (attribute *attributeValue) evalInsert(transpiler *Transpiler) {
+	if attribute.none {
+		transpiler.addCallback("remove attribute: "+attribute.name, func(tx *sqlx.Tx, taskId int64) error {
+			return transpiler.store.RemoveTaskAttributeTx(tx, taskId, attribute.name)
+		})
+		return
+	}
+	var description = fmt.Sprintf("set attribute: %s=%s", attribute.name, attribute.value)
+	transpiler.addCallback(description, func(tx *sqlx.Tx, taskId int64) error {
+		return transpiler.store.SetTaskAttributeTx(tx, taskId, attribute.name, attribute.value)
+	})
+}
@@ -2,17 +2,35 @@ package ast
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/output"
 )
 
 type CommandKind int
 
 const (
-	CommandKindAdd     CommandKind = iota // e.g add "buy dog"
-	CommandKindDepends                    // e.g depends 1 on 2
-	CommandKindList                       // e.g list +HOME
-	CommandKindNext                       // e.g list +HOME
+	CommandKindAdd       CommandKind = iota // e.g add "buy dog"
+	CommandKindDepends                      // e.g depends 1 on 2
+	CommandKindList                         // e.g list +HOME
+	CommandKindNext                         // e.g next 1
+	CommandKindModify                       // e.g modify 1 priority:high
+	CommandKindDone                         // e.g done 1
+	CommandKindDelete                       // e.g delete 1
+	CommandKindStart                        // e.g start 1
+	CommandKindStop                         // e.g stop 1
+	CommandKindTags                         // e.g tags
+	CommandKindUndepends                    // e.g undepends 1 on 2
+	CommandKindReport                       // e.g report next
+	CommandKindUndo                         // e.g undo
+	CommandKindAnnotate                     // e.g annotate 1 "pinged vendor"
+	CommandKindDenotate                     // e.g denotate 1 "pinged vendor"
+	CommandKindSearch                       // e.g search "old ticket" +work
+	CommandKindInfo                         // e.g info 1
 )
 
 // Command represents a command in the AST.
@@ -42,19 +60,53 @@ func (c *CommandKind) String() string {
 		return "depends"
 	case CommandKindNext:
 		return "next"
+	case CommandKindModify:
+		return "modify"
+	case CommandKindDone:
+		return "done"
+	case CommandKindDelete:
+		return "delete"
+	case CommandKindStart:
+		return "start"
+	case CommandKindStop:
+		return "stop"
+	case CommandKindTags:
+		return "tags"
+	case CommandKindUndepends:
+		return "undepends"
+	case CommandKindReport:
+		return "report"
+	case CommandKindUndo:
+		return "undo"
+	case CommandKindAnnotate:
+		return "annotate"
+	case CommandKindDenotate:
+		return "denotate"
+	case CommandKindSearch:
+		return "search"
+	case CommandKindInfo:
+		return "info"
 	default:
 		return "unknown"
 	}
 }
 
+// EvalSelect adds every option to the WHERE clause, options are joined with AND.
+// The sort, limit and format keys order, truncate and print the tasks rather than
+// filtering them, see SortKeys, Limit and Format
 func (c *Command) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError) interface{} {
-	if len(c.Options) == 0 {
-		addError(fmt.Errorf("command %s requires at least one option", c.Kind.String()))
-		return nil
-
-	}
+	var conditions = make([]string, 0, len(c.Options))
 	for _, option := range c.Options {
-		option.EvalSelect(builder, addError)
+		if sortOption(option) != nil || limitOption(option) != nil || formatOption(option) != nil {
+			continue
+		}
+		var cond = selectCondition(option, builder, addError)
+		if cond != "" {
+			conditions = append(conditions, cond)
+		}
+	}
+	if len(conditions) > 0 {
+		builder.Where(conditions...)
 	}
 	return builder
 }
@@ -76,3 +128,165 @@ func (c *Command) EvalInsert(transpiler *Transpiler) interface{} {
 	}
 	return nil
 }
+
+// SortKeys returns the order given by the sort key e.g list sort:due+,priority-
+// nil is returned when the command does not have a sort key, the last one wins.
+// The udas can be sorted by as well as the built in fields
+func (c *Command) SortKeys(udas map[string]config.UDA) ([]db.TaskSortKey, error) {
+	var keys []db.TaskSortKey
+	for _, option := range c.Options {
+		var key = sortOption(option)
+		if key == nil {
+			continue
+		}
+		var parsed, err = key.sortKeys(udas)
+		if err != nil {
+			return nil, err
+		}
+		keys = parsed
+	}
+	return keys, nil
+}
+
+// Limit returns the maximum number of tasks given by the limit key e.g list limit:10,
+// 0 is returned when the command does not have a limit key, the last one wins
+func (c *Command) Limit() (int, error) {
+	var limit = 0
+	for _, option := range c.Options {
+		var key = limitOption(option)
+		if key == nil {
+			continue
+		}
+		var parsed, err = key.limit()
+		if err != nil {
+			return 0, err
+		}
+		limit = parsed
+	}
+	return limit, nil
+}
+
+// Format returns how the tasks are printed on the command line e.g list format:json,
+// an empty format is returned when the command does not have a format key, the last one wins
+func (c *Command) Format() (output.Format, error) {
+	var format output.Format
+	for _, option := range c.Options {
+		var key = formatOption(option)
+		if key == nil {
+			continue
+		}
+		var parsed, err = key.format()
+		if err != nil {
+			return "", err
+		}
+		format = parsed
+	}
+	return format, nil
+}
+
+// ListsTasks returns true if the command selects the tasks to list e.g list +work or search invoice
+func (c *Command) ListsTasks() bool {
+	return c.Kind == CommandKindList || c.Kind == CommandKindSearch
+}
+
+// InfoTaskId returns the task shown by the info command e.g info 3,
+// false is returned for every other command
+func (c *Command) InfoTaskId() (int64, bool) {
+	if c.Kind != CommandKindInfo || c.Param == nil {
+		return 0, false
+	}
+	var taskIds = c.Param.TaskIds()
+	if len(taskIds) != 1 {
+		return 0, false
+	}
+	return taskIds[0], true
+}
+
+// SearchText returns the text of the search command e.g search "old ticket",
+// false is returned for every other command
+func (c *Command) SearchText() (string, bool) {
+	if c.Kind != CommandKindSearch || c.Param == nil {
+		return "", false
+	}
+	var text, ok = c.Param.Value.(string)
+	return text, ok
+}
+
+// IncludesCompleted returns true if the command lists completed tasks e.g list status:completed,
+// completed tasks are hidden from the task list otherwise. A search includes completed tasks
+// unless it is given a status e.g search invoice status:pending
+func (c *Command) IncludesCompleted() bool {
+	var includes = c.Kind == CommandKindSearch
+	for _, option := range c.Options {
+		var key = keyOption(option, "status")
+		if key == nil {
+			continue
+		}
+		var lit, ok = key.Expr.(*Literal)
+		if !ok {
+			continue
+		}
+		var status = strings.ToLower(lit.Value)
+		includes = status == "completed" || status == "all"
+	}
+	return includes
+}
+
+// limit parses the value of a limit key e.g 10
+func (key *Key) limit() (int, error) {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok {
+		return 0, fmt.Errorf("Expected a number e.g limit:10")
+	}
+	var limit, err = strconv.Atoi(lit.Value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("Expected a number that is not negative e.g limit:10")
+	}
+	return limit, nil
+}
+
+// format parses the value of a format key e.g json
+func (key *Key) format() (output.Format, error) {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok || lit.Value == "" {
+		return "", fmt.Errorf("Expected a format e.g format:json")
+	}
+	return output.ParseFormat(lit.Value)
+}
+
+// sortKeys parses the value of a sort key e.g due+,priority-
+func (key *Key) sortKeys(udas map[string]config.UDA) ([]db.TaskSortKey, error) {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok {
+		return nil, fmt.Errorf("Expected a sort e.g sort:due+,priority-")
+	}
+	return db.ParseTaskSort(lit.Value, udas)
+}
+
+// sortOption returns the key if the statement is a sort key e.g sort:due+
+func sortOption(option Statement) *Key {
+	return keyOption(option, "sort")
+}
+
+// limitOption returns the key if the statement is a limit key e.g limit:10
+func limitOption(option Statement) *Key {
+	return keyOption(option, "limit")
+}
+
+// formatOption returns the key if the statement is a format key e.g format:json
+func formatOption(option Statement) *Key {
+	return keyOption(option, "format")
+}
+
+// keyOption returns the key if the statement is a key with the given name
+func keyOption(option Statement, name string) *Key {
+	var stmt, ok = option.(*ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	var key, isKey = stmt.Expr.(*Key)
+	if !isKey || strings.ToLower(key.Key) != name {
+		return nil
+	}
+	return key
+}
@@ -0,0 +1,108 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+var binaryOperatorSymbols = map[BinaryOperator]string{
+	BinaryOperatorAdd: "+",
+	BinaryOperatorSub: "-",
+	BinaryOperatorMul: "*",
+	BinaryOperatorDiv: "/",
+	BinaryOperatorMod: "%",
+	BinaryOperatorEq:  "==",
+	BinaryOperatorNe:  "!=",
+	BinaryOperatorLt:  "<",
+	BinaryOperatorLe:  "<=",
+	BinaryOperatorGt:  ">",
+	BinaryOperatorGe:  ">=",
+}
+
+var paramTypeNames = map[ParamType]string{
+	ParamTypeTaskId:      "TaskId",
+	ParamTypeDescription: "Description",
+	ParamTypeDependency:  "Dependency",
+	ParamTypeFilter:      "Filter",
+	ParamTypeTaskIds:     "TaskIds",
+	ParamTypeReport:      "Report",
+	ParamTypeAnnotation:  "Annotation",
+}
+
+// Dump returns an indented tree of the node and its children, every node is
+// followed by its columns in the input e.g
+//
+//	Command(add) [0:25]
+//	  Param(Description) "milk" [5:9]
+//	  Tag(+home) [11:16]
+func Dump(node Node) string {
+	var builder strings.Builder
+	dump(&builder, node, 0)
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func dump(builder *strings.Builder, node Node, depth int) {
+	var indent = strings.Repeat("  ", depth)
+	var line = func(format string, args ...interface{}) {
+		fmt.Fprintf(builder, "%s%s [%d:%d]\n", indent, fmt.Sprintf(format, args...), node.StartColumn(), node.EndColumn())
+	}
+
+	switch n := node.(type) {
+	case *Command:
+		line("Command(%s)", n.Kind.String())
+		if n.Param != nil {
+			dump(builder, n.Param, depth+1)
+		}
+		for _, option := range n.Options {
+			dump(builder, option, depth+1)
+		}
+	case *Param:
+		switch value := n.Value.(type) {
+		case Node:
+			line("Param(%s)", paramTypeNames[n.Kind])
+			dump(builder, value, depth+1)
+		case ParamDependency:
+			line("Param(%s) %v on %d", paramTypeNames[n.Kind], value.TaskIds, value.DependsOnId)
+		case ParamAnnotation:
+			line("Param(%s) %v %q", paramTypeNames[n.Kind], value.TaskIds, value.Text)
+		case string:
+			line("Param(%s) %q", paramTypeNames[n.Kind], value)
+		default:
+			line("Param(%s) %v", paramTypeNames[n.Kind], value)
+		}
+	case *ExpressionStatement:
+		line("ExpressionStatement")
+		dump(builder, n.Expr, depth+1)
+	case *BinaryExpression:
+		line("BinaryExpression(%s)", binaryOperatorSymbols[n.Operator])
+		dump(builder, n.Left, depth+1)
+		dump(builder, n.Right, depth+1)
+	case *LogicalExpression:
+		var operator = "and"
+		if n.Operator == LogicalOperatorOr {
+			operator = "or"
+		}
+		line("LogicalExpression(%s)", operator)
+		dump(builder, n.Left, depth+1)
+		dump(builder, n.Right, depth+1)
+	case *Key:
+		if n.Match {
+			line("Key(%s~)", n.Key)
+		} else {
+			line("Key(%s)", n.Key)
+		}
+		if n.Expr != nil {
+			dump(builder, n.Expr, depth+1)
+		}
+	case *Tag:
+		var operator = "+"
+		if n.Operator == TagOperatorMinus {
+			operator = "-"
+		}
+		line("Tag(%s%s)", operator, n.Value)
+	case *Literal:
+		line("Literal(%s) %q", n.Kind.String(), n.Value)
+	default:
+		line("%T", n)
+	}
+}
@@ -76,7 +76,8 @@ func (b *BinaryExpression) EvalSelect(builder *sqlbuilder.SelectBuilder, addErro
 }
 
 func (bin *BinaryExpression) EvalInsert(transpiler *Transpiler) interface{} {
-	panic("implement me")
+	transpiler.AddError(fmt.Errorf("Arithmetic is only supported in dates e.g due:now+3d"), bin)
+	return nil
 }
 
 //=============================================================================
@@ -103,7 +104,7 @@ func (stmt *ExpressionStatement) Statement()  {}
 func (stmt *ExpressionStatement) Expression() {}
 
 func (stmt *ExpressionStatement) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError) interface{} {
-	return stmt.Expr.EvalSelect(builder, addError)
+	return selectCondition(stmt.Expr, builder, addError)
 }
 
 func (stmt *ExpressionStatement) EvalInsert(transpiler *Transpiler) interface{} {
@@ -228,23 +229,22 @@ func (l *LogicalExpression) Type() NodeType {
 func (l *LogicalExpression) Expression() {}
 
 func (l *LogicalExpression) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError) interface{} {
-	var left = l.Left.EvalSelect(builder, addError)
-	var right = l.Right.EvalSelect(builder, addError)
+	var left = selectCondition(l.Left, builder, addError)
+	var right = selectCondition(l.Right, builder, addError)
+	if left == "" || right == "" {
+		addError(fmt.Errorf("Logical expression requires a condition on both sides"))
+		return ""
+	}
 
-	switch left.(type) {
-	case string:
-		switch l.Operator {
-		case LogicalOperatorAnd:
-			return builder.And(left.(string), right.(string))
-		case LogicalOperatorOr:
-			return builder.Or(left.(string), right.(string))
-		default:
-			addError(fmt.Errorf("Unknown logical operator: %d", l.Operator))
-			return nil
-		}
+	switch l.Operator {
+	case LogicalOperatorAnd:
+		return builder.And(left, right)
+	case LogicalOperatorOr:
+		return builder.Or(left, right)
+	default:
+		addError(fmt.Errorf("Unknown logical operator: %d", l.Operator))
+		return ""
 	}
-	addError(fmt.Errorf("Expected string got %T", left))
-	return nil
 }
 
 func (logical *LogicalExpression) EvalInsert(transpiler *Transpiler) interface{} {
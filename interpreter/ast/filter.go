@@ -0,0 +1,46 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huandu/go-sqlbuilder"
+)
+
+// selectCondition evaluates a node that is used as a filter and returns the
+// WHERE condition it produced. A bare literal e.g list milk, is treated as a
+// case insensitive match against the task title.
+func selectCondition(node Node, builder *sqlbuilder.SelectBuilder, addError AddError) string {
+	if node == nil {
+		return ""
+	}
+	if lit, ok := node.(*Literal); ok {
+		return builder.Like("tasks.title", "%"+lit.Value+"%")
+	}
+	var cond = node.EvalSelect(builder, addError)
+	switch cond := cond.(type) {
+	case string:
+		return cond
+	case nil:
+		return ""
+	default:
+		addError(fmt.Errorf("Expected a filter condition got %T", cond))
+		return ""
+	}
+}
+
+// taskIdsWithTag selects the IDs of all tasks linked to the tag
+func taskIdsWithTag(name string) *sqlbuilder.SelectBuilder {
+	var sb = sqlbuilder.Select("taskTags.taskID").From("taskTags")
+	sb.Join("tags", "tags.id = taskTags.tagID")
+	sb.Where(sb.Equal("tags.name", strings.ToLower(name)))
+	return sb
+}
+
+// taskIdsInProject selects the IDs of all tasks linked to the project
+func taskIdsInProject(title string) *sqlbuilder.SelectBuilder {
+	var sb = sqlbuilder.Select("taskProjects.taskId").From("taskProjects")
+	sb.Join("projects", "projects.id = taskProjects.projectId")
+	sb.Where(sb.Equal("projects.title", strings.ToLower(title)))
+	return sb
+}
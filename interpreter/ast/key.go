@@ -1,12 +1,16 @@
 package ast
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/date"
 	"github.com/rs/zerolog/log"
 )
 
@@ -14,9 +18,13 @@ import (
 // Key
 // =============================================================================
 type Key struct {
-	Key  string
-	Expr Expression
+	Key   string
+	Expr  Expression
+	Match bool // True when the value is searched for e.g title~invoice, see SearchKeys
 	NodePosition
+
+	attribute *attributeValue // Set by the transpiler when the key is a user defined attribute
+	search    *searchFilter   // Set by the transpiler when the key is searched for
 }
 
 func (key *Key) Expression() {}
@@ -24,11 +32,68 @@ func (key *Key) Type() NodeType {
 	return NodeTypeBinaryExpression
 }
 
+// EvalSelect converts the key into a filter condition e.g project:home
 func (key *Key) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError) interface{} {
-	return key.Expr.EvalSelect(builder, addError)
+	if key.search != nil {
+		return key.search.evalSelect(builder)
+	}
+	if key.attribute != nil {
+		return key.attribute.evalSelect(builder)
+	}
+	var lit, ok = key.Expr.(*Literal)
+	if !ok {
+		addError(fmt.Errorf("Expected literal value for key: %s", key.Key))
+		return ""
+	}
+	switch strings.ToLower(key.Key) {
+	case "priority", "p":
+		var priority, err = lit.ToPriorityInt()
+		if err != nil {
+			addError(err)
+			return ""
+		}
+		return builder.Equal("tasks.priority", priority)
+	case "proj", "project":
+		return builder.In("tasks.id", taskIdsInProject(lit.Value))
+	case "id":
+		var id, err = strconv.ParseInt(lit.Value, 10, 64)
+		if err != nil {
+			addError(fmt.Errorf("Expected a TaskID for the id key"))
+			return ""
+		}
+		return builder.Equal("tasks.id", id)
+	case "sort":
+		addError(fmt.Errorf("The sort key must be an option of the list command e.g list +home sort:due+"))
+		return ""
+	case "limit":
+		addError(fmt.Errorf("The limit key must be an option of the list command e.g list +home limit:10"))
+		return ""
+	case "format":
+		addError(fmt.Errorf("The format key must be an option of the list command e.g list +home format:json"))
+		return ""
+	case "status":
+		switch strings.ToLower(lit.Value) {
+		case "pending":
+			return builder.NotEqual("tasks.state", db.TaskStateCompleted)
+		case "completed":
+			return builder.Equal("tasks.state", db.TaskStateCompleted)
+		case "all":
+			return ""
+		default:
+			addError(fmt.Errorf("Expected pending, completed or all for the status key"))
+			return ""
+		}
+	default:
+		addError(fmt.Errorf("Unknown key: %s", key.Key))
+		return ""
+	}
 }
 
 func (key *Key) EvalInsert(transpiler *Transpiler) interface{} {
+	if key.attribute != nil {
+		key.attribute.evalInsert(transpiler)
+		return nil
+	}
 	var lowerK = strings.ToLower(key.Key)
 	switch lowerK {
 	case "priority", "p":
@@ -39,12 +104,90 @@ func (key *Key) EvalInsert(transpiler *Transpiler) interface{} {
 		return key.handleProjectKey(transpiler)
 	case "deps", "dends", "dependencies":
 		return key.handleDependencies(transpiler)
+	case "due":
+		return key.handleDueKey(transpiler)
+	case "recur":
+		return key.handleRecurKey(transpiler)
+	case "until":
+		return key.handleUntilKey(transpiler)
 	default:
 		transpiler.AddError(fmt.Errorf("Unknown key: %s", key.Key), key)
 		return nil
 	}
 }
 
+// isProjectOption returns true if the statement is a project key e.g project:home
+func isProjectOption(option Statement) bool {
+	var stmt, ok = option.(*ExpressionStatement)
+	if !ok {
+		return false
+	}
+	var key, isKey = stmt.Expr.(*Key)
+	if !isKey {
+		return false
+	}
+	var lowerK = strings.ToLower(key.Key)
+	return lowerK == "proj" || lowerK == "project"
+}
+
+// handleDueKey resolves the date expression against the transpiler's clock e.g due:now+3d,
+// due:none removes the due date
+func (key *Key) handleDueKey(transpiler *Transpiler) interface{} {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok || lit.Kind != LiteralKindString {
+		transpiler.AddError(fmt.Errorf("Expected a date for the due key e.g due:tomorrow"), key)
+		return nil
+	}
+	transpiler.AddCol("dueUtc")
+	if strings.ToLower(lit.Value) == "none" {
+		transpiler.AddValue(nil)
+		return nil
+	}
+	var due, err = date.Parse(lit.Value, transpiler.now())
+	if err != nil {
+		transpiler.AddError(err, lit)
+		return nil
+	}
+	transpiler.AddValue(due.UTC().Format(db.SQLITE_TIME_FORMAT))
+	return due
+}
+
+// handleRecurKey validates the recurrence pattern e.g recur:weekly or recur:2w,
+// the template is created by the transpiler once the task exists
+func (key *Key) handleRecurKey(transpiler *Transpiler) interface{} {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok || lit.Kind != LiteralKindString {
+		transpiler.AddError(fmt.Errorf("Expected a pattern for the recur key e.g recur:weekly"), key)
+		return nil
+	}
+	var _, err = db.ParseRecurrence(lit.Value)
+	if err != nil {
+		transpiler.AddError(err, lit)
+		return nil
+	}
+	transpiler.recurrenceOptions(key).pattern = strings.ToLower(lit.Value)
+	return nil
+}
+
+// handleUntilKey sets the date after which a recurring task stops e.g until:eoy
+func (key *Key) handleUntilKey(transpiler *Transpiler) interface{} {
+	var lit, ok = key.Expr.(*Literal)
+	if !ok || lit.Kind != LiteralKindString {
+		transpiler.AddError(fmt.Errorf("Expected a date for the until key e.g until:eoy"), key)
+		return nil
+	}
+	var until, err = date.Parse(lit.Value, transpiler.now())
+	if err != nil {
+		transpiler.AddError(err, lit)
+		return nil
+	}
+	transpiler.recurrenceOptions(key).until = sql.NullString{
+		String: until.UTC().Format(db.SQLITE_TIME_FORMAT),
+		Valid:  true,
+	}
+	return nil
+}
+
 func (key *Key) handleProjectKey(transpiler *Transpiler) interface{} {
 	if key.Expr.Type() != NodeTypeLiteral {
 		transpiler.AddError(fmt.Errorf("Expected literal value for project key"), key)
@@ -56,6 +199,7 @@ func (key *Key) handleProjectKey(transpiler *Transpiler) interface{} {
 		return nil
 	}
 	var projectName = strings.ToLower(lit.Value)
+	var mark = transpiler.recorded()
 	var projectId, err = transpiler.store.ProjectGetIDByNameOrCreateTx(transpiler.tx, projectName)
 	if err != nil {
 		err = fmt.Errorf("Failed to get or create project with name: %s -> %w", projectName, err)
@@ -63,7 +207,8 @@ func (key *Key) handleProjectKey(transpiler *Transpiler) interface{} {
 		return nil
 	}
 
-	transpiler.addCallback(func(tx *sqlx.Tx, taskId int64) error {
+	var description = fmt.Sprintf("link project: %s", projectName)
+	transpiler.addCallbackSince(description, mark, func(tx *sqlx.Tx, taskId int64) error {
 		var err = transpiler.store.ProjectLinkTaskTx(tx, projectId, taskId)
 		if err != nil {
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
@@ -99,13 +244,26 @@ func (key *Key) handleDependencies(trans *Transpiler) interface{} {
 		trans.AddError(fmt.Errorf("Failed to parse taskId as Int64"), key)
 		return nil
 	}
+	if depOnTaskIdInt64 < 0 {
+		return key.handleRemoveDependency(trans, -depOnTaskIdInt64)
+	}
+	var mark = trans.recorded()
 	var exists = trans.store.TaskIdExistsAndNotCompleted(trans.tx, depOnTaskIdInt64)
 	if !exists {
 		trans.AddError(fmt.Errorf("Task dependency does not exist"), key)
 	}
 
-	trans.addCallback(func(tx *sqlx.Tx, taskId int64) error {
+	var description = fmt.Sprintf("depend on task: %d", depOnTaskIdInt64)
+	trans.addCallbackSince(description, mark, func(tx *sqlx.Tx, taskId int64) error {
+		if taskId == depOnTaskIdInt64 {
+			return fmt.Errorf("Task %d cannot depend on itself", taskId)
+		}
 		var err = trans.store.TaskDependsOnTx(tx, taskId, depOnTaskIdInt64)
+		var cycle *db.DependencyCycleError
+		if errors.As(err, &cycle) {
+			trans.AddError(cycle, key)
+			return err
+		}
 		if err != nil {
 			trans.AddError(fmt.Errorf("Failed to insert task dependency: %w", err), key)
 			return err
@@ -115,3 +273,22 @@ func (key *Key) handleDependencies(trans *Transpiler) interface{} {
 
 	return nil
 }
+
+// handleRemoveDependency removes the dependency on a task e.g deps:-3
+func (key *Key) handleRemoveDependency(trans *Transpiler, depOnTaskId int64) interface{} {
+	var description = fmt.Sprintf("no longer depend on task: %d", depOnTaskId)
+	trans.addCallback(description, func(tx *sqlx.Tx, taskId int64) error {
+		var found, err = trans.store.TaskUndependsOnTx(tx, taskId, depOnTaskId)
+		if err != nil {
+			trans.AddError(fmt.Errorf("Failed to remove task dependency: %w", err), key)
+			return err
+		}
+		if !found {
+			var err = fmt.Errorf("Task %d does not depend on task %d", taskId, depOnTaskId)
+			trans.AddError(err, key)
+			return err
+		}
+		return nil
+	})
+	return nil
+}
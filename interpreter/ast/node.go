@@ -39,6 +39,7 @@ type Node interface {
 	EndColumn() Column
 	StartLine() Line
 	EndLine() Line
+	SetColumns(start, end Column)
 	Transpile
 }
 
@@ -54,6 +55,12 @@ func (n *NodePosition) EndColumn() Column   { return n.endColumn }
 func (n *NodePosition) StartLine() Line     { return n.startLine }
 func (n *NodePosition) EndLine() Line       { return n.endLine }
 
+// SetColumns sets the span of the node in the input, the end is exclusive
+func (n *NodePosition) SetColumns(start, end Column) {
+	n.startColumn = start
+	n.endColumn = end
+}
+
 type Expression interface {
 	Node
 	Expression()
@@ -1,15 +1,26 @@
 package ast
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/huandu/go-sqlbuilder"
 )
 
+// MaxTaskIds limits how many tasks a single ID list can expand to e.g 1-100000
+const MaxTaskIds = 1000
+
 type ParamType int
 
 const (
 	ParamTypeTaskId      ParamType = iota // e.g 1"
 	ParamTypeDescription                  // e.g "buy dog"
 	ParamTypeDependency                   // e.g 1
+	ParamTypeFilter                       // e.g (+HOME and priority:high)
+	ParamTypeTaskIds                      // e.g 1-5,8
+	ParamTypeReport                       // e.g next
+	ParamTypeAnnotation                   // e.g 1 "pinged vendor"
 )
 
 // Param represents a parameter in the AST.
@@ -24,10 +35,17 @@ type Param struct {
 // ParamDependency represents a dependency parameter in the AST.
 // Some command require dependencies like `task 1 depends 2`
 type ParamDependency struct {
-	TaskId      int64
+	TaskIds     []int64 // e.g depends 1,2 on 3
 	DependsOnId int64
 }
 
+// ParamAnnotation represents the tasks and the text of a note e.g annotate 1,2 "pinged vendor"
+type ParamAnnotation struct {
+	TaskIds []int64 // e.g annotate 1,2 "pinged vendor"
+	Text    string  // e.g pinged vendor
+	Latest  bool    // True when denotate has no text and removes the most recent note
+}
+
 func (p *Param) Type() NodeType {
 	return NodeTypeParam
 }
@@ -41,3 +59,58 @@ func (p *Param) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError)
 func (p *Param) EvalInsert(transpiler *Transpiler) interface{} {
 	return ""
 }
+
+// TaskIds returns the task ids of a TaskId or TaskIds param
+func (p *Param) TaskIds() []int64 {
+	switch p.Kind {
+	case ParamTypeTaskId:
+		return []int64{p.Value.(int64)}
+	case ParamTypeTaskIds:
+		return p.Value.([]int64)
+	case ParamTypeAnnotation:
+		return p.Value.(ParamAnnotation).TaskIds
+	default:
+		return nil
+	}
+}
+
+// ParseTaskIds expands a list of task ids and ranges e.g 1-3,8 is 1,2,3,8
+// Duplicates are removed, the order of the first occurrence is kept
+func ParseTaskIds(value string) ([]int64, error) {
+	var ids = make([]int64, 0)
+	var seen = make(map[int64]bool)
+	for _, part := range strings.Split(value, ",") {
+		var bounds = strings.Split(part, "-")
+		if len(bounds) > 2 || part == "" {
+			return nil, fmt.Errorf("Invalid task id or range: %q, expected e.g 1-5,8", part)
+		}
+		var start, err = strconv.ParseInt(bounds[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid task id: %q", bounds[0])
+		}
+		var end = start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseInt(bounds[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid task id: %q", bounds[1])
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("Invalid task range: %s, the start must not be greater than the end", part)
+		}
+		if end-start >= MaxTaskIds {
+			return nil, fmt.Errorf("Task range %s is too large, the limit is %d tasks", part, MaxTaskIds)
+		}
+		for id := start; id <= end; id++ {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxTaskIds {
+		return nil, fmt.Errorf("Too many task ids, the limit is %d tasks", MaxTaskIds)
+	}
+	return ids, nil
+}
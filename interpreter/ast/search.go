@@ -0,0 +1,85 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// SearchKeys maps each key that searches with ~ to the columns it searches e.g title~invoice
+var SearchKeys = map[string][]string{
+	"title": {db.SearchColumnTitle},
+	"text":  db.SearchColumns,
+}
+
+// searchFilter selects the tasks whose columns contain every word of the text
+type searchFilter struct {
+	columns  []string // e.g title, see db.SearchColumns
+	text     string   // e.g old ticket
+	fullText bool     // True when the full text index exists, LIKE is used otherwise
+}
+
+// searchResolver finds every key that searches for its value e.g text~"old ticket"
+type searchResolver struct {
+	transpiler *Transpiler
+}
+
+// resolveSearches checks whether the full text index can be used by the searches of the command
+func (transpiler *Transpiler) resolveSearches(command *Command) {
+	Walk(&searchResolver{transpiler: transpiler}, command)
+}
+
+func (resolver *searchResolver) Visit(node Node) Visitor {
+	var key, ok = node.(*Key)
+	if !ok || !key.Match {
+		return resolver
+	}
+	var lit, isLiteral = key.Expr.(*Literal)
+	var columns, known = SearchKeys[strings.ToLower(key.Key)]
+	if !isLiteral || !known {
+		return resolver // Rejected by the semantic analysis
+	}
+	key.search = resolver.transpiler.searchFilter(columns, lit.Value)
+	return resolver
+}
+
+// searchFilter returns a filter that searches the columns for the text
+func (transpiler *Transpiler) searchFilter(columns []string, text string) *searchFilter {
+	return &searchFilter{
+		columns:  columns,
+		text:     text,
+		fullText: transpiler.store.FullTextSearchTx(transpiler.tx),
+	}
+}
+
+// evalSelect filters the tasks that match the search
+func (search *searchFilter) evalSelect(builder *sqlbuilder.SelectBuilder) string {
+	if search.fullText {
+		var sb = sqlbuilder.Select("taskSearch.rowid").From("taskSearch")
+		sb.Where("taskSearch MATCH " + sb.Var(db.SearchQuery(search.columns, search.text)))
+		return builder.In("tasks.id", sb)
+	}
+	var conditions = []string{}
+	for _, term := range db.SearchTerms(search.text) {
+		var pattern = "%" + term + "%"
+		var columns = make([]string, 0, len(search.columns))
+		for _, column := range search.columns {
+			switch column {
+			case db.SearchColumnTitle:
+				columns = append(columns, builder.Like("tasks.title", pattern))
+			case db.SearchColumnDescription:
+				columns = append(columns, builder.Like("tasks.description", pattern))
+			case db.SearchColumnNotes:
+				var sb = sqlbuilder.Select("taskAnnotations.taskId").From("taskAnnotations")
+				sb.Where(sb.Like("taskAnnotations.text", pattern))
+				columns = append(columns, builder.In("tasks.id", sb))
+			}
+		}
+		conditions = append(conditions, builder.Or(columns...))
+	}
+	if len(conditions) == 0 {
+		return "0" // The text does not contain any words
+	}
+	return builder.And(conditions...)
+}
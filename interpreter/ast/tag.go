@@ -1,7 +1,12 @@
 package ast
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
 )
 
 type TagOperator int // TagOperator is an enum for tag operators.
@@ -20,16 +25,127 @@ type Tag struct {
 	NodePosition
 }
 
+// VirtualTag is derived from the task rather than linked to it, virtual tags are
+// upper case and can only be used to filter tasks e.g list +BLOCKED
+type VirtualTag struct {
+	Name        string // e.g BLOCKED
+	Condition   string // SQL condition against the tasks table
+	Description string // e.g waiting on another task
+}
+
+// VirtualTags are every tag that maps to a derived property of the task
+var VirtualTags = []VirtualTag{
+	{
+		Name:        "BLOCKED",
+		Condition:   "tasks.id IN (SELECT taskDependencies.taskId FROM taskDependencies)",
+		Description: "depends on another task",
+	},
+	{
+		Name:        "BLOCKING",
+		Condition:   "tasks.id IN (SELECT taskDependencies.dependsOnId FROM taskDependencies)",
+		Description: "another task depends on it",
+	},
+	{
+		Name: "ACTIVE",
+		Condition: "tasks.id IN (SELECT taskTime.taskId FROM taskTime " +
+			"WHERE taskTime.startTimeUtc IS NOT NULL AND taskTime.endTimeUtc IS NULL)",
+		Description: "started and not stopped",
+	},
+	{
+		Name:        "OVERDUE",
+		Condition:   "(tasks.dueUtc IS NOT NULL AND tasks.dueUtc < current_timestamp)",
+		Description: "due before now",
+	},
+	{
+		Name:        "NEXT",
+		Condition:   "tasks.next = 1",
+		Description: "marked with the next command",
+	},
+	{
+		Name:        "TODAY",
+		Condition:   "(tasks.dueUtc IS NOT NULL AND date(tasks.dueUtc, 'localtime') = date('now', 'localtime'))",
+		Description: "due today",
+	},
+}
+
+// LookupVirtualTag returns the virtual tag with the name, the name is case sensitive
+// so +next is still a tag that the user created
+func LookupVirtualTag(name string) (VirtualTag, bool) {
+	for _, tag := range VirtualTags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return VirtualTag{}, false
+}
+
+// IsVirtual returns true if the tag is derived from the task e.g +BLOCKED
+func (t *Tag) IsVirtual() bool {
+	var _, ok = LookupVirtualTag(t.Value)
+	return ok
+}
+
 func (t *Tag) Type() NodeType {
 	return NodeTypeTag
 }
 
-func (t *Tag) Statement() {}
+func (t *Tag) Statement()  {}
+func (t *Tag) Expression() {}
 
+// EvalSelect filters tasks that have (+HOME) or do not have (-HOME) the tag
 func (t *Tag) EvalSelect(builder *sqlbuilder.SelectBuilder, addError AddError) interface{} {
-	return ""
+	if virtual, ok := LookupVirtualTag(t.Value); ok {
+		if t.Operator == TagOperatorMinus {
+			return builder.Not(virtual.Condition)
+		}
+		return virtual.Condition
+	}
+	if t.Operator == TagOperatorMinus {
+		return builder.NotIn("tasks.id", taskIdsWithTag(t.Value))
+	}
+	return builder.In("tasks.id", taskIdsWithTag(t.Value))
 }
 
+// EvalInsert attaches (+HOME) or detaches (-HOME) the tag once the task has been written
 func (t *Tag) EvalInsert(transpiler *Transpiler) interface{} {
-	panic("HEHEH")
+	if t.IsVirtual() {
+		transpiler.AddError(fmt.Errorf("The virtual tag %s can only be used to filter tasks", t.Value), t)
+		return nil
+	}
+	var tagName = strings.ToLower(t.Value)
+	if t.Operator == TagOperatorMinus {
+		transpiler.addCallback(fmt.Sprintf("unlink tag: %s", tagName), func(tx *sqlx.Tx, taskId int64) error {
+			var err = transpiler.store.TagUnlinkTaskByNameTx(tx, taskId, tagName)
+			if err != nil {
+				return fmt.Errorf("Failed to unlink tag: %s from task with ID: %d -> %w", tagName, taskId, err)
+			}
+			return nil
+		})
+		return nil
+	}
+
+	var mark = transpiler.recorded()
+	var tagId, err = transpiler.store.TagGetIDByNameOrCreateTx(transpiler.tx, tagName)
+	if err != nil {
+		err = fmt.Errorf("Failed to get or create tag with name: %s -> %w", tagName, err)
+		transpiler.AddError(err, t)
+		return nil
+	}
+
+	transpiler.addCallbackSince(fmt.Sprintf("link tag: %s", tagName), mark, func(tx *sqlx.Tx, taskId int64) error {
+		var err = transpiler.store.TagLinkTaskTx(tx, taskId, tagId)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				log.Warn().Msg("Tag already linked to task")
+				return nil
+			}
+			return fmt.Errorf(
+				"Failed to link tag with ID: %d to task with ID: %d -> %w",
+				tagId, taskId, err,
+			)
+		}
+		return nil
+	})
+
+	return nil
 }
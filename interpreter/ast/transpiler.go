@@ -1,11 +1,16 @@
 package ast
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/jmoiron/sqlx"
 	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/date"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,20 +27,56 @@ type TranspilerContext struct {
 	isPriorityKey bool
 }
 
+// recurrenceOptions are collected from the recur and until keys,
+// the template is created once the task has been inserted
+type recurrenceOptions struct {
+	pattern string         // e.g weekly
+	until   sql.NullString // e.g 2024-12-31 23:59:59
+	node    Node           // Used to report errors
+}
+
 // TranspileCallback is a function that is called after the transpiler has executed a SQL statement.
 // This is useful for executing additional SQL statements that are not part of the main transpilation process.
 type TranspileCallback func(tx *sqlx.Tx, taskId int64) error
 
+// callback is a TranspileCallback with a description of what it does e.g link tag home
+type callback struct {
+	description string
+	fn          TranspileCallback
+	operation   int // The index of the operation that describes the callback
+}
+
+// TranspiledStatement is a SQL statement that was built by the transpiler
+type TranspiledStatement struct {
+	Sql  SqlStatement
+	Args SqlArgs
+}
+
+// Operation is a store operation run by a command e.g link tag: home, the statements it ran
+// are only kept when the transaction is recorded, see db.RecordStatements
+type Operation struct {
+	Description string
+	Statements  []TranspiledStatement
+}
+
 type Transpiler struct {
-	errors    []TranspileError          // A list of errors that occurred during transpilation
-	values    []interface{}             // A list of values that are used in the SQL statement
-	cols      []string                  // A list of columns that are used in the SQL statement
-	Selecter  *sqlbuilder.SelectBuilder // A select builder
-	Inserter  *sqlbuilder.InsertBuilder // An insert builder
-	ctx       *TranspilerContext        // The transpiler context, carries information between transpilation steps
-	tx        *sqlx.Tx                  // The SQL transaction
-	store     *db.Store                 // The database store
-	callbacks []TranspileCallback       // When multiple transactions are needed
+	errors     []TranspileError          // A list of errors that occurred during transpilation
+	values     []interface{}             // A list of values that are used in the SQL statement
+	cols       []string                  // A list of columns that are used in the SQL statement
+	Selecter   *sqlbuilder.SelectBuilder // A select builder
+	Inserter   *sqlbuilder.InsertBuilder // An insert builder
+	ctx        *TranspilerContext        // The transpiler context, carries information between transpilation steps
+	tx         *sqlx.Tx                  // The SQL transaction
+	store      *db.Store                 // The database store
+	callbacks  []callback                // When multiple transactions are needed
+	statements []TranspiledStatement     // Every statement that was built, used to explain a command
+	operations []Operation               // Every store operation, used to explain a command
+	recorder   *db.Recorder              // Records the statements of each operation, nil if not explaining
+	current    int                       // The operation that the recorded statements belong to, -1 if none
+	mark       int                       // The number of statements recorded before the current operation
+	clock      date.Clock                // Dates e.g due:tomorrow are resolved relative to the clock
+	recurrence *recurrenceOptions        // Set by the recur and until keys
+	createdId  int64                     // The task inserted by the add command, 0 otherwise
 }
 
 // NewTranspiler creates a new transpiler with the given store.
@@ -45,11 +86,30 @@ func NewTranspiler(store *db.Store) *Transpiler {
 		values:    make([]interface{}, 0),
 		cols:      make([]string, 0),
 		ctx:       &TranspilerContext{},
-		callbacks: make([]TranspileCallback, 0),
+		callbacks: make([]callback, 0),
 		store:     store,
+		clock:     time.Now,
+		current:   -1,
 	}
 }
 
+// SetRecorder keeps the statements run by each store operation, the transaction must be
+// started with the same recorder e.g db.RecordStatements. A nil recorder stops recording
+func (transpiler *Transpiler) SetRecorder(recorder *db.Recorder) *Transpiler {
+	transpiler.recorder = recorder
+	return transpiler
+}
+
+// SetClock replaces the clock used to resolve dates, this is useful for testing
+func (transpiler *Transpiler) SetClock(clock date.Clock) *Transpiler {
+	transpiler.clock = clock
+	return transpiler
+}
+
+func (transpiler *Transpiler) now() time.Time {
+	return transpiler.clock()
+}
+
 // AddValue adds a value to the transpilers SQL args.
 func (transpiler *Transpiler) AddValue(value interface{}) {
 	transpiler.values = append(transpiler.values, value)
@@ -60,6 +120,28 @@ func (transpiler *Transpiler) AddCol(col string) {
 	transpiler.cols = append(transpiler.cols, col)
 }
 
+// dedupeCols keeps the last value of a repeated column e.g priority:high p:low,
+// sqlite would otherwise use the first value in an INSERT
+func (transpiler *Transpiler) dedupeCols() {
+	if len(transpiler.cols) != len(transpiler.values) {
+		return
+	}
+	var cols = make([]string, 0, len(transpiler.cols))
+	var values = make([]interface{}, 0, len(transpiler.values))
+	var index = make(map[string]int)
+	for i, col := range transpiler.cols {
+		if existing, ok := index[col]; ok {
+			values[existing] = transpiler.values[i]
+			continue
+		}
+		index[col] = len(cols)
+		cols = append(cols, col)
+		values = append(values, transpiler.values[i])
+	}
+	transpiler.cols = cols
+	transpiler.values = values
+}
+
 // AddError adds an error to the transpiler.
 func (transpiler *Transpiler) AddError(message error, node Node) {
 	transpiler.errors = append(transpiler.errors, TranspileError{
@@ -68,6 +150,15 @@ func (transpiler *Transpiler) AddError(message error, node Node) {
 	})
 }
 
+// callbackFailed reports the error of a postprocessing callback, unless the
+// callback already reported it against its own node e.g deps:1
+func (transpiler *Transpiler) callbackFailed(err error, command *Command) {
+	if len(transpiler.errors) != 0 {
+		return
+	}
+	transpiler.AddError(fmt.Errorf("Failed to execute postprocessing callback: %w", err), command)
+}
+
 // Reset resets the transpiler to it's original state, ready for the next command.
 func (transpiler *Transpiler) Reset() *Transpiler {
 	transpiler.errors = make([]TranspileError, 0)
@@ -77,12 +168,121 @@ func (transpiler *Transpiler) Reset() *Transpiler {
 	transpiler.Inserter = nil
 	transpiler.ctx = &TranspilerContext{}
 	transpiler.tx = nil
-	transpiler.callbacks = make([]TranspileCallback, 0)
+	transpiler.callbacks = make([]callback, 0)
+	transpiler.statements = make([]TranspiledStatement, 0)
+	transpiler.operations = make([]Operation, 0)
+	transpiler.current = -1
+	transpiler.recurrence = nil
+	transpiler.createdId = 0
 	return transpiler
 }
 
-func (transpiler *Transpiler) addCallback(fn TranspileCallback) {
-	transpiler.callbacks = append(transpiler.callbacks, fn)
+// addCallback describes the callback straight away, the statements are kept when it runs
+func (transpiler *Transpiler) addCallback(description string, fn TranspileCallback) {
+	transpiler.addCallbackSince(description, transpiler.recorded(), fn)
+}
+
+// addCallbackSince adds a callback that needed the store to be prepared e.g the tag was created,
+// mark is the number of statements recorded before the store was prepared
+func (transpiler *Transpiler) addCallbackSince(description string, mark int, fn TranspileCallback) {
+	transpiler.describeSince(description, mark)
+	transpiler.endOperation()
+	transpiler.callbacks = append(transpiler.callbacks, callback{
+		description: description,
+		fn:          fn,
+		operation:   len(transpiler.operations) - 1,
+	})
+}
+
+// runCallback runs the callback against the task and keeps the statements that it ran
+func (transpiler *Transpiler) runCallback(callback callback, taskId int64) error {
+	transpiler.endOperation()
+	transpiler.current = callback.operation
+	transpiler.mark = transpiler.recorded()
+	var err = callback.fn(transpiler.tx, taskId)
+	transpiler.endOperation()
+	return err
+}
+
+// describe keeps a description of a store operation for Operations, the statements recorded
+// until the next operation belong to it
+func (transpiler *Transpiler) describe(description string) {
+	transpiler.describeSince(description, transpiler.recorded())
+}
+
+// describeSince describes an operation that was run before it was described,
+// mark is the number of statements recorded before it ran
+func (transpiler *Transpiler) describeSince(description string, mark int) {
+	transpiler.endOperation()
+	transpiler.operations = append(transpiler.operations, Operation{Description: description})
+	transpiler.current = len(transpiler.operations) - 1
+	transpiler.mark = mark
+}
+
+// endOperation keeps the statements recorded since the current operation was described
+func (transpiler *Transpiler) endOperation() {
+	if transpiler.current >= 0 && transpiler.recorder != nil {
+		var operation = &transpiler.operations[transpiler.current]
+		for _, statement := range transpiler.recorder.Since(transpiler.mark) {
+			operation.Statements = append(operation.Statements, TranspiledStatement{
+				Sql:  SqlStatement(statement.Sql),
+				Args: SqlArgs(statement.Args),
+			})
+		}
+	}
+	transpiler.current = -1
+}
+
+// recorded returns the number of statements recorded so far, 0 if nothing is recorded
+func (transpiler *Transpiler) recorded() int {
+	if transpiler.recorder == nil {
+		return 0
+	}
+	return transpiler.recorder.Len()
+}
+
+// record logs a statement that was built and keeps it for Statements
+func (transpiler *Transpiler) record(sql string, args []interface{}) {
+	log.Info().Str("sql", sql).Interface("args", args).Msg("Transpiler produced")
+	transpiler.statements = append(transpiler.statements, TranspiledStatement{
+		Sql:  SqlStatement(sql),
+		Args: SqlArgs(args),
+	})
+}
+
+// Statements returns every statement built by the last call to Transpile in the order
+// they were built, including the statements used to find the tasks to modify
+func (transpiler *Transpiler) Statements() []TranspiledStatement {
+	return transpiler.statements
+}
+
+// Operations describes the store operations run by the last call to Transpile e.g link tag: home,
+// callbacks are described once even though they run for every inserted or modified task
+func (transpiler *Transpiler) Operations() []string {
+	var descriptions = make([]string, 0, len(transpiler.operations))
+	for _, operation := range transpiler.operations {
+		descriptions = append(descriptions, operation.Description)
+	}
+	return descriptions
+}
+
+// Plan returns the store operations run by the last call to Transpile with the statements
+// each of them ran, the statements are empty unless a recorder was set
+func (transpiler *Transpiler) Plan() []Operation {
+	return transpiler.operations
+}
+
+// CreatedTaskId returns the ID of the task inserted by the last call to Transpile, 0 if the
+// command was not an add command
+func (transpiler *Transpiler) CreatedTaskId() int64 {
+	return transpiler.createdId
+}
+
+func (transpiler *Transpiler) recurrenceOptions(node Node) *recurrenceOptions {
+	if transpiler.recurrence == nil {
+		transpiler.recurrence = &recurrenceOptions{node: node}
+	}
+	return transpiler.recurrence
 }
 
 func (transpiler *Transpiler) getContext() TranspilerContext {
@@ -99,17 +299,53 @@ func (transpiler *Transpiler) setContext(ctx TranspilerContext) {
 func (transpiler *Transpiler) Transpile(
 	command *Command,
 	tx *sqlx.Tx,
+) (SqlStatement, SqlArgs, []TranspileError) {
+	var sql, args, errs = transpiler.transpile(command, tx)
+	transpiler.endOperation()
+	return sql, args, errs
+}
+
+func (transpiler *Transpiler) transpile(
+	command *Command,
+	tx *sqlx.Tx,
 ) (SqlStatement, SqlArgs, []TranspileError) {
 	transpiler.tx = tx
+	transpiler.resolveAttributes(command)
+	transpiler.resolveSearches(command)
+	if len(transpiler.errors) != 0 {
+		return "", nil, transpiler.errors
+	}
 	switch command.Kind {
 	case CommandKindAdd:
 		return transpiler.transpileCommandAdd(command)
-	// case CommandKindList:
-	// 	return transpiler.transpileCommandList(command)
+	case CommandKindList, CommandKindSearch:
+		return transpiler.transpileCommandList(command)
 	case CommandKindDepends:
 		return "", nil, transpiler.transpileCommandDepends(command)
+	case CommandKindUndepends:
+		return "", nil, transpiler.transpileCommandUndepends(command)
 	case CommandKindNext:
 		return "", nil, transpiler.transpileCommandNext(command)
+	case CommandKindModify:
+		return transpiler.transpileCommandModify(command)
+	case CommandKindDone:
+		return "", nil, transpiler.transpileTaskIdCommand(command, transpiler.store.CompleteTaskTx)
+	case CommandKindDelete:
+		return "", nil, transpiler.transpileTaskIdCommand(command, transpiler.store.DeleteTaskByIdTx)
+	case CommandKindStart:
+		return "", nil, transpiler.transpileTaskIdCommand(command, transpiler.store.StartTrackingTaskTimeTx)
+	case CommandKindStop:
+		return "", nil, transpiler.transpileTaskIdCommand(command, transpiler.store.StopTrackingTaskTimeTx)
+	case CommandKindTags:
+		return transpiler.transpileCommandTags(command)
+	case CommandKindUndo:
+		return "", nil, transpiler.transpileCommandUndo(command)
+	case CommandKindAnnotate:
+		return "", nil, transpiler.transpileCommandAnnotate(command)
+	case CommandKindDenotate:
+		return "", nil, transpiler.transpileCommandDenotate(command)
+	case CommandKindInfo:
+		return "", nil, transpiler.transpileCommandInfo(command)
 	default:
 		transpiler.AddError(fmt.Errorf("Unknown command kind: %s", command.Kind.String()), command)
 		return "", nil, transpiler.errors
@@ -118,24 +354,51 @@ func (transpiler *Transpiler) Transpile(
 
 func (transpiler *Transpiler) transpileCommandList(command *Command) (SqlStatement, SqlArgs, []TranspileError) {
 	var builder = sqlbuilder.
-		Select("id").
+		Select("tasks.id").
 		From("tasks")
-	var whereClauses = command.EvalSelect(builder, nil)
-	var whereBuilder = whereClauses.(*sqlbuilder.SelectBuilder)
-	var sql, args = whereBuilder.Build()
+	command.EvalSelect(builder, func(err error) {
+		transpiler.AddError(err, command)
+	})
+	if text, ok := command.SearchText(); ok {
+		builder.Where(transpiler.searchFilter(db.SearchColumns, text).evalSelect(builder))
+	}
+	for _, option := range command.Options {
+		if key := sortOption(option); key != nil {
+			if _, err := key.sortKeys(transpiler.store.UDAs()); err != nil {
+				transpiler.AddError(err, key)
+			}
+		}
+		if key := limitOption(option); key != nil {
+			if _, err := key.limit(); err != nil {
+				transpiler.AddError(err, key)
+			}
+		}
+		if key := formatOption(option); key != nil {
+			if _, err := key.format(); err != nil {
+				transpiler.AddError(err, key)
+			}
+		}
+	}
+	if len(transpiler.errors) != 0 {
+		return "", nil, transpiler.errors
+	}
+	var sql, args = builder.Build()
+	transpiler.record(sql, args)
 	return SqlStatement(sql), SqlArgs(args), transpiler.errors
 }
 
 func (transpiler *Transpiler) transpileCommandAdd(command *Command) (SqlStatement, SqlArgs, []TranspileError) {
 	transpiler.Inserter = sqlbuilder.InsertInto("tasks")
 	command.EvalInsert(transpiler)
+	transpiler.addRecurrence()
+	transpiler.dedupeCols()
 	transpiler.Inserter.Cols(transpiler.cols...)
 	transpiler.Inserter.Values(transpiler.values...)
 	if len(transpiler.errors) != 0 {
 		return "", nil, transpiler.errors
 	}
 	var sql, args = transpiler.Inserter.Build()
-	log.Info().Str("sql", sql).Interface("args", args).Msg("Transpiler produced")
+	transpiler.record(sql, args)
 	var res, err = transpiler.tx.Exec(sql, args...)
 	var taskId int64
 	if err != nil {
@@ -144,37 +407,300 @@ func (transpiler *Transpiler) transpileCommandAdd(command *Command) (SqlStatemen
 	}
 
 	taskId, err = res.LastInsertId()
+	transpiler.createdId = taskId
 	for _, callback := range transpiler.callbacks {
-		var err = callback(transpiler.tx, taskId)
+		var err = transpiler.runCallback(callback, taskId)
 		if err != nil {
-			transpiler.AddError(fmt.Errorf("Failed to execute postprocessing callback: %w", err), command)
+			transpiler.callbackFailed(err, command)
 			return "", nil, transpiler.errors
 		}
 	}
 	return SqlStatement(sql), SqlArgs(args), transpiler.errors
 }
 
+// addRecurrence creates the template for the recur key once the task has been inserted,
+// recurring tasks without a due date are due now
+func (tran *Transpiler) addRecurrence() {
+	var recurrence = tran.recurrence
+	if recurrence == nil {
+		return
+	}
+	if recurrence.pattern == "" {
+		tran.AddError(fmt.Errorf("The until key requires a recur key e.g recur:weekly"), recurrence.node)
+		return
+	}
+	var hasDue = false
+	for _, col := range tran.cols {
+		hasDue = hasDue || col == "dueUtc"
+	}
+	if !hasDue {
+		tran.AddCol("dueUtc")
+		tran.AddValue(tran.now().UTC().Format(db.SQLITE_TIME_FORMAT))
+	}
+	var description = fmt.Sprintf("create a recurrence template: %s", recurrence.pattern)
+	tran.addCallback(description, func(tx *sqlx.Tx, taskId int64) error {
+		var _, err = tran.store.TemplateCreateTx(tx, taskId, recurrence.pattern, recurrence.until)
+		return err
+	})
+}
+
 func (tran *Transpiler) transpileCommandDepends(command *Command) []TranspileError {
 	var param = command.Param.Value.(ParamDependency)
-	var err = tran.store.TaskDependsOnTx(tran.tx, param.TaskId, param.DependsOnId)
-	if err != nil {
-		tran.AddError(fmt.Errorf("Failed to insert task dependency: %w", err), command)
-		return tran.errors
+	for _, taskId := range param.TaskIds {
+		tran.describe(fmt.Sprintf("task %d depends on task %d", taskId, param.DependsOnId))
+		var err = tran.store.TaskDependsOnTx(tran.tx, taskId, param.DependsOnId)
+		var cycle *db.DependencyCycleError
+		if errors.As(err, &cycle) {
+			tran.AddError(cycle, command.Param)
+		} else if err != nil {
+			tran.AddError(fmt.Errorf("Failed to insert task dependency for task %d: %w", taskId, err), command)
+		}
+	}
+	return tran.errors
+}
+
+func (tran *Transpiler) transpileCommandUndepends(command *Command) []TranspileError {
+	var param = command.Param.Value.(ParamDependency)
+	for _, taskId := range param.TaskIds {
+		tran.describe(fmt.Sprintf("task %d no longer depends on task %d", taskId, param.DependsOnId))
+		var found, err = tran.store.TaskUndependsOnTx(tran.tx, taskId, param.DependsOnId)
+		if err != nil {
+			tran.AddError(fmt.Errorf("Failed to remove task dependency for task %d: %w", taskId, err), command)
+			continue
+		}
+		if !found {
+			tran.AddError(fmt.Errorf("Task %d does not depend on task %d", taskId, param.DependsOnId), command.Param)
+		}
 	}
 	return tran.errors
 }
 
 func (tran *Transpiler) transpileCommandNext(command *Command) []TranspileError {
-	var taskId = command.Param.Value.(int64)
-	if taskId < 0 {
-		tran.AddError(fmt.Errorf("TaskId must be greater than zero"), command)
-		return tran.errors
+	for _, taskId := range command.Param.TaskIds() {
+		if taskId <= 0 {
+			tran.AddError(fmt.Errorf("TaskId must be greater than zero"), command)
+			continue
+		}
+		tran.describe(fmt.Sprintf("toggle next on task %d", taskId))
+		var err = tran.store.TaskToggleNextTx(tran.tx, taskId)
+		if err != nil {
+			tran.AddError(fmt.Errorf("Failed to mark task %d as next: %w", taskId, err), command)
+		}
+	}
+	return tran.errors
+}
+
+// transpileTaskIdCommand runs a store operation against every task in the command's param,
+// the operation reports false when the task does not exist.
+// Every failing task is reported, the transaction is rolled back if any task fails
+func (tran *Transpiler) transpileTaskIdCommand(
+	command *Command,
+	operation func(tx *sqlx.Tx, taskId int64) (bool, error),
+) []TranspileError {
+	for _, taskId := range command.Param.TaskIds() {
+		if taskId <= 0 {
+			tran.AddError(fmt.Errorf("TaskId must be greater than zero"), command)
+			continue
+		}
+		tran.describe(fmt.Sprintf("%s task %d", command.Kind.String(), taskId))
+		var found, err = operation(tran.tx, taskId)
+		if err != nil {
+			tran.AddError(fmt.Errorf("Failed to %s task %d: %w", command.Kind.String(), taskId, err), command)
+			continue
+		}
+		if !found {
+			tran.AddError(fmt.Errorf("Task %d does not exist", taskId), command.Param)
+		}
+	}
+	return tran.errors
+}
+
+func (tran *Transpiler) transpileCommandTags(command *Command) (SqlStatement, SqlArgs, []TranspileError) {
+	var builder = sqlbuilder.
+		Select("tags.id", "tags.name", "COUNT(taskTags.taskID) AS count").
+		From("tags").
+		JoinWithOption(sqlbuilder.LeftJoin, "taskTags", "taskTags.tagID = tags.id").
+		GroupBy("tags.id").
+		OrderBy("tags.name")
+	var sql, args = builder.Build()
+	tran.record(sql, args)
+	return SqlStatement(sql), SqlArgs(args), tran.errors
+}
+
+func (tran *Transpiler) transpileCommandAnnotate(command *Command) []TranspileError {
+	var param = command.Param.Value.(ParamAnnotation)
+	for _, taskId := range param.TaskIds {
+		tran.describe(fmt.Sprintf("annotate task %d: %s", taskId, param.Text))
+		var found, err = tran.store.AnnotateTaskTx(tran.tx, taskId, strings.TrimSpace(param.Text))
+		if err != nil {
+			tran.AddError(err, command)
+			continue
+		}
+		if !found {
+			tran.AddError(fmt.Errorf("Task %d does not exist", taskId), command.Param)
+		}
+	}
+	return tran.errors
+}
+
+func (tran *Transpiler) transpileCommandDenotate(command *Command) []TranspileError {
+	var param = command.Param.Value.(ParamAnnotation)
+	for _, taskId := range param.TaskIds {
+		var found bool
+		var err error
+		if param.Latest {
+			tran.describe(fmt.Sprintf("denotate the most recent note of task %d", taskId))
+			found, err = tran.store.DenotateLatestTaskTx(tran.tx, taskId)
+		} else {
+			tran.describe(fmt.Sprintf("denotate task %d: %s", taskId, param.Text))
+			found, err = tran.store.DenotateTaskTx(tran.tx, taskId, param.Text)
+		}
+		if err != nil {
+			tran.AddError(err, command)
+			continue
+		}
+		if !found && param.Latest {
+			tran.AddError(fmt.Errorf("Task %d does not have any notes", taskId), command.Param)
+		} else if !found {
+			tran.AddError(fmt.Errorf("Task %d does not have the note %q", taskId, param.Text), command.Param)
+		}
+	}
+	return tran.errors
+}
+
+// transpileCommandInfo checks that the task exists, the task and its history are read
+// after the program has run so nothing is changed
+func (tran *Transpiler) transpileCommandInfo(command *Command) []TranspileError {
+	for _, taskId := range command.Param.TaskIds() {
+		if !tran.store.TaskIdExistsTx(tran.tx, taskId) {
+			tran.AddError(fmt.Errorf("Task %d does not exist", taskId), command.Param)
+		}
 	}
-	var err = tran.store.TaskToggleNextTx(tran.tx, taskId)
+	return tran.errors
+}
+
+// transpileCommandUndo reverts the most recent operation in the undo journal
+func (tran *Transpiler) transpileCommandUndo(command *Command) []TranspileError {
+	var mark = tran.recorded()
+	var operation, err = tran.store.UndoTx(tran.tx)
 	if err != nil {
-		tran.AddError(fmt.Errorf("Failed to mark task as next: %w", err), command)
+		tran.AddError(err, command)
 		return tran.errors
 	}
+	tran.describeSince("undo: "+operation.Description, mark)
 	return tran.errors
+}
+
+func (tran *Transpiler) transpileCommandModify(command *Command) (SqlStatement, SqlArgs, []TranspileError) {
+	var taskIds = tran.modifyTargetIds(command)
+	if len(tran.errors) != 0 {
+		return "", nil, tran.errors
+	}
+
+	var title = make([]string, 0)
+	var projectsCleared = false // modify replaces the projects, only clear them once
+	for _, option := range command.Options {
+		if isProjectOption(option) && !projectsCleared {
+			projectsCleared = true
+			tran.addCallback("unlink every project", func(tx *sqlx.Tx, taskId int64) error {
+				return tran.store.ProjectUnlinkAllTx(tx, taskId)
+			})
+		}
+		if stmt, ok := option.(*ExpressionStatement); ok {
+			if lit, ok := stmt.Expr.(*Literal); ok {
+				title = append(title, lit.Value)
+				continue
+			}
+		}
+		option.EvalInsert(tran)
+	}
+	if len(title) > 0 {
+		tran.AddCol("title")
+		tran.AddValue(strings.Join(title, " "))
+	}
+	if tran.recurrence != nil {
+		tran.AddError(fmt.Errorf("Recurrence can only be set when adding a task"), tran.recurrence.node)
+	}
+	if len(tran.cols) != len(tran.values) {
+		tran.AddError(fmt.Errorf("Expected a value for every modified column"), command)
+	}
+	if len(tran.errors) != 0 {
+		return "", nil, tran.errors
+	}
+
+	var updater = sqlbuilder.Update("tasks")
+	var assignments = []string{"updatedAtUtc = current_timestamp"}
+	for i, col := range tran.cols {
+		assignments = append(assignments, updater.Assign(col, tran.values[i]))
+	}
+	var ids = make([]interface{}, 0, len(taskIds))
+	for _, id := range taskIds {
+		ids = append(ids, id)
+	}
+	updater.Set(assignments...).Where(updater.In("id", ids...))
+
+	var sql, args = updater.Build()
+	tran.record(sql, args)
+	var res, err = tran.tx.Exec(sql, args...)
+	if err != nil {
+		tran.AddError(fmt.Errorf("Failed to modify task: %w", err), command)
+		return "", nil, tran.errors
+	}
+	var affected int64
+	affected, err = res.RowsAffected()
+	if err != nil || affected != int64(len(taskIds)) {
+		tran.AddError(fmt.Errorf("Failed to modify task, task does not exist"), command)
+		return "", nil, tran.errors
+	}
+
+	for _, taskId := range taskIds {
+		for _, callback := range tran.callbacks {
+			var err = tran.runCallback(callback, taskId)
+			if err != nil {
+				tran.callbackFailed(err, command)
+				return "", nil, tran.errors
+			}
+		}
+	}
+	return SqlStatement(sql), SqlArgs(args), tran.errors
+}
 
+// modifyTargetIds returns the IDs of the tasks that the modify command will change
+func (tran *Transpiler) modifyTargetIds(command *Command) []int64 {
+	switch command.Param.Kind {
+	case ParamTypeTaskId, ParamTypeTaskIds:
+		var ids = command.Param.TaskIds()
+		for _, id := range ids {
+			if !tran.store.TaskIdExistsTx(tran.tx, id) {
+				tran.AddError(fmt.Errorf("Task %d does not exist", id), command.Param)
+			}
+		}
+		return ids
+	case ParamTypeFilter:
+		var filter = command.Param.Value.(Expression)
+		var builder = sqlbuilder.Select("tasks.id").From("tasks")
+		var cond = selectCondition(filter, builder, func(err error) {
+			tran.AddError(err, filter)
+		})
+		if len(tran.errors) != 0 {
+			return nil
+		}
+		builder.Where(cond, builder.NotEqual("tasks.state", db.TaskStateCompleted))
+		var sql, args = builder.Build()
+		tran.record(sql, args)
+		var ids []int64
+		var err = tran.tx.Select(&ids, sql, args...)
+		if err != nil {
+			tran.AddError(fmt.Errorf("Failed to select tasks matching the filter: %w", err), filter)
+			return nil
+		}
+		if len(ids) == 0 {
+			tran.AddError(fmt.Errorf("No tasks matched the filter"), filter)
+			return nil
+		}
+		return ids
+	default:
+		tran.AddError(fmt.Errorf("Modify requires a task ID or a filter"), command)
+		return nil
+	}
 }
@@ -14,8 +14,20 @@ func Walk(v Visitor, node Node) {
 	case *Literal:
 	case *Tag:
 
+	case *Key:
+		if n.Expr != nil {
+			Walk(v, n.Expr)
+		}
+
+	case *Param:
+		if child, ok := n.Value.(Node); ok {
+			Walk(v, child)
+		}
+
 	case *Command:
-		Walk(v, n.Param)
+		if n.Param != nil {
+			Walk(v, n.Param)
+		}
 		for _, node := range n.Options {
 			Walk(v, node)
 		}
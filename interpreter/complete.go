@@ -0,0 +1,383 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/config"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/lex"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/semantic"
+	"github.com/luke-goddard/taskninja/interpreter/token"
+	"github.com/luke-goddard/taskninja/output"
+)
+
+type CompletionKind string // What is being completed
+
+const (
+	CompletionKindCommand CompletionKind = "command" // e.g add
+	CompletionKindKey     CompletionKind = "key"     // e.g priority:
+	CompletionKindValue   CompletionKind = "value"   // e.g the high in priority:high
+	CompletionKindTag     CompletionKind = "tag"     // e.g +home
+	CompletionKindProject CompletionKind = "project" // e.g the home in project:home
+	CompletionKindTaskId  CompletionKind = "task"    // e.g the 3 in done 3
+	CompletionKindReport  CompletionKind = "report"  // e.g the next in report next
+)
+
+// Completion is a single proposal for the word under the cursor
+type Completion struct {
+	Text        string         // Replaces the word under the cursor e.g priority:
+	Description string         // Shown next to the text e.g the title of a task
+	Kind        CompletionKind // What is being completed
+}
+
+// Completions are the proposals for the word that starts at Start and ends at the cursor
+type Completions struct {
+	Start int // Byte offset of the word under the cursor
+	End   int // Byte offset of the cursor
+	Items []Completion
+}
+
+// Apply replaces the word under the cursor with the item, returning the new input
+// and the byte offset of the cursor after the inserted text
+func (completions *Completions) Apply(input string, index int) (string, int) {
+	var item = completions.Items[index]
+	var output = input[:completions.Start] + item.Text + input[completions.End:]
+	return output, completions.Start + len(item.Text)
+}
+
+var priorityValues = []Completion{
+	{Text: "high", Kind: CompletionKindValue},
+	{Text: "medium", Kind: CompletionKindValue},
+	{Text: "low", Kind: CompletionKindValue},
+	{Text: "none", Kind: CompletionKindValue},
+}
+
+var dateValues = []Completion{
+	{Text: "now", Kind: CompletionKindValue},
+	{Text: "today", Kind: CompletionKindValue},
+	{Text: "tomorrow", Kind: CompletionKindValue},
+	{Text: "yesterday", Kind: CompletionKindValue},
+	{Text: "eod", Description: "end of day", Kind: CompletionKindValue},
+	{Text: "eow", Description: "end of week", Kind: CompletionKindValue},
+	{Text: "eom", Description: "end of month", Kind: CompletionKindValue},
+	{Text: "eoy", Description: "end of year", Kind: CompletionKindValue},
+	{Text: "monday", Kind: CompletionKindValue},
+	{Text: "tuesday", Kind: CompletionKindValue},
+	{Text: "wednesday", Kind: CompletionKindValue},
+	{Text: "thursday", Kind: CompletionKindValue},
+	{Text: "friday", Kind: CompletionKindValue},
+	{Text: "saturday", Kind: CompletionKindValue},
+	{Text: "sunday", Kind: CompletionKindValue},
+}
+
+var statusValues = []Completion{
+	{Text: "pending", Kind: CompletionKindValue},
+	{Text: "completed", Kind: CompletionKindValue},
+	{Text: "all", Kind: CompletionKindValue},
+}
+
+var recurValues = []Completion{
+	{Text: "daily", Kind: CompletionKindValue},
+	{Text: "weekly", Kind: CompletionKindValue},
+	{Text: "biweekly", Kind: CompletionKindValue},
+	{Text: "monthly", Kind: CompletionKindValue},
+	{Text: "quarterly", Kind: CompletionKindValue},
+	{Text: "yearly", Kind: CompletionKindValue},
+}
+
+// Completer proposes commands, keys, tags, projects and task ids for partial input
+type Completer struct {
+	store   *db.Store
+	lexer   *lex.Lexer
+	reports []string // Names of the reports e.g next
+}
+
+// NewCompleter creates a completer, tags, projects and tasks are read from the store
+func NewCompleter(store *db.Store) *Completer {
+	var lexer = lex.NewLexer(manager.NewErrorManager())
+	lexer.SetTolerant(true)
+	return &Completer{store: store, lexer: lexer, reports: config.ReportNames(config.DefaultReports())}
+}
+
+// SetReports replaces the report names proposed after the report command
+func (completer *Completer) SetReports(names []string) *Completer {
+	completer.reports = names
+	return completer
+}
+
+// completionContext is what the completer knows about the input before the cursor
+type completionContext struct {
+	tokens  []token.Token // Tokens before the word under the cursor
+	partial string        // The word under the cursor, may be empty
+	command string        // The command e.g add, empty if there is not one yet
+}
+
+// Complete returns the proposals for the word under the cursor, the cursor is a byte offset
+func (completer *Completer) Complete(ctx context.Context, input string, cursor int) (*Completions, error) {
+	cursor = min(max(cursor, 0), len(input))
+	var prefix = input[:cursor]
+	var tokens, _ = completer.lexer.Reset().SetRawValueKeys(completer.store.UDANames()).SetInput(prefix).Tokenize()
+
+	var completions = &Completions{Start: cursor, End: cursor, Items: make([]Completion, 0)}
+	var last = len(tokens) - 1
+	if last >= 0 && int(tokens[last].EndPosition) == cursor && !endsWithSpace(prefix) {
+		var start = int(tokens[last].StartPosition)
+		if tokens[last].Type == token.String && start > 0 && isQuote(prefix[start-1]) {
+			return completions, nil // Inside a quoted description
+		}
+		if tokens[last].Type != token.Colon {
+			completions.Start = start
+			tokens = tokens[:last]
+		}
+	}
+
+	var context = completionContext{tokens: tokens, partial: prefix[completions.Start:]}
+	for _, tok := range tokens {
+		if tok.Type == token.Command {
+			context.command = strings.ToLower(tok.Value)
+		}
+	}
+
+	var candidates, err = completer.candidates(ctx, &context)
+	if err != nil {
+		return nil, err
+	}
+	var lower = strings.ToLower(context.partial)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate.Text), lower) {
+			completions.Items = append(completions.Items, candidate)
+		}
+	}
+	return completions, nil
+}
+
+// candidates returns every proposal that fits the position of the word under the cursor
+func (completer *Completer) candidates(ctx context.Context, context *completionContext) ([]Completion, error) {
+	if len(context.tokens) == 0 {
+		return commandCompletions(), nil
+	}
+	if key, ok := context.valueKey(); ok {
+		return completer.valueCompletions(ctx, key)
+	}
+
+	switch context.command {
+	case "", "tags", "undo":
+		return nil, nil
+	case "next", "done", "delete", "start", "stop", "depends", "undepends":
+		return completer.taskCompletions(ctx)
+	case "annotate", "denotate", "info":
+		if len(context.tokens) == 1 {
+			return completer.taskCompletions(ctx)
+		}
+	case "modify":
+		if len(context.tokens) == 1 {
+			return completer.taskCompletions(ctx)
+		}
+		return completer.optionCompletions(ctx, context.partial, semantic.KeyUsageInsert)
+	case "add":
+		if len(context.tokens) == 1 {
+			return nil, nil // The description comes first
+		}
+		return completer.optionCompletions(ctx, context.partial, semantic.KeyUsageInsert)
+	case "list":
+		return completer.optionCompletions(ctx, context.partial, semantic.KeyUsageFilter)
+	case "search":
+		if len(context.tokens) == 1 {
+			return nil, nil // The text comes first
+		}
+		return completer.optionCompletions(ctx, context.partial, semantic.KeyUsageFilter)
+	case "report":
+		if len(context.tokens) == 1 {
+			return completer.reportCompletions(), nil
+		}
+	}
+	return nil, nil
+}
+
+// valueKey returns the key when the word under the cursor is its value e.g project:ho
+func (context *completionContext) valueKey() (string, bool) {
+	var tokens = context.tokens
+	var last = len(tokens) - 1
+	if last < 1 || tokens[last].Type != token.Colon || tokens[last-1].Type != token.Key {
+		return "", false
+	}
+	return strings.ToLower(tokens[last-1].Value), true
+}
+
+func commandCompletions() []Completion {
+	var completions = make([]Completion, 0, len(lex.Commands))
+	for _, command := range lex.Commands {
+		completions = append(completions, Completion{Text: string(command), Kind: CompletionKindCommand})
+	}
+	return completions
+}
+
+// optionCompletions proposes tags for words that start with + or - and keys otherwise
+func (completer *Completer) optionCompletions(ctx context.Context, partial string, usage semantic.KeyUsage) ([]Completion, error) {
+	if strings.HasPrefix(partial, "+") || strings.HasPrefix(partial, "-") {
+		var completions, err = completer.tagCompletions(ctx, partial[:1])
+		if err != nil || usage != semantic.KeyUsageFilter {
+			return completions, err
+		}
+		for _, tag := range ast.VirtualTags {
+			completions = append(completions, Completion{
+				Text:        partial[:1] + tag.Name,
+				Description: tag.Description,
+				Kind:        CompletionKindTag,
+			})
+		}
+		return completions, nil
+	}
+	var completions = make([]Completion, 0, len(semantic.Keys))
+	for _, key := range semantic.Keys {
+		if key.Accepts(usage) {
+			var operator = ":"
+			if _, searches := ast.SearchKeys[key.Name]; searches {
+				operator = "~"
+			}
+			completions = append(completions, Completion{
+				Text:        key.Name + operator,
+				Description: key.Description,
+				Kind:        CompletionKindKey,
+			})
+		}
+	}
+	for _, name := range completer.store.UDANames() {
+		var uda, _ = completer.store.UDA(name)
+		var description = uda.Label
+		if description == "" {
+			description = string(uda.Type)
+		}
+		completions = append(completions, Completion{Text: name + ":", Description: description, Kind: CompletionKindKey})
+	}
+	return completions, nil
+}
+
+func (completer *Completer) valueCompletions(ctx context.Context, key string) ([]Completion, error) {
+	var definition, ok = semantic.LookupKey(key)
+	if !ok {
+		return attributeCompletions(completer.store, key), nil
+	}
+	switch definition.Name {
+	case "priority":
+		return priorityValues, nil
+	case "due", "until":
+		return dateValues, nil
+	case "recur":
+		return recurValues, nil
+	case "project":
+		return completer.projectCompletions()
+	case "deps", "id":
+		return completer.taskCompletions(ctx)
+	case "sort":
+		return sortCompletions(completer.store.UDANames()), nil
+	case "status":
+		return statusValues, nil
+	case "format":
+		return formatCompletions(), nil
+	}
+	return nil, nil
+}
+
+// attributeCompletions proposes the allowed values of a user defined attribute
+func attributeCompletions(store *db.Store, name string) []Completion {
+	var uda, ok = store.UDA(name)
+	if !ok {
+		return nil
+	}
+	if uda.Type == config.UDATypeDate && len(uda.Values) == 0 {
+		return dateValues
+	}
+	var completions = make([]Completion, 0, len(uda.Values))
+	for _, value := range uda.Values {
+		completions = append(completions, Completion{Text: value, Kind: CompletionKindValue})
+	}
+	return completions
+}
+
+// formatCompletions proposes every format the tasks can be printed in
+func formatCompletions() []Completion {
+	var completions = make([]Completion, 0, len(output.Formats))
+	for _, format := range output.Formats {
+		completions = append(completions, Completion{Text: string(format), Kind: CompletionKindValue})
+	}
+	return completions
+}
+
+// sortCompletions proposes every field that tasks can be sorted by in both directions,
+// including the user defined attributes
+func sortCompletions(udas []string) []Completion {
+	var fields = append(db.TaskSortFields(), udas...)
+	var completions = make([]Completion, 0, len(fields)*2)
+	for _, field := range fields {
+		completions = append(completions,
+			Completion{Text: field + "+", Description: "ascending", Kind: CompletionKindValue},
+			Completion{Text: field + "-", Description: "descending", Kind: CompletionKindValue},
+		)
+	}
+	return completions
+}
+
+func (completer *Completer) reportCompletions() []Completion {
+	var completions = make([]Completion, 0, len(completer.reports))
+	for _, name := range completer.reports {
+		completions = append(completions, Completion{Text: name, Kind: CompletionKindReport})
+	}
+	return completions
+}
+
+func (completer *Completer) tagCompletions(ctx context.Context, operator string) ([]Completion, error) {
+	var tags, err = completer.store.TagList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var completions = make([]Completion, 0, len(tags))
+	for _, tag := range tags {
+		completions = append(completions, Completion{Text: operator + tag.Name, Kind: CompletionKindTag})
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Text < completions[j].Text })
+	return completions, nil
+}
+
+func (completer *Completer) projectCompletions() ([]Completion, error) {
+	var projects, err = completer.store.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list projects: %w", err)
+	}
+	var completions = make([]Completion, 0, len(projects))
+	for _, project := range projects {
+		completions = append(completions, Completion{Text: project.Title, Kind: CompletionKindProject})
+	}
+	sort.Slice(completions, func(i, j int) bool { return completions[i].Text < completions[j].Text })
+	return completions, nil
+}
+
+func (completer *Completer) taskCompletions(ctx context.Context) ([]Completion, error) {
+	var tasks, err = completer.store.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	var completions = make([]Completion, 0, len(tasks))
+	for _, task := range tasks {
+		completions = append(completions, Completion{
+			Text:        fmt.Sprintf("%d", task.ID),
+			Description: task.Title,
+			Kind:        CompletionKindTaskId,
+		})
+	}
+	return completions, nil
+}
+
+func endsWithSpace(input string) bool {
+	return len(input) > 0 && lex.IsWhitespace(rune(input[len(input)-1]))
+}
+
+func isQuote(b byte) bool {
+	return b == '"' || b == '\''
+}
@@ -0,0 +1,175 @@
+// Resolves date expressions e.g due:tomorrow, due:eow or due:now+3d
+//
+// ========================================================
+// Grammar:
+// ========================================================
+// DATE_EXPRESSION -> BASE | BASE OFFSETS | OFFSETS
+// BASE -> ISO_DATE | NAMED_DATE
+// ISO_DATE -> 2006-01-02 | 2006-01-02T15:04 | 2006-01-02T15:04:05
+// NAMED_DATE -> now | today | tomorrow | yesterday | eod | eow | eom | eoy | WEEKDAY
+// WEEKDAY -> monday | tuesday | ... | sunday (or mon, tue, ...)
+// OFFSETS -> OFFSET | OFFSET OFFSETS
+// OFFSET -> +DURATION | -DURATION | DURATION (only when there is no base)
+// DURATION -> number UNIT
+// UNIT -> min | h | d | w | m | y
+//
+// An expression without a base is relative to now e.g 3d is now+3d
+package date
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock returns the current time, dates are resolved relative to it
+type Clock func() time.Time
+
+var isoLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var isoPrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([tT]\d{2}:\d{2}(:\d{2})?)?`)
+var offsetPattern = regexp.MustCompile(`^([+-]?)(\d+)(min|h|d|w|m|y)`)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Parse resolves the date expression relative to the current time
+func Parse(expression string, now time.Time) (time.Time, error) {
+	var expr = strings.ToLower(strings.TrimSpace(expression))
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("Date expression cannot be empty")
+	}
+
+	var base, rest, err = parseBase(expr, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var hasBase = rest != expr
+	for rest != "" {
+		var match = offsetPattern.FindStringSubmatch(rest)
+		if match == nil {
+			return time.Time{}, fmt.Errorf("Unknown date expression: %s", expression)
+		}
+		if match[1] == "" && hasBase {
+			return time.Time{}, fmt.Errorf("Expected + or - before %s in date expression: %s", match[0], expression)
+		}
+		var amount, _ = strconv.Atoi(match[2])
+		if match[1] == "-" {
+			amount = -amount
+		}
+		base = addDuration(base, amount, match[3])
+		rest = rest[len(match[0]):]
+		hasBase = true
+	}
+	return base, nil
+}
+
+// parseBase returns the date at the start of the expression and the unparsed remainder,
+// expressions that start with an offset are relative to now
+func parseBase(expr string, now time.Time) (time.Time, string, error) {
+	if iso := isoPrefix.FindString(expr); iso != "" {
+		var layout = isoLayouts[len(isoLayouts)-1]
+		for _, l := range isoLayouts {
+			if len(l) == len(iso) {
+				layout = l
+			}
+		}
+		var t, err = time.ParseInLocation(layout, strings.ToUpper(iso), now.Location())
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("Invalid date: %s", iso)
+		}
+		return t, expr[len(iso):], nil
+	}
+
+	var end = strings.IndexAny(expr, "+-")
+	if end == -1 {
+		end = len(expr)
+	}
+	var name = expr[:end]
+	if name == "" || offsetPattern.MatchString(expr) {
+		return now, expr, nil
+	}
+
+	var named, ok = namedDate(name, now)
+	if !ok {
+		return time.Time{}, "", fmt.Errorf(
+			"Unknown date: %s, options are: an ISO date, now, today, tomorrow, yesterday, eod, eow, eom, eoy or a weekday",
+			name,
+		)
+	}
+	return named, expr[end:], nil
+}
+
+func namedDate(name string, now time.Time) (time.Time, bool) {
+	var today = startOfDay(now)
+	switch name {
+	case "now":
+		return now, true
+	case "today", "sod":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "eod":
+		return endOfDay(today), true
+	case "eow":
+		// weeks start on a monday, so the week ends on sunday
+		var daysUntilSunday = (7 - int(today.Weekday())) % 7
+		return endOfDay(today.AddDate(0, 0, daysUntilSunday)), true
+	case "eom":
+		var firstOfMonth = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		return endOfDay(firstOfMonth.AddDate(0, 1, -1)), true
+	case "eoy":
+		return endOfDay(time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location())), true
+	}
+	if weekday, ok := weekdays[name]; ok {
+		// always the next occurrence, monday on a monday is a week away
+		var days = (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true
+	}
+	return time.Time{}, false
+}
+
+func addDuration(t time.Time, amount int, unit string) time.Time {
+	switch unit {
+	case "min":
+		return t.Add(time.Duration(amount) * time.Minute)
+	case "h":
+		return t.Add(time.Duration(amount) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, amount)
+	case "w":
+		return t.AddDate(0, 0, amount*7)
+	case "m":
+		return t.AddDate(0, amount, 0)
+	case "y":
+		return t.AddDate(amount, 0, 0)
+	}
+	return t
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
@@ -0,0 +1,120 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/token"
+)
+
+const (
+	ExplainCommand = "explain"   // e.g explain add "milk" +home
+	DryRunFlag     = "--dry-run" // e.g add "milk" +home --dry-run
+)
+
+// Explanation describes what a command would do, nothing is written to the database
+type Explanation struct {
+	Input       string
+	Expanded    string // The input after aliases and reports were expanded, empty if there were none
+	Tokens      []token.Token
+	Ast         string                    // Indented dump of the AST, empty if parsing failed
+	Statements  []ast.TranspiledStatement // Every SQL statement built by the transpiler
+	Operations  []ast.Operation           // Store operations e.g link tag: home and the statements they ran
+	Diagnostics *manager.Diagnostics      // Errors and warnings, nil if there were none
+}
+
+// ParseExplain returns the command to explain if the input starts with explain
+// or ends with --dry-run, false is returned for every other input
+func ParseExplain(input string) (string, bool) {
+	var trimmed = strings.TrimSpace(input)
+	var fields = strings.Fields(trimmed)
+	if len(fields) == 0 {
+		return "", false
+	}
+	if strings.ToLower(fields[0]) == ExplainCommand {
+		return strings.TrimSpace(trimmed[len(fields[0]):]), true
+	}
+	if fields[len(fields)-1] == DryRunFlag {
+		return strings.TrimSpace(strings.TrimSuffix(trimmed, DryRunFlag)), true
+	}
+	return "", false
+}
+
+// Explain runs the input like Execute but always rolls the transaction back. The explanation
+// contains every stage that was reached, the error is the same one Execute would return.
+// The statements run by each store operation are explained when the transaction was
+// started with the recorder e.g db.RecordStatements, the recorder may be nil
+func (interpreter *Interpreter) Explain(input string, tx *sqlx.Tx, recorder *db.Recorder) (*Explanation, error) {
+	interpreter.transpiler.SetRecorder(recorder)
+	defer interpreter.transpiler.SetRecorder(nil)
+	var tokens, cmd, _, _, err = interpreter.run(input, tx)
+	if err == nil {
+		tx.Rollback()
+	}
+
+	var explanation = &Explanation{
+		Input:       input,
+		Tokens:      tokens,
+		Diagnostics: interpreter.diagnostics,
+	}
+	if interpreter.expansion != nil && interpreter.expansion.expanded() {
+		explanation.Expanded = interpreter.expansion.output
+	}
+	if cmd != nil {
+		explanation.Ast = ast.Dump(cmd)
+	}
+	if err == nil {
+		explanation.Statements = interpreter.transpiler.Statements()
+		explanation.Operations = interpreter.transpiler.Plan()
+	}
+	return explanation, err
+}
+
+func (explanation *Explanation) String() string {
+	var builder strings.Builder
+	if explanation.Expanded != "" {
+		fmt.Fprintf(&builder, "Expanded:\n  %s\n", explanation.Expanded)
+	}
+	builder.WriteString("Tokens:\n")
+	for i := range explanation.Tokens {
+		var tok = &explanation.Tokens[i]
+		fmt.Fprintf(&builder, "  %s [%d:%d]\n", tok.String(), tok.StartPosition, tok.EndPosition)
+	}
+	if explanation.Ast != "" {
+		builder.WriteString("AST:\n")
+		for _, line := range strings.Split(explanation.Ast, "\n") {
+			builder.WriteString("  " + line + "\n")
+		}
+	}
+	if len(explanation.Statements) > 0 {
+		builder.WriteString("SQL:\n")
+		writeStatements(&builder, explanation.Statements, "  ")
+	}
+	if len(explanation.Operations) > 0 {
+		builder.WriteString("Operations:\n")
+		for _, operation := range explanation.Operations {
+			builder.WriteString("  " + operation.Description + "\n")
+			writeStatements(&builder, operation.Statements, "    ")
+		}
+	}
+	if explanation.Diagnostics != nil {
+		builder.WriteString("Diagnostics:\n")
+		builder.WriteString(explanation.Diagnostics.Render() + "\n")
+	}
+	builder.WriteString("Rolled back, nothing was changed")
+	return builder.String()
+}
+
+// writeStatements writes every statement on a single line followed by its args
+func writeStatements(builder *strings.Builder, statements []ast.TranspiledStatement, indent string) {
+	for _, statement := range statements {
+		fmt.Fprintf(builder, "%s%s\n", indent, strings.Join(strings.Fields(string(statement.Sql)), " "))
+		if len(statement.Args) > 0 {
+			fmt.Fprintf(builder, "%s  args: %v\n", indent, []interface{}(statement.Args))
+		}
+	}
+}
@@ -3,10 +3,13 @@ package interpreter
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/config"
 	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/date"
 	"github.com/luke-goddard/taskninja/interpreter/lex"
 	"github.com/luke-goddard/taskninja/interpreter/manager"
 	"github.com/luke-goddard/taskninja/interpreter/parser"
@@ -15,6 +18,7 @@ import (
 )
 
 type Interpreter struct {
+	store      *db.Store
 	input      string
 	lexer      *lex.Lexer
 	parser     *parser.Parser
@@ -22,17 +26,80 @@ type Interpreter struct {
 	transpiler *ast.Transpiler
 	errs       *manager.ErrorManager
 	lastCmd    *ast.Command
+	aliases    Aliases
+	expansion  *expansion // The input after aliases were expanded, set by run
+	reports    map[string]config.Report
+	report     *ActiveReport // The report run by the last call to run, nil for other commands
+
+	diagnostics *manager.Diagnostics // Errors and warnings from the last call to Execute
 }
 
 func NewInterpreter(store *db.Store) *Interpreter {
 	var manager = manager.NewErrorManager()
 	return &Interpreter{
+		store:      store,
 		lexer:      lex.NewLexer(manager),
 		parser:     parser.NewParser(manager),
 		semantic:   semantic.NewAnalyzer(manager),
 		transpiler: ast.NewTranspiler(store),
 		errs:       manager,
+		reports:    config.DefaultReports(),
+	}
+}
+
+// ActiveReport is a report that was run by the report command e.g report next
+type ActiveReport struct {
+	Name string
+	config.Report
+}
+
+// SetClock replaces the clock used to resolve dates e.g due:tomorrow
+func (interpreter *Interpreter) SetClock(clock date.Clock) *Interpreter {
+	interpreter.transpiler.SetClock(clock)
+	interpreter.semantic.SetClock(clock)
+	return interpreter
+}
+
+// SetAliases replaces the aliases that are expanded before the input is lexed,
+// names are case insensitive
+func (interpreter *Interpreter) SetAliases(aliases Aliases) *Interpreter {
+	interpreter.aliases = make(Aliases, len(aliases))
+	for name, template := range aliases {
+		interpreter.aliases[strings.ToLower(name)] = template
+	}
+	return interpreter
+}
+
+// SetReports replaces the reports that can be run with the report command,
+// names are case insensitive
+func (interpreter *Interpreter) SetReports(reports map[string]config.Report) *Interpreter {
+	interpreter.reports = make(map[string]config.Report, len(reports))
+	for name, report := range reports {
+		interpreter.reports[strings.ToLower(name)] = report
+	}
+	return interpreter
+}
+
+// SetUDAs replaces the user defined attributes of the store, a name cannot be the same
+// as a key or an alias e.g recur or p
+func (interpreter *Interpreter) SetUDAs(udas map[string]config.UDA) error {
+	for name := range udas {
+		if semantic.IsReservedKey(name) {
+			return fmt.Errorf("The UDA %s has the same name as a built in key", strings.ToLower(name))
+		}
 	}
+	return interpreter.store.SetUDAs(udas)
+}
+
+// ReportNames returns the name of every report that can be run, see config.ReportNames
+func (interpreter *Interpreter) ReportNames() []string {
+	return config.ReportNames(interpreter.reports)
+}
+
+// LastReport returns the report run by the last call to Execute, nil when the
+// last program was not a report
+func (interpreter *Interpreter) LastReport() *ActiveReport {
+	return interpreter.report
 }
 
 func (interpreter *Interpreter) Reset() *Interpreter {
@@ -50,6 +117,7 @@ func (interpreter *Interpreter) Lex(input string) ([]token.Token, []manager.Erro
 	interpreter.input = input
 	return interpreter.lexer.
 		Reset().
+		SetRawValueKeys(interpreter.store.UDANames()).
 		SetInput(input).
 		Tokenize()
 }
@@ -74,9 +142,64 @@ func (interpreter *Interpreter) GetLastCmd() *ast.Command {
 	return interpreter.lastCmd
 }
 
+// CreatedTaskId returns the ID of the task added by the last call to Execute, 0 if the
+// command failed or did not add a task
+func (interpreter *Interpreter) CreatedTaskId() int64 {
+	if interpreter.lastCmd == nil {
+		return 0
+	}
+	return interpreter.transpiler.CreatedTaskId()
+}
+
+// Operations describes the store operations run by the last call to Execute
+// e.g start task 1, nil if the command failed
+func (interpreter *Interpreter) Operations() []string {
+	if interpreter.lastCmd == nil {
+		return nil
+	}
+	return interpreter.transpiler.Operations()
+}
+
+// Diagnostics returns the warnings reported by the last call to Execute
+func (interpreter *Interpreter) Diagnostics() *manager.Diagnostics {
+	return interpreter.diagnostics
+}
+
+// Execute runs the input inside the transaction. Every error and warning is returned as
+// *manager.Diagnostics, the transaction is rolled back when any of them are fatal
 func (interpreter *Interpreter) Execute(input string, tx *sqlx.Tx) (ast.SqlStatement, ast.SqlArgs, error) {
+	var sql, args, err = interpreter.ExecuteTx(input, tx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		interpreter.lastCmd = nil
+		return "", nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return sql, args, nil
+}
+
+// ExecuteTx runs the input like Execute but leaves the transaction open for the caller
+// to commit, e.g to record the changes in the undo journal first
+func (interpreter *Interpreter) ExecuteTx(input string, tx *sqlx.Tx) (ast.SqlStatement, ast.SqlArgs, error) {
+	var _, cmd, sql, args, err = interpreter.run(input, tx)
+	if err != nil {
+		return "", nil, err
+	}
+	interpreter.lastCmd = cmd
+	return sql, args, nil
+}
+
+// run lexes, parses, analyzes and transpiles the input, the transaction is rolled back
+// on error and left open otherwise
+func (interpreter *Interpreter) run(input string, tx *sqlx.Tx) ([]token.Token, *ast.Command, ast.SqlStatement, ast.SqlArgs, error) {
 	interpreter.input = input
 	interpreter.lastCmd = nil
+	interpreter.diagnostics = nil
+	interpreter.report = nil
+	interpreter.errs.Reset()
 
 	var tokens []token.Token
 	var cmd *ast.Command
@@ -84,42 +207,75 @@ func (interpreter *Interpreter) Execute(input string, tx *sqlx.Tx) (ast.SqlState
 	var args ast.SqlArgs
 	var errs []manager.ErrorTranspiler
 
-	tokens, errs = interpreter.lexer.
-		Reset().
-		SetInput(input).
-		Tokenize()
-
-	if len(errs) > 0 {
-		return "", nil, fmt.Errorf("failed to tokenize input")
+	var expansion, aliasErr = interpreter.aliases.expand(input)
+	interpreter.expansion = expansion
+	if aliasErr != nil {
+		interpreter.errs.Emit(aliasErr)
+		return nil, nil, "", nil, interpreter.fail(tx)
 	}
 
-	cmd, errs = interpreter.parser.
-		Reset().
-		Parse(tokens)
+	for {
+		tokens, errs = interpreter.lexer.
+			Reset().
+			SetRawValueKeys(interpreter.store.UDANames()).
+			SetInput(expansion.output).
+			Tokenize()
 
-	if len(errs) > 0 {
-		var err = errs[0]
-		return "", nil, &err
+		if len(errs) > 0 {
+			return tokens, nil, "", nil, interpreter.fail(tx)
+		}
+
+		cmd, errs = interpreter.parser.
+			Reset().
+			Parse(tokens)
+
+		if len(errs) > 0 {
+			return tokens, cmd, "", nil, interpreter.fail(tx)
+		}
+		if cmd.Kind != ast.CommandKindReport || interpreter.report != nil {
+			break
+		}
+
+		// The report is replaced with its list command, errors in the list command
+		// point at the report command the user typed
+		if interpreter.semantic.SetReports(interpreter.ReportNames()).Analyze(cmd) != nil {
+			return tokens, cmd, "", nil, interpreter.fail(tx)
+		}
+		var name = cmd.Param.Value.(string)
+		interpreter.report = &ActiveReport{Name: name, Report: interpreter.reports[name]}
+		expansion = expansion.replace(interpreter.report.Program())
+		interpreter.expansion = expansion
 	}
 
-	var semErr = interpreter.semantic.Analyze(cmd)
-	if semErr != nil {
-		return "", nil, semErr
+	var tags, err = interpreter.store.TagNamesTx(tx)
+	if err != nil {
+		tx.Rollback()
+		return tokens, cmd, "", nil, err
+	}
+	if interpreter.semantic.SetTags(tags).SetUDAs(interpreter.store.UDANames()).Analyze(cmd) != nil {
+		return tokens, cmd, "", nil, interpreter.fail(tx)
 	}
 
 	var tranErrors []ast.TranspileError
 	sql, args, tranErrors = interpreter.transpiler.Reset().Transpile(cmd, tx)
 	if len(tranErrors) > 0 {
-		tx.Rollback()
-		var err = fmt.Errorf("failed to transpile input: %v", tranErrors)
-		return "", nil, err
+		for _, tranErr := range tranErrors {
+			interpreter.errs.EmitTranspilation(tranErr.Message.Error(), tranErr.Node)
+		}
+		return tokens, cmd, "", nil, interpreter.fail(tx)
 	}
 
-	var err = tx.Commit()
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to commit transaction: %v", err)
+	if warnings := interpreter.errs.Warnings(); len(warnings) > 0 {
+		interpreter.diagnostics = manager.NewDiagnostics(input, expansion.remap(warnings))
 	}
+	return tokens, cmd, sql, args, nil
+}
 
-	interpreter.lastCmd = cmd
-	return sql, args, nil
+// fail rolls back the transaction and returns every diagnostic that was reported,
+// the spans point at the user's input rather than the expanded aliases
+func (interpreter *Interpreter) fail(tx *sqlx.Tx) error {
+	tx.Rollback()
+	var items = interpreter.expansion.remap(interpreter.errs.Errors())
+	interpreter.diagnostics = manager.NewDiagnostics(interpreter.input, items)
+	return interpreter.diagnostics
 }
@@ -3,11 +3,20 @@ package interpreter
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/luke-goddard/taskninja/config"
 	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/lex"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/semantic"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rs/zerolog"
@@ -20,6 +29,12 @@ func TestServices(t *testing.T) {
 	RunSpecs(t, "Interpreter Suite")
 }
 
+// explain explains the input in a transaction that records the statements of each operation
+func explain(interpreter *Interpreter, store *db.Store, input string) (*Explanation, error) {
+	var recorder = db.NewRecorder()
+	return interpreter.Explain(input, store.MustCreateRecordedTx(recorder), recorder)
+}
+
 var _ = Describe("Transpiler should transpile add commands", func() {
 	var interpreter *Interpreter
 	var store *db.Store
@@ -247,7 +262,32 @@ var _ = Describe("Transpiler should transpile add commands", func() {
 	Describe("When adding a task with a tag that does not exist", func() {
 		It("should add the tag", func() {
 			var _, _, err = interpreter.Execute(`add "test" +WORK`, tx)
-			Expect(err).NotTo(BeNil())
+			Expect(err).To(BeNil())
+			var tasks, _ = store.ListTasks(context.Background())
+			Expect(store.FilterByTaskId(1, tasks).TagNames.Value()).To(Equal("work"))
+		})
+	})
+
+	Describe("When adding tasks with a tag that already exists", func() {
+		It("should reuse the tag", func() {
+			var _, _, err = interpreter.Execute(`add "one" +work`, tx)
+			Expect(err).To(BeNil())
+			_, _, err = interpreter.Execute(`add "two" +work +home project:a project:b`, store.MustCreateTxTodo())
+			Expect(err).To(BeNil())
+			var tags, _ = store.TagList(context.Background())
+			Expect(tags).To(HaveLen(2))
+			var tasks, _ = store.ListTasks(context.Background())
+			Expect(store.FilterByTaskId(2, tasks).TagNames.Value()).To(Equal("home,work"))
+			Expect(store.FilterByTaskId(2, tasks).TagCount).To(Equal(2))
+		})
+	})
+
+	Describe("When adding a task and removing a tag", func() {
+		It("should not link the tag", func() {
+			var _, _, err = interpreter.Execute(`add "test" +work -work`, tx)
+			Expect(err).To(BeNil())
+			var tasks, _ = store.ListTasks(context.Background())
+			Expect(store.FilterByTaskId(1, tasks).TagNames.Valid).To(BeFalse())
 		})
 	})
 })
@@ -342,3 +382,1341 @@ var _ = Describe("When executing the next command", func() {
 		Expect(task.Next).To(Equal(true))
 	})
 })
+
+var _ = Describe("Transpiler should transpile list commands", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+	})
+
+	DescribeTable("good",
+		func(input string, expectedSql string, expectedArgs interface{}) {
+			sql, args, err := interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).To(BeNil())
+			Expect(string(sql)).To(Equal(expectedSql))
+			Expect(args).To(Equal(expectedArgs))
+		},
+		Entry(
+			"list",
+			`list`,
+			"SELECT tasks.id FROM tasks",
+			ast.SqlArgs(nil),
+		),
+		Entry(
+			"list priority:high",
+			`list priority:high`,
+			"SELECT tasks.id FROM tasks WHERE tasks.priority = ?",
+			ast.SqlArgs{db.TaskPriorityHigh},
+		),
+		Entry(
+			"list +work",
+			`list +work`,
+			"SELECT tasks.id FROM tasks WHERE tasks.id IN (SELECT taskTags.taskID FROM taskTags JOIN tags ON tags.id = taskTags.tagID WHERE tags.name = ?)",
+			ast.SqlArgs{"work"},
+		),
+		Entry(
+			"list -work",
+			`list -work`,
+			"SELECT tasks.id FROM tasks WHERE tasks.id NOT IN (SELECT taskTags.taskID FROM taskTags JOIN tags ON tags.id = taskTags.tagID WHERE tags.name = ?)",
+			ast.SqlArgs{"work"},
+		),
+		Entry(
+			"list priority:high and project:infra",
+			`list priority:high and project:infra`,
+			"SELECT tasks.id FROM tasks WHERE (tasks.priority = ? AND tasks.id IN (SELECT taskProjects.taskId FROM taskProjects JOIN projects ON projects.id = taskProjects.projectId WHERE projects.title = ?))",
+			ast.SqlArgs{db.TaskPriorityHigh, "infra"},
+		),
+		Entry(
+			"list +work priority:high or priority:medium",
+			`list +work priority:high or priority:medium`,
+			"SELECT tasks.id FROM tasks WHERE tasks.id IN (SELECT taskTags.taskID FROM taskTags JOIN tags ON tags.id = taskTags.tagID WHERE tags.name = ?) AND (tasks.priority = ? OR tasks.priority = ?)",
+			ast.SqlArgs{"work", db.TaskPriorityHigh, db.TaskPriorityMedium},
+		),
+		Entry(
+			"list (priority:high or +urgent) milk",
+			`list (priority:high or +urgent) milk`,
+			"SELECT tasks.id FROM tasks WHERE (tasks.priority = ? OR tasks.id IN (SELECT taskTags.taskID FROM taskTags JOIN tags ON tags.id = taskTags.tagID WHERE tags.name = ?)) AND tasks.title LIKE ?",
+			ast.SqlArgs{db.TaskPriorityHigh, "urgent", "%milk%"},
+		),
+	)
+
+	DescribeTable("bad",
+		func(input string) {
+			_, _, err := interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("unknown key", `list colour:red`),
+		Entry("unknown priority", `list priority:urgent`),
+		Entry("dangling and", `list priority:high and`),
+		Entry("unclosed paren", `list (priority:high`),
+	)
+})
+
+var _ = Describe("When executing the modify command", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "one" project:home`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "two" priority:low`, store.MustCreateTxTodo())
+	})
+
+	It("should produce an UPDATE statement", func() {
+		var sql, args, err = interpreter.Execute(`modify 1 priority:high`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(string(sql)).To(Equal("UPDATE tasks SET updatedAtUtc = current_timestamp, priority = ? WHERE id IN (?)"))
+		Expect(args).To(Equal(ast.SqlArgs{db.TaskPriorityHigh, int64(1)}))
+	})
+
+	It("should change the title", func() {
+		var _, _, err = interpreter.Execute(`modify 1 "buy oat milk"`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Title).To(Equal("buy oat milk"))
+	})
+
+	It("should replace the project", func() {
+		var _, _, err = interpreter.Execute(`modify 1 project:work`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(1, tasks).ProjectNames.Value()).To(Equal("work"))
+	})
+
+	It("should add and remove tags", func() {
+		var _, _, err = interpreter.Execute(`modify 1 +home +work`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(1, tasks).TagNames.Value()).To(Equal("home,work"))
+
+		_, _, err = interpreter.Execute(`modify 1 -home`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(1, tasks).TagNames.Value()).To(Equal("work"))
+	})
+
+	It("should add a dependency", func() {
+		var _, _, err = interpreter.Execute(`modify 2 deps:1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(2, tasks).Dependencies.Value()).To(Equal("1"))
+	})
+
+	It("should modify every task matching a filter", func() {
+		var _, _, err = interpreter.Execute(`modify (project:home or priority:low) priority:medium`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityMedium))
+		Expect(store.GetTaskByIdOrPanic(2).Priority).To(Equal(db.TaskPriorityMedium))
+	})
+
+	It("should not partially apply a failed modification", func() {
+		var _, _, err = interpreter.Execute(`modify 1 priority:high deps:1`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityNone))
+	})
+
+	DescribeTable("bad",
+		func(input string) {
+			_, _, err := interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("missing task", `modify 10 priority:high`),
+		Entry("no modifications", `modify 1`),
+		Entry("no target", `modify priority:high`),
+		Entry("empty title", `modify 1 ""`),
+		Entry("negative id", `modify -1 priority:high`),
+		Entry("unknown priority", `modify 1 priority:urgent`),
+		Entry("filter without matches", `modify (project:nothing) priority:high`),
+	)
+})
+
+var _ = Describe("When executing task id commands", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "one" +home`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "two" deps:1`, store.MustCreateTxTodo())
+	})
+
+	It("should start and stop a task", func() {
+		var _, _, err = interpreter.Execute(`start 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).State).To(Equal(db.TaskStateStarted))
+
+		_, _, err = interpreter.Execute(`stop 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).State).To(Equal(db.TaskStateIncomplete))
+		var times, _ = store.GetTaskTimes(context.Background(), 1)
+		Expect(times).To(HaveLen(1))
+		Expect(times[0].EndTimeUtc.Valid).To(BeTrue())
+	})
+
+	It("should complete a task and remove its dependencies", func() {
+		var _, _, err = interpreter.Execute(`done 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).State).To(Equal(db.TaskStateCompleted))
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(2, tasks).Dependencies.Valid).To(BeFalse())
+	})
+
+	It("should delete a task", func() {
+		var _, _, err = interpreter.Execute(`delete 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var _, getErr = store.GetTaskById(context.Background(), 1)
+		Expect(getErr).NotTo(BeNil())
+	})
+
+	It("should list the tags with their task count", func() {
+		var sql, args, err = interpreter.Execute(`tags`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(string(sql)).To(Equal("SELECT tags.id, tags.name, COUNT(taskTags.taskID) AS count FROM tags LEFT JOIN taskTags ON taskTags.tagID = tags.id GROUP BY tags.id ORDER BY tags.name"))
+		Expect(args).To(BeEmpty())
+	})
+
+	DescribeTable("bad",
+		func(input string) {
+			_, _, err := interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("done missing task", `done 10`),
+		Entry("delete missing task", `delete 10`),
+		Entry("start missing task", `start 10`),
+		Entry("stop missing task", `stop 10`),
+		Entry("done without id", `done`),
+		Entry("done with zero id", `done 0`),
+		Entry("start with trailing tokens", `start 1 priority:high`),
+		Entry("tags with arguments", `tags +home`),
+	)
+})
+
+var _ = Describe("When adding a task with a due date", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	// Wednesday
+	var now = time.Date(2024, time.January, 10, 15, 30, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.SetClock(func() time.Time { return now })
+	})
+
+	DescribeTable("good",
+		func(input string, expected string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).To(BeNil())
+			Expect(store.GetTaskByIdOrPanic(1).Due.Value()).To(Equal(expected))
+		},
+		Entry("iso date", `add "x" due:2024-02-01`, "2024-02-01 00:00:00"),
+		Entry("iso date time", `add "x" due:2024-02-01T09:15`, "2024-02-01 09:15:00"),
+		Entry("iso date arithmetic", `add "x" due:2024-02-01-1d`, "2024-01-31 00:00:00"),
+		Entry("now", `add "x" due:now`, "2024-01-10 15:30:00"),
+		Entry("today", `add "x" due:today`, "2024-01-10 00:00:00"),
+		Entry("tomorrow", `add "x" due:tomorrow`, "2024-01-11 00:00:00"),
+		Entry("end of week", `add "x" due:eow`, "2024-01-14 23:59:59"),
+		Entry("end of month", `add "x" due:eom`, "2024-01-31 23:59:59"),
+		Entry("weekday", `add "x" due:monday`, "2024-01-15 00:00:00"),
+		Entry("same weekday is next week", `add "x" due:wednesday`, "2024-01-17 00:00:00"),
+		Entry("now plus days", `add "x" due:now+3d`, "2024-01-13 15:30:00"),
+		Entry("multiple offsets", `add "x" due:tomorrow+1w-2h priority:high`, "2024-01-17 22:00:00"),
+		Entry("bare duration", `add "x" due:2w`, "2024-01-24 15:30:00"),
+		Entry("months", `add "x" due:today+1m`, "2024-02-10 00:00:00"),
+		Entry("uppercase", `add "x" DUE:Tomorrow`, "2024-01-11 00:00:00"),
+	)
+
+	DescribeTable("bad",
+		func(input string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("unknown name", `add "x" due:someday`),
+		Entry("unknown unit", `add "x" due:now+3q`),
+		Entry("missing sign", `add "x" due:now3d`),
+		Entry("invalid date", `add "x" due:2024-13-01`),
+		Entry("missing value", `add "x" due:`),
+	)
+
+	It("should change and remove the due date", func() {
+		var _, _, err = interpreter.Execute(`add "x" due:tomorrow`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`modify 1 due:eom`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Due.Value()).To(Equal("2024-01-31 23:59:59"))
+		_, _, err = interpreter.Execute(`modify 1 due:none`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Due.Valid).To(BeFalse())
+	})
+
+	It("should make overdue tasks more urgent", func() {
+		interpreter.SetClock(time.Now)
+		var _, _, err = interpreter.Execute(`add "overdue" due:now-1w`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`add "later" due:now+1y`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		var overdue = store.FilterByTaskId(1, tasks)
+		var later = store.FilterByTaskId(2, tasks)
+		Expect(overdue.Urgency()).To(BeNumerically(">", later.Urgency()))
+	})
+})
+
+var _ = Describe("When executing commands with a list of task ids", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		for _, title := range []string{"one", "two", "three", "four", "five"} {
+			var _, _, err = interpreter.Execute(`add "`+title+`"`, store.MustCreateTxTodo())
+			Expect(err).To(BeNil())
+		}
+	})
+
+	It("should complete every task in the list", func() {
+		var _, _, err = interpreter.Execute(`done 1-3,5`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].ID).To(Equal(int64(4)))
+	})
+
+	It("should modify every task in the list", func() {
+		var _, _, err = interpreter.Execute(`modify 3,4 +urgent priority:high`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(3, tasks).TagNames.Value()).To(Equal("urgent"))
+		Expect(store.FilterByTaskId(4, tasks).Priority).To(Equal(db.TaskPriorityHigh))
+		Expect(store.FilterByTaskId(5, tasks).TagNames.Valid).To(BeFalse())
+	})
+
+	It("should add a dependency to every task in the list", func() {
+		var _, _, err = interpreter.Execute(`depends 1,2 on 3`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, _ = store.ListTasks(context.Background())
+		Expect(store.FilterByTaskId(1, tasks).Dependencies.Value()).To(Equal("3"))
+		Expect(store.FilterByTaskId(2, tasks).Dependencies.Value()).To(Equal("3"))
+	})
+
+	It("should report every missing task without a partial commit", func() {
+		var _, _, err = interpreter.Execute(`done 4-7`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("Task 6 does not exist"))
+		Expect(err.Error()).To(ContainSubstring("Task 7 does not exist"))
+		Expect(store.GetTaskByIdOrPanic(4).State).To(Equal(db.TaskStateIncomplete))
+
+		_, _, err = interpreter.Execute(`modify 5,9 priority:high`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("Task 9 does not exist"))
+		Expect(store.GetTaskByIdOrPanic(5).Priority).To(Equal(db.TaskPriorityNone))
+	})
+
+	DescribeTable("bad",
+		func(input string) {
+			_, _, err := interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("reversed range", `done 5-1`),
+		Entry("zero id", `delete 0-2`),
+		Entry("empty item", `done 1,,2`),
+		Entry("too large", `done 1-100000`),
+		Entry("depends on itself", `depends 1-3 on 2`),
+	)
+})
+
+var _ = Describe("When reporting diagnostics", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+	})
+
+	var executeDiagnostics = func(input string) *manager.Diagnostics {
+		var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		var diagnostics *manager.Diagnostics
+		Expect(errors.As(err, &diagnostics)).To(BeTrue())
+		return diagnostics
+	}
+
+	DescribeTable("should underline the offending range",
+		func(input string, variant manager.ErrorTranspilerVariant, expected string) {
+			var diagnostics = executeDiagnostics(input)
+			Expect(diagnostics.HasFatal()).To(BeTrue())
+			Expect(diagnostics.Items[0].Variant).To(Equal(variant))
+			Expect(diagnostics.Render()).To(HavePrefix(input + "\n" + expected + "\n"))
+		},
+		Entry("unknown character", `add "milk" ;`, manager.TranspilerErrorLex, `           ^`),
+		Entry("empty description", `add "" +home`, manager.TranspilerErrorSemantic, `     ^`),
+		Entry("unknown key", `add "milk" colour:red`, manager.TranspilerErrorSemantic, `           ^^^^^^^^^^`),
+		Entry("missing value", `add "milk" due:`, manager.TranspilerErrorParse, `               ^`),
+		Entry("missing task", `done 4`, manager.TranspilerErrorTranspilation, `     ^`),
+		Entry("missing value after multibyte text", `add "café" priority:`, manager.TranspilerErrorParse, `                    ^`),
+		Entry("unknown key after wide text", `add "日本" colour:red`, manager.TranspilerErrorSemantic, `           ^^^^^^^^^^`),
+	)
+
+	It("should report every transpilation error", func() {
+		var diagnostics = executeDiagnostics(`done 4,5`)
+		Expect(diagnostics.Items).To(HaveLen(2))
+		Expect(diagnostics.Items[0].Message).To(ContainSubstring("Task 4 does not exist"))
+		Expect(diagnostics.Items[1].Message).To(ContainSubstring("Task 5 does not exist"))
+	})
+
+	It("should not abort on warnings", func() {
+		var _, _, err = interpreter.Execute(`add "milk" priority:high p:low +home +home`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityLow))
+
+		var diagnostics = interpreter.Diagnostics()
+		Expect(diagnostics).NotTo(BeNil())
+		Expect(diagnostics.HasFatal()).To(BeFalse())
+		Expect(diagnostics.Items).To(HaveLen(2))
+		Expect(diagnostics.Items[0].Error()).To(Equal("(Warning) Semantic: The priority key is repeated, the last value is used"))
+		Expect(diagnostics.Items[1].Error()).To(Equal("(Warning) Semantic: The tag home is repeated"))
+		Expect(diagnostics.Render()).To(Equal(
+			"add \"milk\" priority:high p:low +home +home\n" +
+				"                         ^^^^^\n" +
+				"(Warning) Semantic: The priority key is repeated, the last value is used\n" +
+				"                                     ^^^^^\n" +
+				"(Warning) Semantic: The tag home is repeated",
+		))
+	})
+
+	It("should clear the warnings on the next execution", func() {
+		interpreter.Execute(`add "milk" p:high p:low`, store.MustCreateTxTodo())
+		Expect(interpreter.Diagnostics()).NotTo(BeNil())
+		interpreter.Execute(`add "bread"`, store.MustCreateTxTodo())
+		Expect(interpreter.Diagnostics()).To(BeNil())
+	})
+})
+
+var _ = Describe("When suggesting a fix for a typo", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		var _, _, err = interpreter.Execute(`add "milk" +home +work`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+	})
+
+	DescribeTable("should reject",
+		func(input string, expectedErr string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(expectedErr))
+		},
+		Entry("misspelt key", `add "bread" prority:high`, `(Fatal) Semantic: Unknown key: prority, did you mean priority?`),
+		Entry("misspelt alias", `add "bread" prj:home`, `(Fatal) Semantic: Unknown key: prj, did you mean proj?`),
+		Entry("misspelt filter key", `list projcet:home`, `(Fatal) Semantic: Unknown key: projcet, did you mean project?`),
+		Entry("misspelt key in a filter", `modify (prority:high) due:tomorrow`, `(Fatal) Semantic: Unknown key: prority, did you mean priority?`),
+		Entry("unknown key", `add "bread" colour:red`, `(Fatal) Semantic: Unknown key: colour`),
+		Entry("filter only key", `add "bread" id:1`, `(Fatal) Semantic: The id key can only be used to filter tasks`),
+		Entry("insert only key", `list due:tomorrow`, `(Fatal) Semantic: The due key cannot be used to filter tasks`),
+		Entry("misspelt command", `ad "bread"`, `(Fatal) Syntax: Unknown command: String(ad), did you mean add?`),
+		Entry("unknown command", `shopping "bread"`, `(Fatal) Syntax: Unknown command: String(shopping)`),
+	)
+
+	It("should not insert a task with an unknown key", func() {
+		interpreter.Execute(`add "bread" prority:high`, store.MustCreateTxTodo())
+		var _, err = store.GetTaskById(context.Background(), 2)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should warn about a misspelt tag but still create it", func() {
+		var _, _, err = interpreter.Execute(`add "bread" +hoem`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var diagnostics = interpreter.Diagnostics()
+		Expect(diagnostics).NotTo(BeNil())
+		Expect(diagnostics.Items).To(HaveLen(1))
+		Expect(diagnostics.Items[0].Error()).To(Equal("(Warning) Semantic: The tag hoem does not exist, did you mean +home?"))
+		var _, tagErr = store.TagGetByName("hoem")
+		Expect(tagErr).To(BeNil())
+	})
+
+	It("should suggest an existing tag when filtering", func() {
+		var _, _, err = interpreter.Execute(`list -wrok`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.Diagnostics().Items[0].Suggestion).To(Equal("-work"))
+	})
+
+	It("should not warn about new tags that are not close to an existing tag", func() {
+		var _, _, err = interpreter.Execute(`add "bread" +shopping`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.Diagnostics()).To(BeNil())
+	})
+
+	It("should not warn about short tags that are one edit from an existing tag", func() {
+		var _, _, err = interpreter.Execute(`add "deploy" +ci +wen`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`add "bread" +cd +web`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.Diagnostics()).To(BeNil())
+	})
+
+	It("should only warn about misspelt tags of four or more letters", func() {
+		var _, _, err = interpreter.Execute(`add "bread" +hme`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.Diagnostics()).To(BeNil())
+		_, _, err = interpreter.Execute(`add "bread" +wokr`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.Diagnostics().Items[0].Suggestion).To(Equal("+work"))
+	})
+})
+
+var _ = Describe("When explaining a command", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+	})
+
+	DescribeTable("should find the command to explain",
+		func(input string, expected string, ok bool) {
+			var program, found = ParseExplain(input)
+			Expect(found).To(Equal(ok))
+			Expect(program).To(Equal(expected))
+		},
+		Entry("explain prefix", `explain add "milk"`, `add "milk"`, true),
+		Entry("upper case prefix", `EXPLAIN list +home`, `list +home`, true),
+		Entry("dry run flag", `add "milk" +home --dry-run`, `add "milk" +home`, true),
+		Entry("no prefix", `add "explain"`, ``, false),
+		Entry("empty", ``, ``, false),
+	)
+
+	It("should not change the database", func() {
+		var explanation, err = explain(interpreter, store, `add "milk" priority:high +home`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Statements).To(HaveLen(1))
+		Expect(string(explanation.Statements[0].Sql)).To(Equal("INSERT INTO tasks (title, priority) VALUES (?, ?)"))
+		Expect(explanation.Statements[0].Args).To(Equal(ast.SqlArgs{"milk", db.TaskPriorityHigh}))
+		Expect(explanation.Operations).To(HaveLen(1))
+		Expect(explanation.Operations[0].Description).To(Equal("link tag: home"))
+
+		var _, getErr = store.GetTaskById(context.Background(), 1)
+		Expect(getErr).NotTo(BeNil())
+		var _, tagErr = store.TagGetByName("home")
+		Expect(tagErr).NotTo(BeNil())
+	})
+
+	It("should dump the tokens and the AST", func() {
+		var explanation, err = explain(interpreter, store, `add "milk" p:high +home`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Tokens).To(HaveLen(6))
+		Expect(explanation.Ast).To(Equal(
+			"Command(add) [0:23]\n" +
+				"  Param(Description) \"milk\" [5:9]\n" +
+				"  ExpressionStatement [11:17]\n" +
+				"    Key(p) [11:17]\n" +
+				"      Literal(String) \"high\" [13:17]\n" +
+				"  Tag(+home) [18:23]",
+		))
+		Expect(explanation.String()).To(ContainSubstring("SQL:\n  INSERT INTO tasks (title, priority) VALUES (?, ?)\n    args: [milk 3]\n"))
+		Expect(explanation.String()).To(HaveSuffix("Rolled back, nothing was changed"))
+	})
+
+	It("should record the statement used to find the tasks to modify", func() {
+		interpreter.Execute(`add "milk" +home`, store.MustCreateTxTodo())
+		var explanation, err = explain(interpreter, store, `modify (+home) priority:low`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Statements).To(HaveLen(2))
+		Expect(string(explanation.Statements[1].Sql)).To(HavePrefix("UPDATE tasks SET"))
+		Expect(store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityNone))
+	})
+
+	It("should record the statements of every store operation", func() {
+		var explanation, err = explain(interpreter, store, `add "milk" +home`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Operations).To(HaveLen(1))
+		var statements = explanation.Operations[0].Statements
+		Expect(statements).NotTo(BeEmpty())
+		Expect(statements).To(ContainElement(HaveField("Args", ContainElement("home"))))
+		for _, statement := range statements {
+			Expect(string(statement.Sql)).NotTo(HavePrefix("INSERT INTO tasks (title)"))
+		}
+		Expect(explanation.String()).To(MatchRegexp(`Operations:\n  link tag: home\n    .*tags.*\n      args: \[.*home.*\]\n`))
+	})
+
+	It("should record the statements of a store operation run for every task", func() {
+		interpreter.Execute(`add "milk"`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 1 "semi skimmed"`, store.MustCreateTxTodo())
+		var explanation, err = explain(interpreter, store, `denotate 1 "semi skimmed"`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Operations).To(HaveLen(1))
+		Expect(explanation.Operations[0].Description).To(Equal("denotate task 1: semi skimmed"))
+		Expect(explanation.Operations[0].Statements).To(ContainElement(HaveField("Args", ContainElement("semi skimmed"))))
+		Expect(explanation.String()).To(ContainSubstring("DELETE FROM taskAnnotations WHERE id = ( SELECT id FROM taskAnnotations"))
+		Expect(store.ListTaskAnnotations(context.Background(), 1)).To(HaveLen(1))
+	})
+
+	It("should explain the stages reached before an error", func() {
+		var explanation, err = explain(interpreter, store, `add "milk" prority:high`)
+		Expect(err).NotTo(BeNil())
+		Expect(explanation.Tokens).NotTo(BeEmpty())
+		Expect(explanation.Ast).To(HavePrefix("Command(add)"))
+		Expect(explanation.Statements).To(BeEmpty())
+		Expect(explanation.String()).To(ContainSubstring("did you mean priority?"))
+	})
+})
+
+var _ = Describe("When completing partial input", func() {
+	var completer *Completer
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		var interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "buy milk" +home +health project:house`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "walk dog" +work`, store.MustCreateTxTodo())
+		completer = NewCompleter(store)
+	})
+
+	var texts = func(completions *Completions) []string {
+		var texts = make([]string, 0, len(completions.Items))
+		for _, item := range completions.Items {
+			texts = append(texts, item.Text)
+		}
+		return texts
+	}
+
+	DescribeTable("should propose",
+		func(input string, expected []string) {
+			var completions, err = completer.Complete(context.Background(), input, len(input))
+			Expect(err).To(BeNil())
+			Expect(texts(completions)).To(Equal(expected))
+		},
+		Entry("every command", ``, []string{"add", "depends", "undepends", "next", "list", "modify", "delete", "done", "start", "stop", "tags", "report", "undo", "annotate", "denotate", "search", "info"}),
+		Entry("a partial command", `d`, []string{"depends", "delete", "done", "denotate"}),
+		Entry("a complete command", `list`, []string{"list"}),
+		Entry("keys for add", `add "milk" p`, []string{"priority:", "project:"}),
+		Entry("keys for list", `list `, []string{"priority:", "project:", "id:", "sort:", "status:", "limit:", "format:", "title~", "text~"}),
+		Entry("keys for search", `search "milk" t`, []string{"title~", "text~"}),
+		Entry("tags", `add "milk" +h`, []string{"+health", "+home"}),
+		Entry("tags to remove", `modify 1 -w`, []string{"-work"}),
+		Entry("every tag", `list +`, []string{
+			"+health", "+home", "+work", "+BLOCKED", "+BLOCKING", "+ACTIVE", "+OVERDUE", "+NEXT", "+TODAY",
+		}),
+		Entry("virtual tags", `list -bl`, []string{"-BLOCKED", "-BLOCKING"}),
+		Entry("projects", `list project:h`, []string{"house"}),
+		Entry("sort fields", `list sort:pr`, []string{"priority+", "priority-", "project+", "project-"}),
+		Entry("priorities", `add "milk" priority:`, []string{"high", "medium", "low", "none"}),
+		Entry("dates", `add "milk" due:to`, []string{"today", "tomorrow"}),
+		Entry("task ids", `done `, []string{"1", "2"}),
+		Entry("task ids for modify", `modify 2`, []string{"2"}),
+		Entry("dependencies", `add "milk" deps:`, []string{"1", "2"}),
+		Entry("nothing inside a description", `add "bu`, []string{}),
+		Entry("nothing before the description", `add `, []string{}),
+		Entry("nothing for a tolerated lex error", `add "milk" ;`, []string{}),
+	)
+
+	It("should describe a task with its title", func() {
+		var completions, err = completer.Complete(context.Background(), `start 1`, 7)
+		Expect(err).To(BeNil())
+		Expect(completions.Items).To(Equal([]Completion{{Text: "1", Description: "buy milk", Kind: CompletionKindTaskId}}))
+	})
+
+	It("should complete the word at the cursor", func() {
+		var input = `add "milk" +wo priority:high`
+		var completions, err = completer.Complete(context.Background(), input, 14)
+		Expect(err).To(BeNil())
+		Expect(completions.Start).To(Equal(11))
+		Expect(completions.End).To(Equal(14))
+		var output, cursor = completions.Apply(input, 0)
+		Expect(output).To(Equal(`add "milk" +work priority:high`))
+		Expect(cursor).To(Equal(16))
+	})
+})
+
+var _ = Describe("When expanding aliases", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store).SetAliases(Aliases{
+			"buy":    "add $1 +shopping",
+			"urgent": "list priority:high $*",
+			"Later":  "add $1 due:tomorrow",
+			"shop":   "buy",
+			"ping":   "pong $*",
+			"pong":   "ping $*",
+			"add":    "list",
+		})
+	})
+
+	DescribeTable("should expand",
+		func(input string, expected string) {
+			var explanation, err = explain(interpreter, store, input)
+			Expect(err).To(BeNil())
+			Expect(explanation.Input).To(Equal(input))
+			Expect(explanation.Expanded).To(Equal(expected))
+		},
+		Entry("positional argument", `buy "milk"`, `add "milk" +shopping`),
+		Entry("unused arguments are appended", `buy "milk" p:high`, `add "milk" +shopping p:high`),
+		Entry("every argument", `urgent +home -work`, `list priority:high +home -work`),
+		Entry("no arguments", `urgent`, `list priority:high `),
+		Entry("case insensitive name", `LATER "walk"`, `add "walk" due:tomorrow`),
+		Entry("an alias of an alias", `shop "eggs"`, `add "eggs" +shopping`),
+		Entry("quoted argument with spaces", `buy "oat milk"`, `add "oat milk" +shopping`),
+		Entry("not a command", `add "milk"`, ``),
+	)
+
+	It("should create the task", func() {
+		var _, _, err = interpreter.Execute(`buy "milk" priority:high`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var task = store.GetTaskByIdOrPanic(1)
+		Expect(task.Title).To(Equal("milk"))
+		Expect(task.Priority).To(Equal(db.TaskPriorityHigh))
+		var tag, tagErr = store.TagGetByName("shopping")
+		Expect(tagErr).To(BeNil())
+		Expect(tag.Name).To(Equal("shopping"))
+	})
+
+	DescribeTable("should underline the user's input",
+		func(input string, message string, expected string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			var diagnostics *manager.Diagnostics
+			Expect(errors.As(err, &diagnostics)).To(BeTrue())
+			Expect(diagnostics.Items[0].Message).To(ContainSubstring(message))
+			Expect(diagnostics.Render()).To(HavePrefix(input + "\n" + expected + "\n"))
+		},
+		Entry("error in an argument", `buy "milk" colour:red`, "Unknown key", `           ^^^^^^^^^^`),
+		Entry("error in the template", `buy ""`, "Description", `     ^`),
+		Entry("missing argument", `buy`, "expects at least 1 arguments", `^^^`),
+		Entry("recursive alias", `ping 1`, "The alias ping expands to itself", `^^^^`),
+	)
+
+	It("should report the depth limit separately from a recursive alias", func() {
+		var aliases = Aliases{}
+		for i := 0; i <= MAX_ALIAS_DEPTH; i++ {
+			aliases[fmt.Sprintf("a%d", i)] = fmt.Sprintf("a%d", i+1)
+		}
+		interpreter.SetAliases(aliases)
+		var _, _, err = interpreter.Execute(`a0`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("expands into more than %d aliases", MAX_ALIAS_DEPTH)))
+		Expect(err.Error()).NotTo(ContainSubstring("expands to itself"))
+	})
+})
+
+var _ = Describe("When removing dependencies and detecting cycles", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		for _, title := range []string{"one", "two", "three", "four", "five"} {
+			interpreter.Execute(fmt.Sprintf(`add "%s"`, title), store.MustCreateTxTodo())
+		}
+		interpreter.Execute(`depends 2 on 5`, store.MustCreateTxTodo())
+		interpreter.Execute(`depends 5 on 1`, store.MustCreateTxTodo())
+	})
+
+	var dependencies = func(taskId int64) []int64 {
+		var deps, err = store.GetDependenciesForTask(taskId)
+		Expect(err).To(BeNil())
+		var ids = make([]int64, 0, len(deps))
+		for _, dep := range deps {
+			ids = append(ids, dep.DependsOnID)
+		}
+		return ids
+	}
+
+	It("should remove a dependency with the undepends command", func() {
+		var _, _, err = interpreter.Execute(`undepends 2 on 5`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(dependencies(2)).To(BeEmpty())
+		Expect(dependencies(5)).To(Equal([]int64{1}))
+	})
+
+	It("should remove a dependency with a negative deps key", func() {
+		var _, _, err = interpreter.Execute(`modify 2 deps:-5`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(dependencies(2)).To(BeEmpty())
+	})
+
+	It("should report a dependency that does not exist", func() {
+		var _, _, err = interpreter.Execute(`undepends 2,3 on 5`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("Task 3 does not depend on task 5"))
+		Expect(dependencies(2)).To(Equal([]int64{5}))
+	})
+
+	DescribeTable("should reject a cycle and report its path",
+		func(input string, expected string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			var diagnostics *manager.Diagnostics
+			Expect(errors.As(err, &diagnostics)).To(BeTrue())
+			Expect(diagnostics.Items).To(HaveLen(1))
+			Expect(diagnostics.Items[0].Message).To(Equal(expected))
+			Expect(dependencies(1)).To(BeEmpty())
+		},
+		Entry("depends command", `depends 1 on 2`,
+			"Task 1 cannot depend on task 2, it would create a cycle: 1 → 2 → 5 → 1"),
+		Entry("deps key", `modify 1 deps:5`,
+			"Task 1 cannot depend on task 5, it would create a cycle: 1 → 5 → 1"),
+	)
+
+	It("should allow a dependency that does not close a cycle", func() {
+		var _, _, err = interpreter.Execute(`depends 1 on 3`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(dependencies(1)).To(Equal([]int64{3}))
+	})
+
+	It("should find the shortest dependency path", func() {
+		interpreter.Execute(`depends 2 on 1`, store.MustCreateTxTodo())
+		var tx = store.MustCreateTxTodo()
+		defer tx.Rollback()
+		var path, err = store.DependencyPathTx(tx, 2, 1)
+		Expect(err).To(BeNil())
+		Expect(path).To(Equal([]int64{2, 1}))
+		path, err = store.DependencyPathTx(tx, 1, 2)
+		Expect(err).To(BeNil())
+		Expect(path).To(BeNil())
+	})
+})
+
+var _ = Describe("When filtering with virtual tags", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "blocked" +home`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "blocking" due:yesterday`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "started" due:eod`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "next" due:tomorrow`, store.MustCreateTxTodo())
+		interpreter.Execute(`depends 1 on 2`, store.MustCreateTxTodo())
+		interpreter.Execute(`start 3`, store.MustCreateTxTodo())
+		interpreter.Execute(`next 4`, store.MustCreateTxTodo())
+	})
+
+	var list = func(input string) []int64 {
+		var sql, args, err = interpreter.Execute(input, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var tasks, listErr = store.ListTasksFiltered(context.Background(), string(sql), args...)
+		Expect(listErr).To(BeNil())
+		var ids = make([]int64, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	DescribeTable("should match the derived property",
+		func(input string, expected []int64) {
+			Expect(list(input)).To(ConsistOf(expected))
+		},
+		Entry("blocked", `list +BLOCKED`, []int64{1}),
+		Entry("not blocked", `list -BLOCKED`, []int64{2, 3, 4}),
+		Entry("blocking", `list +BLOCKING`, []int64{2}),
+		Entry("active", `list +ACTIVE`, []int64{3}),
+		Entry("not active", `list -ACTIVE`, []int64{1, 2, 4}),
+		Entry("overdue", `list +OVERDUE`, []int64{2}),
+		Entry("next", `list +NEXT`, []int64{4}),
+		Entry("today", `list +TODAY`, []int64{3}),
+		Entry("combined with a tag", `list -BLOCKED -ACTIVE -home`, []int64{2, 4}),
+		Entry("a lower case tag is not virtual", `list +next`, []int64{}),
+	)
+
+	It("should not link a virtual tag to a task", func() {
+		var _, _, err = interpreter.Execute(`add "milk" +BLOCKED`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("The virtual tag BLOCKED can only be used to filter tasks"))
+	})
+})
+
+var _ = Describe("When sorting the task list", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+	})
+
+	DescribeTable("should parse the sort",
+		func(input string, expected []db.TaskSortKey) {
+			var cmd, errs = interpreter.ParserString(input)
+			Expect(errs).To(BeEmpty())
+			var keys, err = cmd.SortKeys(nil)
+			Expect(err).To(BeNil())
+			Expect(keys).To(Equal(expected))
+		},
+		Entry("several keys", `list +home sort:due+,priority-,urgency-`, []db.TaskSortKey{
+			{Field: "due"}, {Field: "priority", Descending: true}, {Field: "urgency", Descending: true},
+		}),
+		Entry("no direction", `list sort:title`, []db.TaskSortKey{{Field: "title"}}),
+		Entry("the last sort wins", `list sort:id- sort:due`, []db.TaskSortKey{{Field: "due"}}),
+		Entry("no sort", `list +home`, nil),
+	)
+
+	It("should not filter on the sort key", func() {
+		var sql, _, err = interpreter.Execute(`list +home sort:due+`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(string(sql)).NotTo(ContainSubstring("sort"))
+		Expect(string(sql)).NotTo(ContainSubstring("due"))
+	})
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("an unknown field", `list sort:colour+`, "Cannot sort by colour"),
+		Entry("an empty field", `list sort:due,`, "Expected a field to sort by"),
+		Entry("a nested sort", `list (+home or sort:due)`, "The sort key must be an option of the list command"),
+		Entry("sort when adding", `add "milk" sort:due`, "The sort key can only be used to filter tasks"),
+		Entry("an unknown format", `list format:yaml`, "Unknown format yaml"),
+		Entry("a nested format", `list (+home or format:json)`, "The format key must be an option of the list command"),
+	)
+})
+
+var _ = Describe("When running reports", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+	})
+
+	It("should run a built in report as a list command", func() {
+		var _, _, err = interpreter.Execute(`report next`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var cmd = interpreter.GetLastCmd()
+		Expect(cmd.Kind).To(Equal(ast.CommandKindList))
+		Expect(cmd.Limit()).To(Equal(25))
+		Expect(interpreter.LastReport().Name).To(Equal("next"))
+	})
+
+	It("should include completed tasks in the completed report", func() {
+		var sql, args, err = interpreter.Execute(`report completed`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(string(sql)).To(Equal("SELECT tasks.id FROM tasks WHERE tasks.state = ?"))
+		Expect(args).To(Equal(ast.SqlArgs{db.TaskStateCompleted}))
+		Expect(interpreter.GetLastCmd().IncludesCompleted()).To(BeTrue())
+		var keys, _ = interpreter.GetLastCmd().SortKeys(nil)
+		Expect(keys).To(Equal([]db.TaskSortKey{{Field: "completed", Descending: true}}))
+	})
+
+	It("should forget the report when the next program is not a report", func() {
+		var _, _, err = interpreter.Execute(`report next`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`list`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(interpreter.LastReport()).To(BeNil())
+	})
+
+	It("should run reports from the config", func() {
+		interpreter.SetReports(map[string]config.Report{
+			"Home": {Filter: "+home priority:high", Sort: "due+", Columns: []string{"id", "title"}},
+		})
+		var sql, _, err = interpreter.Execute(`report home`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(string(sql)).To(ContainSubstring("tasks.priority = ?"))
+		Expect(interpreter.LastReport().Columns).To(Equal([]string{"id", "title"}))
+		Expect(interpreter.ReportNames()).To(Equal([]string{"home"}))
+	})
+
+	It("should suggest a report for a typo", func() {
+		var _, _, err = interpreter.Execute(`report nxt`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		var diagnostics = err.(*manager.Diagnostics)
+		Expect(diagnostics.Items[0].Message).To(Equal("Unknown report: nxt"))
+		Expect(diagnostics.Items[0].Suggestion).To(Equal("next"))
+	})
+
+	It("should point errors in the report at the report command", func() {
+		interpreter.SetReports(map[string]config.Report{"broken": {Filter: "priority:urgent"}})
+		var _, _, err = interpreter.Execute(`report broken`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		var start, end, ok = err.(*manager.Diagnostics).Items[0].Span()
+		Expect(ok).To(BeTrue())
+		Expect([]int{start, end}).To(Equal([]int{0, len(`report broken`)}))
+	})
+
+	It("should explain the list command of the report", func() {
+		var explanation, err = explain(interpreter, store, `report waiting`)
+		Expect(err).To(BeNil())
+		Expect(explanation.Expanded).To(Equal("list +BLOCKED sort:due+,urgency-"))
+	})
+
+	DescribeTable("should filter on the status",
+		func(input string, expected string, includesCompleted bool) {
+			var sql, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).To(BeNil())
+			Expect(string(sql)).To(Equal(expected))
+			Expect(interpreter.GetLastCmd().IncludesCompleted()).To(Equal(includesCompleted))
+		},
+		Entry("pending", `list status:pending`, "SELECT tasks.id FROM tasks WHERE tasks.state <> ?", false),
+		Entry("completed", `list status:completed`, "SELECT tasks.id FROM tasks WHERE tasks.state = ?", true),
+		Entry("all", `list status:all`, "SELECT tasks.id FROM tasks", true),
+	)
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("a report without a name", `report`, "Expected the name of a report"),
+		Entry("options after the report", `report next +home`, "The report command only accepts the name of a report"),
+		Entry("an unknown status", `list status:done`, "Expected pending, completed or all"),
+		Entry("a limit that is not a number", `list limit:ten`, "Expected a number that is not negative"),
+		Entry("a nested limit", `list (+home or limit:3)`, "The limit key must be an option of the list command"),
+	)
+})
+
+var _ = Describe("When using user defined attributes", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+	var now = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	var attribute = func(taskId int64, name string) string {
+		var tasks, err = store.ListTasksFiltered(context.Background(), "SELECT ?", taskId)
+		Expect(err).To(BeNil())
+		Expect(tasks).To(HaveLen(1))
+		var value, _ = tasks[0].Attribute(name)
+		return value
+	}
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		Expect(store.SetUDAs(map[string]config.UDA{
+			"estimate": {Type: config.UDATypeNumber, Urgency: 2},
+			"Customer": {Values: []string{"acme", "globex"}},
+			"ticket":   {},
+			"review":   {Type: config.UDATypeDate},
+			"effort":   {Type: config.UDATypeDuration},
+		})).To(Succeed())
+		interpreter = NewInterpreter(store).SetClock(func() time.Time { return now })
+	})
+
+	// reservedKeys has an entry for every key, alias and raw value key e.g recur or p
+	var reservedKeys = func() []TableEntry {
+		var names = slices.Clone(lex.RawValueKeys)
+		for _, key := range semantic.Keys {
+			names = append(names, key.Name)
+			names = append(names, key.Aliases...)
+		}
+		var entries = make([]TableEntry, 0, len(names))
+		for _, name := range names {
+			entries = append(entries, Entry(name, name))
+		}
+		return entries
+	}
+
+	DescribeTable("should reject an attribute with the name of a key",
+		func(name string) {
+			var err = interpreter.SetUDAs(map[string]config.UDA{strings.ToUpper(name): {}})
+			Expect(err).To(MatchError(ContainSubstring("same name as a built in key")))
+			var _, ok = store.UDA("estimate")
+			Expect(ok).To(BeTrue(), "the attributes are not replaced")
+		},
+		reservedKeys(),
+	)
+
+	It("should store the attributes when adding a task", func() {
+		var _, _, err = interpreter.Execute(
+			`add "migrate" estimate:3.50 ticket:OPS-12 customer:ACME effort:90m review:tomorrow`,
+			store.MustCreateTxTodo(),
+		)
+		Expect(err).To(BeNil())
+		Expect(attribute(1, "estimate")).To(Equal("3.5"))
+		Expect(attribute(1, "ticket")).To(Equal("OPS-12"))
+		Expect(attribute(1, "customer")).To(Equal("acme"))
+		Expect(attribute(1, "effort")).To(Equal("5400"))
+		Expect(attribute(1, "review")).To(Equal("2024-01-02 00:00:00"))
+	})
+
+	It("should replace and remove attributes when modifying a task", func() {
+		var _, _, err = interpreter.Execute(`add "migrate" estimate:3 ticket:OPS-12`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`modify 1 estimate:5 ticket:none`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(attribute(1, "estimate")).To(Equal("5"))
+		Expect(attribute(1, "ticket")).To(BeEmpty())
+	})
+
+	It("should filter on the attributes", func() {
+		var _, _, err = interpreter.Execute(`add "migrate" estimate:3`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`add "deploy" estimate:5`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`add "review"`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+
+		var sql, args, execErr = interpreter.Execute(`list estimate:5.0`, store.MustCreateTxTodo())
+		Expect(execErr).To(BeNil())
+		var tasks, _ = store.ListTasksFiltered(context.Background(), string(sql), args...)
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].Title).To(Equal("deploy"))
+
+		sql, args, execErr = interpreter.Execute(`list estimate:none`, store.MustCreateTxTodo())
+		Expect(execErr).To(BeNil())
+		tasks, _ = store.ListTasksFiltered(context.Background(), string(sql), args...)
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].Title).To(Equal("review"))
+	})
+
+	It("should sort by the attributes", func() {
+		var cmd, errs = interpreter.ParserString(`list sort:estimate-,ticket`)
+		Expect(errs).To(BeEmpty())
+		var keys, err = cmd.SortKeys(store.UDAs())
+		Expect(err).To(BeNil())
+		Expect(keys).To(Equal([]db.TaskSortKey{
+			{Field: "estimate", Descending: true, AttributeType: config.UDATypeNumber},
+			{Field: "ticket", AttributeType: config.UDATypeString},
+		}))
+	})
+
+	It("should suggest an attribute for a typo", func() {
+		var _, _, err = interpreter.Execute(`add "migrate" estimat:3`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(err.(*manager.Diagnostics).Items[0].Suggestion).To(Equal("estimate"))
+	})
+
+	It("should complete the attributes and their values", func() {
+		var completer = NewCompleter(store)
+		var completions, err = completer.Complete(context.Background(), `list cu`, 7)
+		Expect(err).To(BeNil())
+		Expect(completions.Items).To(HaveLen(1))
+		Expect(completions.Items[0].Text).To(Equal("customer:"))
+
+		completions, err = completer.Complete(context.Background(), `list customer:`, 14)
+		Expect(err).To(BeNil())
+		Expect(completions.Items).To(HaveLen(2))
+		Expect(completions.Items[0].Text).To(Equal("acme"))
+		Expect(completions.Items[1].Text).To(Equal("globex"))
+	})
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("a value that is not allowed", `add "x" customer:initech`, "The customer must be one of acme, globex"),
+		Entry("a number that is not a number", `add "x" estimate:lots`, "The estimate must be a number"),
+		Entry("an invalid duration", `add "x" effort:soon`, "Invalid duration"),
+		Entry("an invalid date", `list review:someday`, "Unknown date: someday"),
+	)
+})
+
+var _ = Describe("When annotating tasks", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	var notes = func(taskId int64) []string {
+		var annotations, err = store.ListTaskAnnotations(context.Background(), taskId)
+		Expect(err).To(BeNil())
+		var texts = make([]string, 0, len(annotations))
+		for _, annotation := range annotations {
+			texts = append(texts, annotation.Text)
+		}
+		return texts
+	}
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		for _, title := range []string{"one", "two", "three"} {
+			interpreter.Execute(fmt.Sprintf(`add "%s"`, title), store.MustCreateTxTodo())
+		}
+	})
+
+	It("should add notes to the tasks", func() {
+		var _, _, err = interpreter.Execute(`annotate 1 "pinged vendor"`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		_, _, err = interpreter.Execute(`annotate 1,2 "waiting on reply"`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(notes(1)).To(Equal([]string{"pinged vendor", "waiting on reply"}))
+		Expect(notes(2)).To(Equal([]string{"waiting on reply"}))
+		Expect(notes(3)).To(BeEmpty())
+	})
+
+	It("should count the notes when listing tasks", func() {
+		interpreter.Execute(`annotate 2 "a"`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 2 "b"`, store.MustCreateTxTodo())
+		var tasks, err = store.ListTasksFiltered(context.Background(), "SELECT ?", 2)
+		Expect(err).To(BeNil())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].AnnotationCount).To(Equal(2))
+	})
+
+	It("should remove a note matching the text", func() {
+		interpreter.Execute(`annotate 1 "pinged vendor"`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 1 "waiting on reply"`, store.MustCreateTxTodo())
+		var _, _, err = interpreter.Execute(`denotate 1 "PINGED vendor"`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(notes(1)).To(Equal([]string{"waiting on reply"}))
+	})
+
+	It("should remove the most recent note without a text", func() {
+		interpreter.Execute(`annotate 1 "pinged vendor"`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 1 "waiting on reply"`, store.MustCreateTxTodo())
+		var _, _, err = interpreter.Execute(`denotate 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		Expect(notes(1)).To(Equal([]string{"pinged vendor"}))
+	})
+
+	It("should keep the notes when the text is blank", func() {
+		interpreter.Execute(`annotate 1 "pinged vendor"`, store.MustCreateTxTodo())
+		var _, _, err = interpreter.Execute(`denotate 1 " "`, store.MustCreateTxTodo())
+		Expect(err).NotTo(BeNil())
+		Expect(notes(1)).To(Equal([]string{"pinged vendor"}))
+	})
+
+	It("should dump the annotation", func() {
+		var cmd, errs = interpreter.ParserString(`annotate 1,2 "pinged vendor"`)
+		Expect(errs).To(BeEmpty())
+		Expect(ast.Dump(cmd)).To(ContainSubstring(`Param(Annotation) [1 2] "pinged vendor"`))
+	})
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("a missing task", `annotate`, "Expected a taskId"),
+		Entry("a missing note", `annotate 1`, "Expected the text of the note"),
+		Entry("an empty note", `annotate 1 "  "`, "The note cannot be empty"),
+		Entry("an unquoted note", `annotate 1 "pinged" vendor`, "Unexpected token after the note"),
+		Entry("a task that does not exist", `annotate 9 "x"`, "Task 9 does not exist"),
+		Entry("a task without notes", `denotate 1`, "Task 1 does not have any notes"),
+		Entry("a note that does not exist", `denotate 1 "x"`, `Task 1 does not have the note "x"`),
+		Entry("a blank note to remove", `denotate 1 " "`, "The note cannot be empty"),
+	)
+})
+
+var _ = Describe("When searching tasks", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "pay the invoice" +work`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "call the bank"`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "walk the dog" +work`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 2 "ask about the old invoice"`, store.MustCreateTxTodo())
+		interpreter.Execute(`annotate 3 "the old ticket was closed"`, store.MustCreateTxTodo())
+		interpreter.Execute(`done 3`, store.MustCreateTxTodo())
+	})
+
+	var search = func(input string) []int64 {
+		var sql, args, err = interpreter.Execute(input, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var cmd = interpreter.GetLastCmd()
+		var text, _ = cmd.SearchText()
+		var tasks, listErr = store.ListTasksQuery(context.Background(), &db.TaskQuery{
+			Filter:           string(sql),
+			Args:             args,
+			Sort:             db.SearchTaskSort,
+			IncludeCompleted: cmd.IncludesCompleted(),
+			Search:           text,
+		})
+		Expect(listErr).To(BeNil())
+		var ids = make([]int64, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	DescribeTable("should find the tasks containing every word",
+		func(input string, expected []int64) {
+			Expect(search(input)).To(ConsistOf(expected))
+		},
+		Entry("in the title or notes", `search invoice`, []int64{1, 2}),
+		Entry("of a completed task", `search "old ticket"`, []int64{3}),
+		Entry("as a prefix", `search invo`, []int64{1, 2}),
+		Entry("with a filter", `search the +work`, []int64{1, 3}),
+		Entry("with a status", `search old status:pending`, []int64{2}),
+		Entry("in the title", `list title~invoice`, []int64{1}),
+		Entry("in the notes", `list text~invoice`, []int64{1, 2}),
+		Entry("in a group", `list (title~bank or text~"old ticket") status:all`, []int64{2, 3}),
+	)
+
+	It("should list a match in the title before a match in the notes", func() {
+		Expect(search(`search invoice`)).To(Equal([]int64{1, 2}))
+	})
+
+	It("should dump the search", func() {
+		var cmd, errs = interpreter.ParserString(`search "old ticket" title~ask`)
+		Expect(errs).To(BeEmpty())
+		Expect(ast.Dump(cmd)).To(ContainSubstring(`Command(search)`))
+		Expect(ast.Dump(cmd)).To(ContainSubstring(`Key(title~)`))
+	})
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("a missing text", `search`, "Expected the text to search for"),
+		Entry("a text without words", `search "--"`, "The search must contain at least one word"),
+		Entry("a search key without ~", `list title:invoice`, "The title key searches with ~"),
+		Entry("~ with another key", `list project~home`, "The ~ operator can only be used with the title and text keys"),
+		Entry("a search key without words", `list text~"!"`, "The text key must search for at least one word"),
+		Entry("a search key in an insert", `add "x" title~invoice`, "The title key can only be used to filter tasks"),
+	)
+})
+
+var _ = Describe("When showing a task", func() {
+	var interpreter *Interpreter
+	var store *db.Store
+
+	BeforeEach(func() {
+		store = db.NewInMemoryStore()
+		interpreter = NewInterpreter(store)
+		interpreter.Execute(`add "fix build" +ci`, store.MustCreateTxTodo())
+		interpreter.Execute(`add "write docs"`, store.MustCreateTxTodo())
+	})
+
+	It("should show the task without changing it", func() {
+		var _, _, err = interpreter.Execute(`info 1`, store.MustCreateTxTodo())
+		Expect(err).To(BeNil())
+		var id, ok = interpreter.GetLastCmd().InfoTaskId()
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(int64(1)))
+		Expect(interpreter.Operations()).To(BeEmpty())
+
+		var history []db.TaskChange
+		history, err = store.ListTaskHistory(context.Background(), 1)
+		Expect(err).To(BeNil())
+		Expect(history).To(HaveLen(2))
+	})
+
+	DescribeTable("should reject",
+		func(input string, message string) {
+			var _, _, err = interpreter.Execute(input, store.MustCreateTxTodo())
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("a missing task", `info`, "Expected a taskId to the info command"),
+		Entry("a task that does not exist", `info 9`, "Task 9 does not exist"),
+		Entry("several tasks", `info 1,2`, "The info command shows a single task"),
+		Entry("an option", `info 1 +ci`, "Unexpected token after the info command"),
+	)
+})
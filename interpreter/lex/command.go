@@ -10,20 +10,58 @@ import (
 type Command string
 
 const (
-	CommandAdd     Command = "add"     // Add a new task
-	CommandDepends Command = "depends" // Add a dependency
-	CommandNext    Command = "next"    // Mark this task as the next task to be executed
+	CommandAdd       Command = "add"       // Add a new task
+	CommandDepends   Command = "depends"   // Add a dependency
+	CommandUndepends Command = "undepends" // Remove a dependency
+	CommandNext      Command = "next"      // Mark this task as the next task to be executed
+	CommandList      Command = "list"      // List tasks
+	CommandModify    Command = "modify"    // Modify a task
+	CommandDelete    Command = "delete"    // Delete a task
+	CommandDone      Command = "done"      // Mark a task as done
+	CommandStart     Command = "start"     // Start a task
+	CommandStop      Command = "stop"      // Stop a task
+	CommandTags      Command = "tags"      // List all tags
+	CommandReport    Command = "report"    // Run a named report e.g report next
+	CommandUndo      Command = "undo"      // Revert the most recent change
+	CommandAnnotate  Command = "annotate"  // Add a note to a task
+	CommandDenotate  Command = "denotate"  // Remove a note from a task
+	CommandSearch    Command = "search"    // Search the text of every task
+	CommandInfo      Command = "info"      // Show everything about a task and its history
 	// CommandAll    Command = "all"    // List all tasks
-	// CommandDelete Command = "delete" // Delete a task
-	// CommandDone   Command = "done"   // Mark a task as done
-	// CommandList   Command = "list"   // List tasks
-	// CommandModify Command = "modify" // Modify a task
 	// CommandReady  Command = "ready"  // Mark a task as ready
-	// CommandStart  Command = "start"  // Start a task
-	// CommandStop   Command = "stop"   // Stop a task
-	// CommandTags   Command = "tags"   // List all tags
 )
 
+// Commands contains every keyword that will be emitted as a token.Command
+var Commands = []Command{
+	CommandAdd,
+	CommandDepends,
+	CommandUndepends,
+	CommandNext,
+	CommandList,
+	CommandModify,
+	CommandDelete,
+	CommandDone,
+	CommandStart,
+	CommandStop,
+	CommandTags,
+	CommandReport,
+	CommandUndo,
+	CommandAnnotate,
+	CommandDenotate,
+	CommandSearch,
+	CommandInfo,
+}
+
+// IsCommand returns true if the lexeme is a known command keyword
+func IsCommand(lexeme string) bool {
+	for _, command := range Commands {
+		if lexeme == string(command) {
+			return true
+		}
+	}
+	return false
+}
+
 func lexCommand(l *Lexer) StateFn {
 	var last = l.readUntil(func(r rune) bool {
 		return !IsAlphabet(r)
@@ -31,12 +69,13 @@ func lexCommand(l *Lexer) StateFn {
 	if last == ':' {
 		return lexPair
 	}
+	if last == '~' {
+		return lexMatch
+	}
 
 	var lexeme = l.current()
 
-	if lexeme == string(CommandAdd) ||
-		lexeme == string(CommandDepends) ||
-		lexeme == string(CommandNext) {
+	if IsCommand(lexeme) {
 		if !l.seenCommand {
 			l.seenCommand = true
 			l.emit(token.Command)
@@ -29,6 +29,8 @@ type Lexer struct {
 	depth       int           // current depth of the lexer
 	maxDepth    int           // maximum depth of the lexer
 	seenCommand bool          // whether a command has been seen
+	tolerant    bool          // errors become error tokens instead of being reported
+	rawKeys     []string      // extra keys whose value is lexed as a single string e.g estimate
 }
 
 // Create a new lexer that will tokenize the given input
@@ -51,6 +53,20 @@ func (l *Lexer) SetInitialState(state StateFn) {
 	l.initalState = state
 }
 
+// SetTolerant stops the lexer from reporting errors, instead the rest of the input is
+// emitted as a token.Error. Useful for partial input e.g when completing a command
+func (l *Lexer) SetTolerant(tolerant bool) *Lexer {
+	l.tolerant = tolerant
+	return l
+}
+
+// SetRawValueKeys lexes the value of the keys as a single string like RawValueKeys,
+// used for user defined attributes e.g ticket:OPS-12
+func (l *Lexer) SetRawValueKeys(keys []string) *Lexer {
+	l.rawKeys = keys
+	return l
+}
+
 func (l *Lexer) SetInput(input string) *Lexer {
 	l.input = input
 	return l
@@ -115,6 +131,11 @@ func (l *Lexer) toToken(tokenType token.TokenType) *token.Token {
 
 // Emit an error token with the given message
 func (l *Lexer) emitError(message string) StateFn {
+	if l.tolerant {
+		l.position = token.Pos(len(l.input))
+		l.emit(token.Error)
+		return nil
+	}
 	var token = token.NewToken(
 		token.Error,
 		l.start,
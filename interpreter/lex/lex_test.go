@@ -37,6 +37,15 @@ var _ = Describe("Lexer", func() {
 		Entry("double String", "hello hello", token.String, 2),
 		Entry("Command", "add hello", token.Command, 2),
 		Entry("Command", "depends 1 on 2", token.Command, 4),
+		Entry("Command", "list +work and priority:high", token.Command, 6),
+		Entry("Command", "done 3", token.Command, 2),
+		Entry("Command", "tags", token.Command, 1),
+		Entry("Command", "undo", token.Command, 1),
+		Entry("Command", "annotate", token.Command, 1),
+		Entry("Command", `search "old ticket" +work`, token.Command, 3),
+		Entry("Command", "done 1-5,8", token.Command, 2),
+		Entry("IdList", "1-5,8", token.IdList, 1),
+		Entry("IdList", "3,4", token.IdList, 1),
 		Entry("Number", "1", token.Number, 1),
 		Entry("Number", "1.1", token.Number, 1),
 		Entry("Number", "-1.1", token.Number, 1),
@@ -51,6 +60,13 @@ var _ = Describe("Lexer", func() {
 		Entry("Tag", `+Tag`, token.Tag, 1),
 		Entry("Tag", `-Tag`, token.Tag, 1),
 		Entry("Key", `project:home`, token.Key, 3),
+		Entry("Date key", `due:now+3d`, token.Key, 3),
+		Entry("Date key with iso date", `due:2024-01-02 +home`, token.Key, 4),
+		Entry("Date key with a less than", `due:<tomorrow`, token.Key, 3),
+		Entry("Recur key", `recur:2w`, token.Key, 3),
+		Entry("Search key", `title~invoice`, token.Key, 3),
+		Entry("Search key with quoted text", `text~"old ticket" +work`, token.Key, 4),
+		Entry("Search key in a group", `(title~milk)`, token.LeftParen, 5),
 		Entry("Colon", `:home`, token.Colon, 2),
 		Entry("LeftParen", `(`, token.LeftParen, 1),
 		Entry("RightParen", `)`, token.RightParen, 1),
@@ -58,3 +74,21 @@ var _ = Describe("Lexer", func() {
 	)
 
 })
+
+var _ = Describe("Tolerant lexer", func() {
+	var manager *man.ErrorManager
+	var lexer *Lexer
+
+	BeforeEach(func() {
+		manager = man.NewErrorManager()
+		lexer = NewLexer(manager).SetTolerant(true)
+	})
+
+	It("should emit the rest of the input as an error token", func() {
+		var tokens, errs = lexer.SetInput(`add "milk" ; +home`).Tokenize()
+		Expect(errs).To(BeEmpty())
+		Expect(tokens).To(HaveLen(3))
+		Expect(tokens[2].Type).To(Equal(token.Error))
+		Expect(tokens[2].Value).To(Equal("; +home"))
+	})
+})
@@ -7,7 +7,45 @@ func lexNumber(l *Lexer) StateFn {
 		return !IsNumber(r) && r != '.'
 	})
 
+	if isIdListSeparator(l) {
+		return lexIdList
+	}
+
 	l.emit(token.Number)
 
 	return lexStart
 }
+
+// isIdListSeparator returns true if the positive whole number is followed by
+// a comma or a range e.g 1,2 or 1-5
+func isIdListSeparator(l *Lexer) bool {
+	var number = l.current()
+	if len(number) == 0 || !IsNumber(rune(number[0])) {
+		return false
+	}
+	for _, r := range number {
+		if r == '.' {
+			return false
+		}
+	}
+	var peek = l.peek()
+	if peek == ',' {
+		return true
+	}
+	if peek != '-' {
+		return false
+	}
+	l.next()
+	var afterDash = l.peek()
+	l.backup()
+	return IsNumber(afterDash)
+}
+
+// lexIdList lexes a list of task ids and ranges e.g 1-5,8
+func lexIdList(l *Lexer) StateFn {
+	l.readUntil(func(r rune) bool {
+		return !IsNumber(r) && r != ',' && r != '-'
+	})
+	l.emit(token.IdList)
+	return lexStart
+}
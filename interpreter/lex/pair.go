@@ -1,11 +1,73 @@
 package lex
 
-import "github.com/luke-goddard/taskninja/interpreter/token"
+import (
+	"slices"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/interpreter/token"
+)
+
+// RawValueKeys contains the keys whose value is lexed as a single string
+// e.g due:now+3d, recur:2w or sort:due+,priority-
+var RawValueKeys = []string{"due", "until", "recur", "sort"}
+
+// IsRawValueKey returns true if the value of the key is lexed as a single string
+func IsRawValueKey(key string) bool {
+	for _, rawKey := range RawValueKeys {
+		if strings.ToLower(key) == rawKey {
+			return true
+		}
+	}
+	return false
+}
 
 func lexPair(l *Lexer) StateFn {
 	if l.peek() != ':' {
 		panic("lexPair called without a colon")
 	}
+	var key = l.current()
 	l.emit(token.Key)
+	if IsRawValueKey(key) || slices.Contains(l.rawKeys, strings.ToLower(key)) {
+		return lexRawValue
+	}
+	return lexStart
+}
+
+// lexMatch emits a key that searches for its value e.g title~invoice or text~"old ticket",
+// the value is lexed as a single string so that it can contain any character
+func lexMatch(l *Lexer) StateFn {
+	if l.peek() != '~' {
+		panic("lexMatch called without a tilde")
+	}
+	l.emit(token.Key)
+	l.next()
+	l.emit(token.Tilde)
+
+	var peek = l.peek()
+	if peek == EOF || IsWhitespace(peek) || peek == '"' || peek == '\'' {
+		return lexStart
+	}
+	l.readUntil(func(r rune) bool {
+		return IsWhitespace(r) || r == ')'
+	})
+	l.emit(token.String)
+	return lexStart
+}
+
+// lexRawValue emits the whole value as a single string e.g now+3d,
+// otherwise 2024-01-02 would be lexed as numbers and -01 as a negative number
+func lexRawValue(l *Lexer) StateFn {
+	l.next()
+	l.emit(token.Colon)
+
+	var peek = l.peek()
+	if peek == EOF || IsWhitespace(peek) || peek == '"' || peek == '\'' {
+		return lexStart
+	}
+
+	l.readUntil(func(r rune) bool {
+		return IsWhitespace(r) || r == ')'
+	})
+	l.emit(token.String)
 	return lexStart
 }
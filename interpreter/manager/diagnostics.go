@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Diagnostics are every error and warning reported for a single input
+type Diagnostics struct {
+	Input string
+	Items []ErrorTranspiler
+}
+
+// NewDiagnostics creates the diagnostics for the input
+func NewDiagnostics(input string, items []ErrorTranspiler) *Diagnostics {
+	return &Diagnostics{Input: input, Items: items}
+}
+
+func (d *Diagnostics) Error() string {
+	var messages = make([]string, 0, len(d.Items))
+	for i := range d.Items {
+		messages = append(messages, d.Items[i].Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// HasFatal returns true if any of the diagnostics stopped the pipeline
+func (d *Diagnostics) HasFatal() bool {
+	for i := range d.Items {
+		if d.Items[i].IsFatal() {
+			return true
+		}
+	}
+	return false
+}
+
+// Underline returns the columns to underline for the item, the range is clamped
+// to the input and errors without a position point at the end of the input.
+// The span of an item is in bytes, the columns are the display width of the text
+// before it so that the caret lines up below e.g add "café" priority:
+func (d *Diagnostics) Underline(item *ErrorTranspiler) (start int, end int) {
+	var length = len(d.Input)
+	start, end, ok := item.Span()
+	if !ok {
+		start, end = length, length
+	}
+	start = min(max(start, 0), length)
+	end = min(max(end, start), length)
+	var startColumn = runewidth.StringWidth(d.Input[:start])
+	var endColumn = startColumn + runewidth.StringWidth(d.Input[start:end])
+	if endColumn == startColumn {
+		endColumn = startColumn + 1
+	}
+	return startColumn, endColumn
+}
+
+// Render returns the input followed by a caret line and message for each item e.g
+//
+//	add "x" priority:
+//	                 ^
+//	(Fatal) Syntax: Expected a value after the colon
+func (d *Diagnostics) Render() string {
+	var builder strings.Builder
+	builder.WriteString(d.Input)
+	for i := range d.Items {
+		var item = &d.Items[i]
+		var start, end = d.Underline(item)
+		builder.WriteString("\n")
+		builder.WriteString(strings.Repeat(" ", start))
+		builder.WriteString(strings.Repeat("^", end-start))
+		builder.WriteString("\n")
+		builder.WriteString(item.Summary())
+	}
+	return builder.String()
+}
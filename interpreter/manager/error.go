@@ -2,6 +2,7 @@ package manager
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/luke-goddard/taskninja/interpreter/ast"
 	"github.com/luke-goddard/taskninja/interpreter/token"
@@ -15,6 +16,7 @@ const (
 	TranspilerErrorParse         ErrorTranspilerVariant = "Syntax"
 	TranspilerErrorSemantic      ErrorTranspilerVariant = "Semantic"
 	TranspilerErrorTranspilation ErrorTranspilerVariant = "Transpilation"
+	TranspilerErrorAlias         ErrorTranspilerVariant = "Alias"
 )
 
 const (
@@ -30,6 +32,11 @@ type ErrorTranspiler struct {
 	Message  string
 	Token    *token.Token
 	Node     ast.Node // Node is an interface so no pointer
+
+	Suggestion string // Optional, what the user probably meant e.g priority for prority
+
+	span    [2]int // Optional, overrides the position of the token and node
+	hasSpan bool
 }
 
 // NewErrorTranspiler creates a new error transpiler
@@ -49,7 +56,19 @@ func (e *ErrorTranspiler) Error() string {
 	if e.hasToken() {
 		baseMessage = fmt.Sprintf("%s: %s", baseMessage, e.Token.String())
 	}
-	return baseMessage
+	return baseMessage + e.didYouMean()
+}
+
+// Summary returns the severity, variant and message without the token
+func (e *ErrorTranspiler) Summary() string {
+	return fmt.Sprintf("(%s) %s: %s%s", e.Severity, e.Variant, e.Message, e.didYouMean())
+}
+
+func (e *ErrorTranspiler) didYouMean() string {
+	if e.Suggestion == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %s?", e.Suggestion)
 }
 
 func (e *ErrorTranspiler) SetToken(token *token.Token) *ErrorTranspiler {
@@ -62,6 +81,19 @@ func (e *ErrorTranspiler) SetNode(node ast.Node) *ErrorTranspiler {
 	return e
 }
 
+func (e *ErrorTranspiler) SetSuggestion(suggestion string) *ErrorTranspiler {
+	e.Suggestion = suggestion
+	return e
+}
+
+// SetSpan overrides the columns returned by Span e.g when the input was rewritten
+// before lexing and the token positions no longer match what the user typed
+func (e *ErrorTranspiler) SetSpan(start int, end int) *ErrorTranspiler {
+	e.span = [2]int{start, end}
+	e.hasSpan = true
+	return e
+}
+
 func (e *ErrorTranspiler) SetSeverityFatal() *ErrorTranspiler {
 	e.Severity = TranspilerErrorSeverityFatal
 	return e
@@ -73,7 +105,34 @@ func (e *ErrorTranspiler) SetSeverityWarning() *ErrorTranspiler {
 }
 
 func (e *ErrorTranspiler) hasToken() bool { return e.Token != nil }
-func (e *ErrorTranspiler) hasNode() bool  { return e.Node != nil }
+func (e *ErrorTranspiler) hasNode() bool {
+	if e.Node == nil {
+		return false
+	}
+	var value = reflect.ValueOf(e.Node)
+	return value.Kind() != reflect.Ptr || !value.IsNil()
+}
+
+// IsFatal returns true if the error should stop the pipeline
+func (e *ErrorTranspiler) IsFatal() bool {
+	return e.Severity == TranspilerErrorSeverityFatal
+}
+
+// Span returns the columns of the input that caused the error, the end is exclusive.
+// The token is preferred over the node, ok is false when neither has a position.
+// An empty span e.g the description in add "" points at the column it starts in
+func (e *ErrorTranspiler) Span() (start int, end int, ok bool) {
+	if e.hasSpan {
+		return e.span[0], e.span[1], true
+	}
+	if e.hasToken() {
+		return int(e.Token.StartPosition), int(e.Token.EndPosition), true
+	}
+	if e.hasNode() && e.Node.EndColumn() > 0 {
+		return int(e.Node.StartColumn()), int(e.Node.EndColumn()), true
+	}
+	return 0, 0, false
+}
 
 // Occurred during the Lexical Analysis phase
 func NewLexError(message string) *ErrorTranspiler {
@@ -90,6 +149,11 @@ func NewSemanticError(message string) *ErrorTranspiler {
 	return NewErrorTranspiler(TranspilerErrorSemantic, message)
 }
 
+// Occurred while expanding a user defined alias
+func NewAliasError(message string) *ErrorTranspiler {
+	return NewErrorTranspiler(TranspilerErrorAlias, message)
+}
+
 // Occurred during the Transpilation phase
 func NewTranspilationError(message string) *ErrorTranspiler {
 	return NewErrorTranspiler(TranspilerErrorTranspilation, message)
@@ -21,7 +21,7 @@ func (manager *ErrorManager) GetErrors() []ErrorTranspiler {
 	return manager.errors
 }
 
-// HasErrors returns true if there are any errors stored
+// HasErrors returns true if there are any fatal errors stored
 func (manager *ErrorManager) HasErrors() bool {
 	return manager.hasErrors
 }
@@ -50,6 +50,14 @@ func (manager *ErrorManager) EmitSemantic(message string, node ast.Node) {
 	manager.emit(err)
 }
 
+// EmitSemanticWarning emits a semantic warning, warnings do not stop the pipeline
+func (manager *ErrorManager) EmitSemanticWarning(message string, node ast.Node) {
+	var err = NewSemanticError(message).
+		SetNode(node).
+		SetSeverityWarning()
+	manager.emit(err)
+}
+
 // EmitTranspilation emits a transpilation error
 func (manager *ErrorManager) EmitTranspilation(message string, node ast.Node) {
 	var err = NewTranspilationError(message).
@@ -58,8 +66,15 @@ func (manager *ErrorManager) EmitTranspilation(message string, node ast.Node) {
 	manager.emit(err)
 }
 
+// Emit stores an error that was built by the caller e.g to attach a suggestion
+func (manager *ErrorManager) Emit(e *ErrorTranspiler) {
+	manager.emit(e)
+}
+
 func (manager *ErrorManager) emit(e *ErrorTranspiler) {
-	manager.hasErrors = true
+	if e.IsFatal() {
+		manager.hasErrors = true
+	}
 	manager.errors = append(manager.errors, *e)
 }
 
@@ -83,6 +98,27 @@ func (manager *ErrorManager) TranspilationErrors() []ErrorTranspiler {
 	return manager.filterErrors(TranspilerErrorTranspilation)
 }
 
+// Warnings returns the errors that do not stop the pipeline
+func (manager *ErrorManager) Warnings() []ErrorTranspiler {
+	var warnings = make([]ErrorTranspiler, 0)
+	for _, e := range manager.errors {
+		if !e.IsFatal() {
+			warnings = append(warnings, e)
+		}
+	}
+	return warnings
+}
+
+// FirstFatal returns the first error that stops the pipeline or nil
+func (manager *ErrorManager) FirstFatal() *ErrorTranspiler {
+	for i := range manager.errors {
+		if manager.errors[i].IsFatal() {
+			return &manager.errors[i]
+		}
+	}
+	return nil
+}
+
 func (manager *ErrorManager) filterErrors(variant ErrorTranspilerVariant) []ErrorTranspiler {
 	var errors = make([]ErrorTranspiler, 0)
 	for _, e := range manager.errors {
@@ -20,41 +20,44 @@ func parsePrimaryExpression(parser *Parser) ast.Expression {
 			Kind:  ast.LiteralKindNumber,
 			Value: parser.consume().Value,
 		}
+	case token.Tag:
+		return parseTag(parser)
 	case token.Key:
 		var key = parser.consume().Value
 		if parser.hasNoTokens() {
-			parser.errors.EmitParse("Expected more tokens", parser.current())
+			parser.errors.EmitParse("Expected more tokens", parser.currentOrEOF())
 			return nil
 		}
-		if !parser.expectCurrent(token.Colon) {
+		if !parser.expectOneOf(token.Colon, token.Tilde) {
 			parser.errors.EmitParse("Missing Colon in key", parser.current())
 			return nil
 		}
-		parser.consume()
+		var match = parser.consume().Type == token.Tilde
 		if parser.hasNoTokens() {
-			parser.errors.EmitParse("Expected more tokens", parser.current())
+			parser.errors.EmitParse(fmt.Sprintf("Expected a value for key: %s", key), parser.currentOrEOF())
+			return nil
 		}
 		if !parser.expectOneOf(token.String, token.Number) {
 			parser.errors.EmitParse("Expected string/number value", parser.current())
+			return nil
 		}
 		parser.endStatement = true
-		switch parser.current().Type {
+		var value = parser.current()
+		switch value.Type {
 		case token.Number:
-			return &ast.Key{
-				Key: key,
-				Expr: &ast.Literal{
-					Kind:  ast.LiteralKindNumber,
-					Value: parser.consume().Value,
-				},
+			var literal = &ast.Literal{
+				Kind:  ast.LiteralKindNumber,
+				Value: parser.consume().Value,
 			}
+			parser.setSpan(literal, value)
+			return &ast.Key{Key: key, Expr: literal, Match: match}
 		case token.String:
-			return &ast.Key{
-				Key: key,
-				Expr: &ast.Literal{
-					Kind:  ast.LiteralKindString,
-					Value: parser.consume().Value,
-				},
+			var literal = &ast.Literal{
+				Kind:  ast.LiteralKindString,
+				Value: parser.consume().Value,
 			}
+			parser.setSpan(literal, value)
+			return &ast.Key{Key: key, Expr: literal, Match: match}
 		default:
 			assert.Fail("Expected string or number")
 		}
@@ -78,23 +81,31 @@ func parseExpression(parser *Parser, bp BindingPower) ast.Expression {
 		return nil
 	}
 
+	var start = parser.current()
 	var left = nudHandler(parser)
-	if left == nil || parser.hasNoTokens() {
+	if left == nil {
+		return nil
+	}
+	parser.setSpan(left, start)
+	if parser.hasNoTokens() {
 		return left
 	}
-	for BindingPowerTable[parser.current().Type] > bp && parser.endStatement == false {
+	for BindingPowerTable[parser.current().Type] > bp {
 		var tokenKind = parser.current().Type
 		var ledHandler, exists = LedTable[tokenKind]
 		if !exists {
-			var current = parser.current()
-			var message = fmt.Sprintf("Missing led handler Unknown token: %s", current.String())
-			parser.errors.EmitParse(message, current)
-			return nil
+			// e.g +HOME priority:high, the next token starts a new statement
+			break
+		}
+		if parser.endStatement && !isLogicalOperator(tokenKind) {
+			break
 		}
+		parser.endStatement = false
 		left = ledHandler(parser, left, bp)
 		if left == nil {
 			return nil
 		}
+		parser.setSpan(left, start)
 		if parser.hasNoTokens() {
 			return left
 		}
@@ -123,6 +134,8 @@ func parseBinaryExpression(parser *Parser, left ast.Expression, bp BindingPower)
 		binop = ast.BinaryOperatorSub
 	case token.Star:
 		binop = ast.BinaryOperatorMul
+	case token.Slash:
+		binop = ast.BinaryOperatorDiv
 	default:
 		var err = fmt.Errorf("Unknown binary operator: %s", op.String())
 		panic(err)
@@ -139,12 +152,73 @@ func parseBinaryExpression(parser *Parser, left ast.Expression, bp BindingPower)
 	}
 }
 
+func parseLogicalExpression(parser *Parser, left ast.Expression, bp BindingPower) ast.Expression {
+	if !parser.expectOneOf(token.And, token.Or) {
+		return nil
+	}
+	var op = parser.consume()
+	var logicalOp = ast.LogicalOperatorAnd
+	if op.Type == token.Or {
+		logicalOp = ast.LogicalOperatorOr
+	}
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse("Expected an expression after logical operator", op)
+		return nil
+	}
+	var right = parseExpression(parser, BP_LOGICAL)
+	if right == nil {
+		return nil
+	}
+	return &ast.LogicalExpression{
+		Operator: logicalOp,
+		Left:     left,
+		Right:    right,
+	}
+}
+
+func parseTag(parser *Parser) ast.Expression {
+	var value = parser.current().Value
+	if len(value) < 2 {
+		parser.errors.EmitParse("Expected tag name", parser.current())
+		return nil
+	}
+
+	var op ast.TagOperator
+	switch value[0] {
+	case '+':
+		op = ast.TagOperatorPlus
+	case '-':
+		op = ast.TagOperatorMinus
+	default:
+		parser.errors.EmitParse("Expected tag operator + or -", parser.current())
+		return nil
+	}
+
+	parser.consume()
+	return &ast.Tag{
+		Operator: op,
+		Value:    value[1:],
+	}
+}
+
+func isLogicalOperator(tokenType token.TokenType) bool {
+	return tokenType == token.And || tokenType == token.Or
+}
+
 func parseGroupedExpression(parser *Parser) ast.Expression {
 	parser.consume() // Get past left paren
 	var expression = parseExpression(parser, BP_DEFAULT)
 	if expression == nil {
 		return nil
 	}
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse("Expected closing parenthesis", parser.eof())
+		return nil
+	}
+	if !parser.expectCurrent(token.RightParen) {
+		return nil
+	}
 	parser.consume() // Get past right paren
+	parser.endStatement = false
 	return expression
 }
@@ -59,8 +59,8 @@ func createLookupTable() {
 	nud(token.LeftParen, BP_PRIMARY, parseGroupedExpression)
 
 	// Logical
-	led(token.Or, BP_LOGICAL, parseBinaryExpression)
-	led(token.And, BP_LOGICAL, parseBinaryExpression)
+	led(token.Or, BP_LOGICAL, parseLogicalExpression)
+	led(token.And, BP_LOGICAL, parseLogicalExpression)
 
 	// Relational
 	led(token.Equal, BP_RELATIONAL, parseBinaryExpression)
@@ -9,11 +9,25 @@
 // ========================================================
 // COMMAND -> (
 //  COMMAND_ADD | // e.g add "Buy milk"
+//  COMMAND_LIST | // e.g list +home priority:high
+//  COMMAND_MODIFY | // e.g modify 1 priority:high +home "Buy oat milk"
+//  COMMAND_TASKID | // e.g done 1
+//  COMMAND_TAGS | // e.g tags
 // )
 // COMMAND_ADD -> (
 //  add PARAM | // e.g add "Buy milk"
 //  add PARAM EXPRESSION_STATEMENTS | // e.g add "Buy milk" due:2016-01-02 priority:high
 // )
+// COMMAND_LIST -> (
+//  list | // e.g list
+//  list EXPRESSION_STATEMENTS | // e.g list +home (project:home or priority:high)
+// )
+// COMMAND_MODIFY -> (
+//  modify TASKID EXPRESSION_STATEMENTS | // e.g modify 1 project:home -work
+//  modify (EXPRESSION) EXPRESSION_STATEMENTS | // e.g modify (+work) priority:low
+// )
+// COMMAND_TASKID -> (next | done | delete | start | stop) TASKID
+// COMMAND_TAGS -> tags
 // EXPRESSION_STATEMENTS -> EXPRESSION_STATEMENT | EXPRESSION_STATEMENT EXPRESSION_STATEMENTS
 // EXPRESSION_STATEMENT -> EXPRESSION | EXPRESSION EXPRESSION_STATEMENT
 // EXPRESSION -> BINARY_EXPRESSION | LOGICAL_EXPRESSION | TAG | PAIR
@@ -24,7 +38,8 @@
 // PARAM -> TASKID | STRING
 // TAG -> +TAG | -TAG
 // PAIR -> key:EXPRESSION | key:EXPRESSION_STATEMENTS
-// TASKID -> number
+// TASKID -> number | IDLIST
+// IDLIST -> number,number | number-number | IDLIST,IDLIST // e.g 1-5,8
 
 package parser
 
@@ -57,7 +72,7 @@ func NewParser(manager *manager.ErrorManager) *Parser {
 func (p *Parser) Parse(tokens []token.Token) (*ast.Command, []manager.ErrorTranspiler) {
 	p.tokens = tokens
 	if len(tokens) == 0 {
-		p.errors.EmitParse("no tokens to parse", &token.Token{})
+		p.errors.EmitParse("no tokens to parse", p.eof())
 		return nil, p.errors.ParseErrors()
 	}
 	return parseCommand(p), p.errors.ParseErrors()
@@ -66,6 +81,7 @@ func (p *Parser) Parse(tokens []token.Token) (*ast.Command, []manager.ErrorTrans
 func (p *Parser) Reset() *Parser {
 	p.position = 0
 	p.hasCheckedExists = false
+	p.endStatement = false
 	p.errors.Reset()
 	return p
 }
@@ -101,6 +117,43 @@ func (parser *Parser) current() *token.Token {
 	return &parser.tokens[parser.position]
 }
 
+// currentOrEOF returns the current token or the EOF token if there are none left,
+// useful when reporting an error at the end of the input
+func (parser *Parser) currentOrEOF() *token.Token {
+	if parser.hasNoTokens() {
+		return parser.eof()
+	}
+	return parser.current()
+}
+
+// eof returns a token positioned after the last token
+func (parser *Parser) eof() *token.Token {
+	var end token.Pos = 0
+	var line = 1
+	if len(parser.tokens) > 0 {
+		var last = parser.tokens[len(parser.tokens)-1]
+		end = last.EndPosition
+		line = last.LineNumber
+	}
+	return token.NewToken(token.Error, end, end, line, "EOF")
+}
+
+// previous returns the last consumed token
+func (parser *Parser) previous() *token.Token {
+	if parser.position == 0 {
+		return parser.eof()
+	}
+	return &parser.tokens[parser.position-1]
+}
+
+// setSpan sets the columns of the node from the start token to the last consumed token
+func (parser *Parser) setSpan(node ast.Node, start *token.Token) {
+	if node == nil || start == nil {
+		return
+	}
+	node.SetColumns(ast.Column(start.StartPosition), ast.Column(parser.previous().EndPosition))
+}
+
 func (parser *Parser) consume() *token.Token {
 	if !parser.hasCheckedExists {
 		panic("Must call hasTokens before calling consume")
@@ -14,7 +14,7 @@ func FuzzParser(f *testing.F) {
 		var tokens = make([]token.Token, 0)
 		tokens = append(tokens, token.Token{Type: token.Command, Value: "add"})
 		for i, b := range input {
-			if b >= 18 {
+			if b >= 19 {
 				t.Skip()
 			}
 			var token = token.Token{
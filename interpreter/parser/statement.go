@@ -6,15 +6,27 @@ import (
 	"strings"
 
 	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/lex"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/suggest"
 	"github.com/luke-goddard/taskninja/interpreter/token"
 	"github.com/rs/zerolog/log"
 )
 
 func parseCommand(parser *Parser) *ast.Command {
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("no tokens to parse", &token.Token{})
+		parser.errors.EmitParse("no tokens to parse", parser.eof())
 		return nil
 	}
+	var start = parser.current()
+	var command = parseCommandKind(parser)
+	if command != nil {
+		parser.setSpan(command, start)
+	}
+	return command
+}
+
+func parseCommandKind(parser *Parser) *ast.Command {
 	if parser.current().Type == token.Command &&
 		strings.ToLower(parser.current().Value) == "add" {
 		return parseAddCommand(parser)
@@ -22,75 +34,259 @@ func parseCommand(parser *Parser) *ast.Command {
 
 	if parser.current().Type == token.Command &&
 		strings.ToLower(parser.current().Value) == "depends" {
-		return parseDependsCommand(parser)
+		return parseDependsCommand(parser, ast.CommandKindDepends)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "undepends" {
+		return parseDependsCommand(parser, ast.CommandKindUndepends)
 	}
 
 	if parser.current().Type == token.Command &&
 		strings.ToLower(parser.current().Value) == "next" {
-		return parseNextCommand(parser)
+		return parseTaskIdCommand(parser, ast.CommandKindNext)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "done" {
+		return parseTaskIdCommand(parser, ast.CommandKindDone)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "delete" {
+		return parseTaskIdCommand(parser, ast.CommandKindDelete)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "start" {
+		return parseTaskIdCommand(parser, ast.CommandKindStart)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "stop" {
+		return parseTaskIdCommand(parser, ast.CommandKindStop)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "tags" {
+		return parseTagsCommand(parser)
 	}
 
-	// if parser.current().Type == token.Command &&
-	// 	strings.ToLower(parser.current().Value) == "list" {
-	// 	return parseListCommand(parser)
-	// }
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "list" {
+		return parseListCommand(parser)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "modify" {
+		return parseModifyCommand(parser)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "report" {
+		return parseReportCommand(parser)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "undo" {
+		return parseUndoCommand(parser)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "annotate" {
+		return parseAnnotateCommand(parser, ast.CommandKindAnnotate)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "denotate" {
+		return parseAnnotateCommand(parser, ast.CommandKindDenotate)
+	}
+
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "search" {
+		return parseSearchCommand(parser)
+	}
 
-	parser.errors.EmitParse("Unknown command", parser.current())
+	if parser.current().Type == token.Command &&
+		strings.ToLower(parser.current().Value) == "info" {
+		return parseTaskIdCommand(parser, ast.CommandKindInfo)
+	}
+
+	var err = manager.NewParseError("Unknown command").SetToken(parser.current())
+	if suggestion, ok := suggest.Closest(parser.current().Value, commandNames()); ok {
+		err.SetSuggestion(suggestion)
+	}
+	parser.errors.Emit(err)
 	return nil
 }
 
-func parseNextCommand(parser *Parser) *ast.Command {
-	var taskIdInt64 int64
-	var err error
+// commandNames returns every command keyword, used to suggest a command for a typo
+func commandNames() []string {
+	var names = make([]string, 0, len(lex.Commands))
+	for _, command := range lex.Commands {
+		names = append(names, string(command))
+	}
+	return names
+}
+
+// parseTaskIdCommand parses commands that only take task ids e.g done 1 or done 1-5,8
+func parseTaskIdCommand(parser *Parser, kind ast.CommandKind) *ast.Command {
 	parser.consume()
 
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("Expected a taskId to the next command", &token.Token{})
+		parser.errors.EmitParse(
+			fmt.Sprintf("Expected a taskId to the %s command", kind.String()),
+			parser.eof(),
+		)
 		return nil
 	}
-	if !parser.expectCurrent(token.Number) {
+	if !parser.expectOneOf(token.Number, token.IdList) {
+		return nil
+	}
+	var param = parseTaskIdParam(parser)
+	if param == nil {
+		return nil
+	}
+	if !parser.hasNoTokens() {
+		parser.errors.EmitParse(
+			fmt.Sprintf("Unexpected token after the %s command", kind.String()),
+			parser.current(),
+		)
 		return nil
 	}
-	var taskId = parser.consume().Value
-	taskIdInt64, err = strconv.ParseInt(taskId, 10, 64)
+	return &ast.Command{
+		Kind:  kind,
+		Param: param,
+	}
+}
+
+// parseTaskIdParam parses a single task id (1) or a list of task ids (1-5,8)
+func parseTaskIdParam(parser *Parser) *ast.Param {
+	var tok = parser.consume()
+	if tok.Type == token.IdList {
+		var ids, err = ast.ParseTaskIds(tok.Value)
+		if err != nil {
+			parser.errors.EmitParse(err.Error(), tok)
+			return nil
+		}
+		var param = &ast.Param{Kind: ast.ParamTypeTaskIds, Value: ids}
+		parser.setSpan(param, tok)
+		return param
+	}
+	var taskId, err = strconv.ParseInt(tok.Value, 10, 64)
 	if err != nil {
+		parser.errors.EmitParse(fmt.Sprintf("Failed to parse task id: %s", err), tok)
+		return nil
+	}
+	var param = &ast.Param{Kind: ast.ParamTypeTaskId, Value: taskId}
+	parser.setSpan(param, tok)
+	return param
+}
+
+func parseTagsCommand(parser *Parser) *ast.Command {
+	parser.consume()
+	if !parser.hasNoTokens() {
+		parser.errors.EmitParse("The tags command does not accept any arguments", parser.current())
+		return nil
+	}
+	return &ast.Command{Kind: ast.CommandKindTags}
+}
+
+// parseAnnotateCommand parses the tasks and the text of a note e.g annotate 1 "pinged vendor",
+// the text is optional for denotate which then removes the most recent note
+func parseAnnotateCommand(parser *Parser, kind ast.CommandKind) *ast.Command {
+	parser.consume()
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse(fmt.Sprintf("Expected a taskId e.g %s 1 \"pinged vendor\"", kind.String()), parser.eof())
+		return nil
+	}
+	if !parser.expectOneOf(token.Number, token.IdList) {
+		return nil
+	}
+	var taskIds = parseTaskIdParam(parser)
+	if taskIds == nil {
+		return nil
+	}
+	var param = &ast.Param{
+		Kind:  ast.ParamTypeAnnotation,
+		Value: ast.ParamAnnotation{TaskIds: taskIds.TaskIds()},
+	}
+	param.SetColumns(taskIds.StartColumn(), taskIds.EndColumn())
+	if parser.hasNoTokens() {
+		if kind == ast.CommandKindAnnotate {
+			parser.errors.EmitParse("Expected the text of the note e.g annotate 1 \"pinged vendor\"", parser.eof())
+			return nil
+		}
+		param.Value = ast.ParamAnnotation{TaskIds: taskIds.TaskIds(), Latest: true}
+		return &ast.Command{Kind: kind, Param: param}
+	}
+	if !parser.expectCurrent(token.String) {
+		return nil
+	}
+	param.Value = ast.ParamAnnotation{TaskIds: taskIds.TaskIds(), Text: parser.consume().Value}
+	param.SetColumns(taskIds.StartColumn(), ast.Column(parser.previous().EndPosition))
+	if !parser.hasNoTokens() {
 		parser.errors.EmitParse(
-			fmt.Sprintf("Failed to parse task id: %s", err),
+			fmt.Sprintf("Unexpected token after the note, quote the text e.g %s 1 \"pinged vendor\"", kind.String()),
 			parser.current(),
 		)
 		return nil
 	}
+	return &ast.Command{Kind: kind, Param: param}
+}
+
+func parseUndoCommand(parser *Parser) *ast.Command {
+	parser.consume()
+	if !parser.hasNoTokens() {
+		parser.errors.EmitParse("The undo command does not accept any arguments", parser.current())
+		return nil
+	}
+	return &ast.Command{Kind: ast.CommandKindUndo}
+}
+
+// parseReportCommand parses the name of a report e.g report next
+func parseReportCommand(parser *Parser) *ast.Command {
+	parser.consume()
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse("Expected the name of a report e.g report next", parser.eof())
+		return nil
+	}
+	if !parser.expectCurrent(token.String) {
+		return nil
+	}
+	var tok = parser.consume()
+	var param = &ast.Param{Kind: ast.ParamTypeReport, Value: strings.ToLower(tok.Value)}
+	parser.setSpan(param, tok)
+	if !parser.hasNoTokens() {
+		parser.errors.EmitParse("The report command only accepts the name of a report", parser.current())
+		return nil
+	}
 	return &ast.Command{
-		Kind: ast.CommandKindNext,
-		Param: &ast.Param{
-			Kind:  ast.ParamTypeTaskId,
-			Value: taskIdInt64,
-		},
+		Kind:  ast.CommandKindReport,
+		Param: param,
 	}
 }
 
-func parseDependsCommand(parser *Parser) *ast.Command {
+// parseDependsCommand parses depends and undepends e.g depends 1 on 2 or undepends 1,3 2
+func parseDependsCommand(parser *Parser, kind ast.CommandKind) *ast.Command {
 	// dutty
-	var taskIdInt64, dependsOnIdInt64 int64
+	var dependsOnIdInt64 int64
 	var err error
 
 	parser.consume()
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("Expected a param e.g depends 1 on 2", &token.Token{})
+		parser.errors.EmitParse(fmt.Sprintf("Expected a param e.g %s 1 on 2", kind.String()), parser.eof())
 		return nil
 	}
-	if !parser.expectCurrent(token.Number) {
+	if !parser.expectOneOf(token.Number, token.IdList) {
 		return nil
 	}
-	var taskId = parser.consume().Value
-
-	taskIdInt64, err = strconv.ParseInt(taskId, 10, 64)
-	if err != nil {
-		parser.errors.EmitParse("Failed to parse task Id", parser.current())
+	var taskIds = parseTaskIdParam(parser)
+	if taskIds == nil {
 		return nil
 	}
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("Expected more tokens after taskID", &token.Token{})
+		parser.errors.EmitParse("Expected more tokens after taskID", parser.eof())
 		return nil
 	}
 	if !parser.expectOneOf(token.String, token.Number) {
@@ -104,12 +300,12 @@ func parseDependsCommand(parser *Parser) *ast.Command {
 		}
 		parser.consume()
 		if parser.hasNoTokens() {
-			parser.errors.EmitParse("Expected a number after string('ON')", &token.Token{})
+			parser.errors.EmitParse("Expected a number after string('ON')", parser.eof())
 			return nil
 		}
 	}
 	if !parser.expectCurrent(token.Number) {
-		parser.errors.EmitParse("Expected a number e.g depends 1 on 2", parser.current())
+		parser.errors.EmitParse(fmt.Sprintf("Expected a number e.g %s 1 on 2", kind.String()), parser.current())
 		return nil
 	}
 	var dependsOnId = parser.consume().Value
@@ -118,23 +314,25 @@ func parseDependsCommand(parser *Parser) *ast.Command {
 		parser.errors.EmitParse("Failed to parse dependsOnId", parser.current())
 		return nil
 	}
-	return &ast.Command{
-		Kind: ast.CommandKindDepends,
-		Param: &ast.Param{
-			Kind: ast.ParamTypeDependency,
-			Value: ast.ParamDependency{
-				TaskId:      taskIdInt64,
-				DependsOnId: dependsOnIdInt64,
-			},
+	var param = &ast.Param{
+		Kind: ast.ParamTypeDependency,
+		Value: ast.ParamDependency{
+			TaskIds:     taskIds.TaskIds(),
+			DependsOnId: dependsOnIdInt64,
 		},
 	}
+	param.SetColumns(taskIds.StartColumn(), ast.Column(parser.previous().EndPosition))
+	return &ast.Command{
+		Kind:  kind,
+		Param: param,
+	}
 
 }
 
 func parseAddCommand(parser *Parser) *ast.Command {
 	parser.consume()
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("Expected a param", &token.Token{})
+		parser.errors.EmitParse("Expected a param", parser.eof())
 		return nil
 	}
 	if !parser.expectCurrent(token.String) {
@@ -152,6 +350,41 @@ func parseAddCommand(parser *Parser) *ast.Command {
 	}
 }
 
+func parseModifyCommand(parser *Parser) *ast.Command {
+	parser.consume()
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse("Expected a taskId or a filter e.g modify 1 priority:high", parser.eof())
+		return nil
+	}
+
+	var param *ast.Param
+	switch parser.current().Type {
+	case token.Number, token.IdList:
+		param = parseTaskIdParam(parser)
+		if param == nil {
+			return nil
+		}
+	case token.LeftParen:
+		var start = parser.current()
+		var filter = parseGroupedExpression(parser)
+		if filter == nil {
+			return nil
+		}
+		param = &ast.Param{Kind: ast.ParamTypeFilter, Value: filter}
+		parser.setSpan(param, start)
+	default:
+		parser.errors.EmitParse("Expected a taskId or a filter in parentheses e.g modify (+home) priority:high", parser.current())
+		return nil
+	}
+
+	var options = parseStatments(parser)
+	return &ast.Command{
+		Kind:    ast.CommandKindModify,
+		Param:   param,
+		Options: options,
+	}
+}
+
 func parseListCommand(parser *Parser) *ast.Command {
 	parser.consume()
 	var options = parseStatments(parser)
@@ -161,56 +394,77 @@ func parseListCommand(parser *Parser) *ast.Command {
 	}
 }
 
+// parseSearchCommand parses the text to search for and the filters e.g search "old ticket" +work
+func parseSearchCommand(parser *Parser) *ast.Command {
+	parser.consume()
+	if parser.hasNoTokens() {
+		parser.errors.EmitParse("Expected the text to search for e.g search \"old ticket\"", parser.eof())
+		return nil
+	}
+	if !parser.expectCurrent(token.String) {
+		parser.errors.EmitParse("Expected the text to search for e.g search \"old ticket\"", parser.current())
+		return nil
+	}
+	var param = parseParam(parser)
+	if param == nil {
+		return nil
+	}
+	var options = parseStatments(parser)
+	return &ast.Command{
+		Kind:    ast.CommandKindSearch,
+		Param:   param,
+		Options: options,
+	}
+}
+
 func parseExpressionStatement(parser *Parser) *ast.ExpressionStatement {
 	var expression = parseExpression(parser, BP_DEFAULT)
 	if expression == nil {
-		parser.errors.EmitParse("Expected an expression", parser.current())
+		parser.errors.EmitParse("Expected an expression", parser.currentOrEOF())
 		return nil
 	}
-	return &ast.ExpressionStatement{Expr: expression}
+	var stmt = &ast.ExpressionStatement{Expr: expression}
+	stmt.SetColumns(expression.StartColumn(), expression.EndColumn())
+	return stmt
 }
 
 func parseParam(parser *Parser) *ast.Param {
 	if parser.hasNoTokens() {
-		parser.errors.EmitParse("Expected a param", &token.Token{})
+		parser.errors.EmitParse("Expected a param", parser.eof())
 		return nil
 	}
-	if parser.current().Type == token.String {
-		return &ast.Param{
+	var start = parser.current()
+	if start.Type == token.String {
+		var param = &ast.Param{
 			Kind:  ast.ParamTypeDescription,
 			Value: parser.consume().Value,
 		}
+		parser.setSpan(param, start)
+		return param
 	}
-	if parser.current().Type == token.Number {
-		return &ast.Param{
+	if start.Type == token.Number {
+		var param = &ast.Param{
 			Kind:  ast.ParamTypeTaskId,
 			Value: parser.consume().Value,
 		}
+		parser.setSpan(param, start)
+		return param
 	}
 	panic("Unknown param")
 }
 
 func parseTagDecStatement(parser *Parser) ast.Statement {
-	var value = parser.current().Value
-	if len(value) == 0 {
-		panic("Expected tag")
-	}
-
-	var op ast.TagOperator
-
-	if value[0] == '+' {
-		op = ast.TagOperatorPlus
-	} else if value[0] == '-' {
-		op = ast.TagOperatorMinus
-	} else {
-		panic("Expected tag operator")
+	var expression = parseExpression(parser, BP_DEFAULT)
+	if expression == nil {
+		return nil
 	}
-
-	parser.consume()
-	return &ast.Tag{
-		Operator: op,
-		Value:    value[1:],
+	// +HOME on it's own is a statement, +HOME or +WORK is an expression
+	if tag, ok := expression.(*ast.Tag); ok {
+		return tag
 	}
+	var stmt = &ast.ExpressionStatement{Expr: expression}
+	stmt.SetColumns(expression.StartColumn(), expression.EndColumn())
+	return stmt
 }
 
 func parseStatments(parser *Parser) []ast.Statement {
@@ -237,7 +491,7 @@ func parseStatment(parser *Parser) ast.Statement {
 	}
 	var statement = parseExpressionStatement(parser)
 	if statement == nil {
-		parser.errors.EmitParse("Expected a statement", parser.current())
+		parser.errors.EmitParse("Expected a statement", parser.currentOrEOF())
 		return nil
 	}
 	log.Info().Interface("statement", statement).Msg("parseStatment")
@@ -1,24 +1,52 @@
 package semantic
 
 import (
+	"time"
+
 	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/date"
 	"github.com/luke-goddard/taskninja/interpreter/manager"
 )
 
 type Analyzer struct {
-	errors *manager.ErrorManager
+	errors  *manager.ErrorManager
+	tags    []string   // Existing tag names, used to suggest a tag for a typo
+	reports []string   // Names of the reports that can be run e.g next
+	udas    []string   // Names of the user defined attributes, they can be used like any other key
+	clock   date.Clock // Dates e.g until:eoy are compared relative to the clock
 }
 
 func NewAnalyzer(errors *manager.ErrorManager) *Analyzer {
-	return &Analyzer{errors: errors}
+	return &Analyzer{errors: errors, clock: time.Now}
+}
+
+// SetClock replaces the clock used to compare dates e.g due:tomorrow until:today
+func (a *Analyzer) SetClock(clock date.Clock) *Analyzer {
+	a.clock = clock
+	return a
+}
+
+// SetTags sets the existing tag names, misspelt tags are compared against them
+func (a *Analyzer) SetTags(tags []string) *Analyzer {
+	a.tags = tags
+	return a
+}
+
+// SetUDAs sets the names of the user defined attributes e.g estimate
+func (a *Analyzer) SetUDAs(udas []string) *Analyzer {
+	a.udas = udas
+	return a
+}
+
+// SetReports sets the names of the reports that the report command can run
+func (a *Analyzer) SetReports(reports []string) *Analyzer {
+	a.reports = reports
+	return a
 }
 
 func (a *Analyzer) Analyze(node ast.Node) *manager.ErrorTranspiler {
 	a.Visit(node)
-	if a.errors.HasErrors() {
-		return &a.errors.Errors()[0]
-	}
-	return nil
+	return a.errors.FirstFatal()
 }
 
 func (a *Analyzer) Visit(node ast.Node) *Analyzer {
@@ -39,6 +67,12 @@ func (a *Analyzer) EmitError(message string, node ast.Node) *Analyzer {
 	return nil // Return nil to stop the visitor
 }
 
+// EmitWarning reports a problem that does not stop the visitor
+func (a *Analyzer) EmitWarning(message string, node ast.Node) *Analyzer {
+	a.errors.EmitSemanticWarning(message, node)
+	return a
+}
+
 func (a *Analyzer) Reset() *Analyzer {
 	a.errors.Reset()
 	return a
@@ -2,8 +2,14 @@ package semantic
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/date"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/suggest"
 )
 
 func (a *Analyzer) VisitCommand(cmd *ast.Command) *Analyzer {
@@ -12,10 +18,25 @@ func (a *Analyzer) VisitCommand(cmd *ast.Command) *Analyzer {
 		return a.VisitAddCommand(cmd)
 	case ast.CommandKindList:
 		return a.VisitListCommand(cmd)
-	case ast.CommandKindDepends:
+	case ast.CommandKindSearch:
+		return a.VisitSearchCommand(cmd)
+	case ast.CommandKindDepends, ast.CommandKindUndepends:
 		return a.VisitDependsCommand(cmd)
-	case ast.CommandKindNext:
-		return a.VisitNextCommand(cmd)
+	case ast.CommandKindNext, ast.CommandKindDone, ast.CommandKindDelete,
+		ast.CommandKindStart, ast.CommandKindStop:
+		return a.VisitTaskIdCommand(cmd)
+	case ast.CommandKindTags:
+		return a.VisitTagsCommand(cmd)
+	case ast.CommandKindUndo:
+		return a.VisitUndoCommand(cmd)
+	case ast.CommandKindAnnotate, ast.CommandKindDenotate:
+		return a.VisitAnnotateCommand(cmd)
+	case ast.CommandKindModify:
+		return a.VisitModifyCommand(cmd)
+	case ast.CommandKindReport:
+		return a.VisitReportCommand(cmd)
+	case ast.CommandKindInfo:
+		return a.VisitInfoCommand(cmd)
 	}
 	return a.EmitError(fmt.Sprintf("Unknown command kind: %d", cmd.Kind), cmd)
 }
@@ -34,34 +55,250 @@ func (a *Analyzer) VisitAddCommand(cmd *ast.Command) *Analyzer {
 		return a.EmitError("Description cannot be empty", cmd.Param)
 	}
 
+	if a.visitOptions(cmd.Options, KeyUsageInsert) == nil {
+		return nil
+	}
+	if a.visitUntil(cmd) == nil {
+		return nil
+	}
+	return a.visitRepeatedOptions(cmd)
+}
+
+// visitUntil checks that a recurring task does not end before it is due e.g
+// add "x" recur:daily due:tomorrow until:today, a task without a due date is due now
+func (a *Analyzer) visitUntil(cmd *ast.Command) *Analyzer {
+	var now = a.clock()
+	var due, until = now, time.Time{}
+	var untilKey *ast.Key
+	for _, option := range cmd.Options {
+		var stmt, ok = option.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		var key, isKey = stmt.Expr.(*ast.Key)
+		if !isKey {
+			continue
+		}
+		var lit, isLiteral = key.Expr.(*ast.Literal)
+		if !isLiteral {
+			continue
+		}
+		var name = strings.ToLower(key.Key)
+		if name != "due" && name != "until" {
+			continue
+		}
+		var parsed, err = date.Parse(lit.Value, now)
+		if err != nil {
+			continue // Reported when the key is transpiled
+		}
+		if name == "due" {
+			due = parsed
+		} else {
+			until, untilKey = parsed, key
+		}
+	}
+	if untilKey != nil && until.Before(due) {
+		return a.EmitError("The until date cannot be before the due date", untilKey)
+	}
 	return a
 }
 
 func (a *Analyzer) VisitListCommand(cmd *ast.Command) *Analyzer {
 	if cmd.Param != nil {
-		return nil
+		return a.EmitError("List command does not accept a parameter", cmd.Param)
 	}
-	return a
+	return a.visitOptions(cmd.Options, KeyUsageFilter)
+}
+
+// VisitSearchCommand checks the text and the filters of a search e.g search "old ticket" +work
+func (a *Analyzer) VisitSearchCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param == nil || cmd.Param.Kind != ast.ParamTypeDescription {
+		return a.EmitError("The search command requires the text to search for e.g search \"old ticket\"", cmd)
+	}
+	if len(db.SearchTerms(cmd.Param.Value.(string))) == 0 {
+		return a.EmitError("The search must contain at least one word", cmd.Param)
+	}
+	return a.visitOptions(cmd.Options, KeyUsageFilter)
 }
 
 func (a *Analyzer) VisitDependsCommand(cmd *ast.Command) *Analyzer {
 	var param = cmd.Param.Value.(ast.ParamDependency)
-	if param.TaskId < 0 {
-		return a.EmitError("Task ID cannot be negative", cmd.Param)
-	}
 	if param.DependsOnId < 0 {
 		return a.EmitError("DependsOn ID cannot be negative", cmd.Param)
 	}
-	if param.TaskId == param.DependsOnId {
-		return a.EmitError("Task ID and DependsOn ID cannot be the same", cmd.Param)
+	for _, taskId := range param.TaskIds {
+		if taskId < 0 {
+			return a.EmitError("Task ID cannot be negative", cmd.Param)
+		}
+		if taskId == param.DependsOnId {
+			return a.EmitError("Task ID and DependsOn ID cannot be the same", cmd.Param)
+		}
+	}
+	return a
+}
+
+// VisitTaskIdCommand checks commands that only accept a task id e.g next 1
+func (a *Analyzer) VisitTaskIdCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param == nil || cmd.Param.TaskIds() == nil {
+		return a.EmitError(fmt.Sprintf("The %s command requires a task ID", cmd.Kind.String()), cmd)
+	}
+	if len(cmd.Options) != 0 {
+		return a.EmitError(fmt.Sprintf("The %s command does not accept options", cmd.Kind.String()), cmd)
+	}
+	return a.visitTaskIds(cmd.Param)
+}
+
+// VisitInfoCommand checks that a single task is shown e.g info 1
+func (a *Analyzer) VisitInfoCommand(cmd *ast.Command) *Analyzer {
+	if a.VisitTaskIdCommand(cmd) == nil {
+		return nil
+	}
+	if len(cmd.Param.TaskIds()) != 1 {
+		return a.EmitError("The info command shows a single task e.g info 3", cmd.Param)
+	}
+	return a
+}
+
+// visitTaskIds checks that every task id in the param is positive
+func (a *Analyzer) visitTaskIds(param *ast.Param) *Analyzer {
+	for _, tid := range param.TaskIds() {
+		if tid <= 0 {
+			return a.EmitError("Task ID cannot be zero or negative", param)
+		}
+	}
+	return a
+}
+
+// VisitAnnotateCommand checks the tasks and the text of a note e.g annotate 1 "pinged vendor"
+func (a *Analyzer) VisitAnnotateCommand(cmd *ast.Command) *Analyzer {
+	var param = cmd.Param.Value.(ast.ParamAnnotation)
+	if !param.Latest && strings.TrimSpace(param.Text) == "" {
+		return a.EmitError("The note cannot be empty", cmd.Param)
+	}
+	return a.visitTaskIds(cmd.Param)
+}
+
+func (a *Analyzer) VisitUndoCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param != nil {
+		return a.EmitError("Undo command does not accept a parameter", cmd.Param)
+	}
+	if len(cmd.Options) != 0 {
+		return a.EmitError("Undo command does not accept options", cmd)
+	}
+	return a
+}
+
+func (a *Analyzer) VisitTagsCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param != nil {
+		return a.EmitError("Tags command does not accept a parameter", cmd.Param)
+	}
+	if len(cmd.Options) != 0 {
+		return a.EmitError("Tags command does not accept options", cmd)
 	}
 	return a
 }
 
-func (a *Analyzer) VisitNextCommand(cmd *ast.Command) *Analyzer {
-	var tid = cmd.Param.Value.(int64)
-	if tid <= 0 {
-		return a.EmitError("Task ID cannot be zero or negative", cmd.Param)
+// VisitReportCommand checks that the report exists, a misspelt name is given a suggestion
+func (a *Analyzer) VisitReportCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param == nil || cmd.Param.Kind != ast.ParamTypeReport {
+		return a.EmitError("The report command requires the name of a report e.g report next", cmd)
+	}
+	var name = cmd.Param.Value.(string)
+	for _, known := range a.reports {
+		if known == name {
+			return a
+		}
+	}
+	var err = manager.NewSemanticError(fmt.Sprintf("Unknown report: %s", name)).SetNode(cmd.Param)
+	if suggestion, found := suggest.Closest(name, a.reports); found {
+		err.SetSuggestion(suggestion)
+	}
+	a.errors.Emit(err)
+	return nil
+}
+
+func (a *Analyzer) VisitModifyCommand(cmd *ast.Command) *Analyzer {
+	if cmd.Param == nil {
+		return a.EmitError("Modify command requires a task ID or a filter", cmd)
+	}
+
+	switch cmd.Param.Kind {
+	case ast.ParamTypeTaskId, ast.ParamTypeTaskIds:
+		if a.visitTaskIds(cmd.Param) == nil {
+			return nil
+		}
+	case ast.ParamTypeFilter:
+		if a.visitOption(cmd.Param.Value.(ast.Node), KeyUsageFilter) == nil {
+			return nil
+		}
+	default:
+		return a.EmitError("Modify command requires a task ID or a filter", cmd.Param)
+	}
+
+	if len(cmd.Options) == 0 {
+		return a.EmitError("Modify command requires at least one modification e.g priority:high", cmd)
+	}
+
+	for _, option := range cmd.Options {
+		var stmt, ok = option.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		var lit, isLiteral = stmt.Expr.(*ast.Literal)
+		if isLiteral && lit.Kind == ast.LiteralKindString && len(lit.Value) == 0 {
+			return a.EmitError("Title cannot be empty", lit)
+		}
+	}
+
+	if a.visitOptions(cmd.Options, KeyUsageInsert) == nil {
+		return nil
+	}
+	return a.visitRepeatedOptions(cmd)
+}
+
+// singleValueKeys maps each key alias to its name, the last value wins when one is repeated
+var singleValueKeys = map[string]string{
+	"priority": "priority",
+	"p":        "priority",
+	"due":      "due",
+	"recur":    "recur",
+	"until":    "until",
+	"sort":     "sort",
+	"status":   "status",
+	"limit":    "limit",
+	"format":   "format",
+}
+
+// visitRepeatedOptions warns about keys and tags that are given more than once,
+// e.g add "x" priority:high p:low only sets the priority to low
+func (a *Analyzer) visitRepeatedOptions(cmd *ast.Command) *Analyzer {
+	var keys = make(map[string]bool)
+	var tags = make(map[string]ast.TagOperator)
+	for _, option := range cmd.Options {
+		switch node := option.(type) {
+		case *ast.ExpressionStatement:
+			var key, ok = node.Expr.(*ast.Key)
+			if !ok {
+				continue
+			}
+			var name, single = singleValueKeys[strings.ToLower(key.Key)]
+			if !single {
+				continue
+			}
+			if keys[name] {
+				a.EmitWarning(fmt.Sprintf("The %s key is repeated, the last value is used", name), key)
+			}
+			keys[name] = true
+		case *ast.Tag:
+			var name = strings.ToLower(node.Value)
+			var previous, seen = tags[name]
+			if seen && previous == node.Operator {
+				a.EmitWarning(fmt.Sprintf("The tag %s is repeated", name), node)
+			} else if seen {
+				a.EmitWarning(fmt.Sprintf("The tag %s is both added and removed, the last one is used", name), node)
+			}
+			tags[name] = node.Operator
+		}
 	}
 	return a
 }
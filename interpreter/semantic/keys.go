@@ -0,0 +1,91 @@
+package semantic
+
+import (
+	"strings"
+
+	"github.com/luke-goddard/taskninja/interpreter/lex"
+)
+
+// KeyUsage describes which commands accept a key
+type KeyUsage int
+
+const (
+	KeyUsageInsert KeyUsage = 1 << iota // add and modify e.g add "milk" due:tomorrow
+	KeyUsageFilter                      // list and modify filters e.g list project:home
+)
+
+// KeyDefinition is a key that can be used as an option e.g priority:high
+type KeyDefinition struct {
+	Name        string   // e.g priority
+	Aliases     []string // e.g p
+	Usage       KeyUsage // which commands accept the key
+	Description string   // shown to the user
+}
+
+// Keys is the registry of every key the interpreter understands
+var Keys = []KeyDefinition{
+	{Name: "priority", Aliases: []string{"p"}, Usage: KeyUsageInsert | KeyUsageFilter, Description: "high, medium, low or none"},
+	{Name: "project", Aliases: []string{"proj"}, Usage: KeyUsageInsert | KeyUsageFilter, Description: "the project the task belongs to"},
+	{Name: "deps", Aliases: []string{"dends", "dependencies"}, Usage: KeyUsageInsert, Description: "the task ids this task depends on"},
+	{Name: "due", Usage: KeyUsageInsert, Description: "a date expression e.g tomorrow or eow"},
+	{Name: "recur", Usage: KeyUsageInsert, Description: "how often the task recurs e.g weekly"},
+	{Name: "until", Usage: KeyUsageInsert, Description: "the date a recurring task ends"},
+	{Name: "id", Usage: KeyUsageFilter, Description: "the task id"},
+	{Name: "sort", Usage: KeyUsageFilter, Description: "the order of the tasks e.g due+,priority-"},
+	{Name: "status", Usage: KeyUsageFilter, Description: "pending, completed or all"},
+	{Name: "limit", Usage: KeyUsageFilter, Description: "the maximum number of tasks to list"},
+	{Name: "format", Usage: KeyUsageFilter, Description: "table, json, csv or markdown on the command line"},
+	{Name: "title", Usage: KeyUsageFilter, Description: "words in the title e.g title~invoice"},
+	{Name: "text", Usage: KeyUsageFilter, Description: "words in the title, description or notes e.g text~invoice"},
+}
+
+// LookupKey returns the definition of the key or one of its aliases
+func LookupKey(name string) (*KeyDefinition, bool) {
+	var lower = strings.ToLower(name)
+	for i := range Keys {
+		if Keys[i].Name == lower {
+			return &Keys[i], true
+		}
+		for _, alias := range Keys[i].Aliases {
+			if alias == lower {
+				return &Keys[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// IsReservedKey returns true if the name is a key, an alias or a key whose value is lexed
+// as a single string e.g recur, a user defined attribute with the name would replace it
+func IsReservedKey(name string) bool {
+	var _, ok = LookupKey(name)
+	return ok || lex.IsRawValueKey(name)
+}
+
+// KeyNames returns the name of every key that can be used in the given way
+func KeyNames(usage KeyUsage) []string {
+	var names = make([]string, 0, len(Keys))
+	for _, key := range Keys {
+		if key.Usage&usage != 0 {
+			names = append(names, key.Name)
+		}
+	}
+	return names
+}
+
+// Accepts returns true if the key can be used in the given way
+func (key *KeyDefinition) Accepts(usage KeyUsage) bool {
+	return key.Usage&usage != 0
+}
+
+// keySpellings returns the names and aliases of every key that can be used in the given way
+func keySpellings(usage KeyUsage) []string {
+	var spellings = make([]string, 0, len(Keys))
+	for _, key := range Keys {
+		if key.Accepts(usage) {
+			spellings = append(spellings, key.Name)
+			spellings = append(spellings, key.Aliases...)
+		}
+	}
+	return spellings
+}
@@ -0,0 +1,128 @@
+package semantic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/interpreter/suggest"
+)
+
+// visitOptions checks every key and tag in the nodes, stopping at the first unknown key
+func (a *Analyzer) visitOptions(nodes []ast.Statement, usage KeyUsage) *Analyzer {
+	for _, node := range nodes {
+		if a.visitOption(node, usage) == nil {
+			return nil
+		}
+	}
+	return a
+}
+
+func (a *Analyzer) visitOption(node ast.Node, usage KeyUsage) *Analyzer {
+	switch n := node.(type) {
+	case *ast.ExpressionStatement:
+		return a.visitOption(n.Expr, usage)
+	case *ast.BinaryExpression:
+		if a.visitOption(n.Left, usage) == nil {
+			return nil
+		}
+		return a.visitOption(n.Right, usage)
+	case *ast.LogicalExpression:
+		if a.visitOption(n.Left, usage) == nil {
+			return nil
+		}
+		return a.visitOption(n.Right, usage)
+	case *ast.Key:
+		return a.visitKey(n, usage)
+	case *ast.Tag:
+		return a.visitTag(n, usage)
+	}
+	return a
+}
+
+// visitKey checks that the key exists and can be used by the command e.g prority:high
+func (a *Analyzer) visitKey(key *ast.Key, usage KeyUsage) *Analyzer {
+	var definition, ok = LookupKey(key.Key)
+	var _, searches = ast.SearchKeys[strings.ToLower(key.Key)]
+	if key.Match && !searches {
+		return a.EmitError("The ~ operator can only be used with the title and text keys e.g title~invoice", key)
+	}
+	if !key.Match && searches {
+		return a.EmitError(fmt.Sprintf("The %s key searches with ~ e.g %s~invoice", definition.Name, definition.Name), key)
+	}
+	if key.Match {
+		return a.visitSearchKey(key, definition, usage)
+	}
+	if !ok && slices.Contains(a.udas, strings.ToLower(key.Key)) {
+		return a // User defined attributes can be used to insert and filter
+	}
+	if !ok {
+		var err = manager.NewSemanticError(fmt.Sprintf("Unknown key: %s", key.Key)).SetNode(key)
+		if suggestion, found := suggest.Closest(key.Key, append(keySpellings(usage), a.udas...)); found {
+			err.SetSuggestion(suggestion)
+		}
+		a.errors.Emit(err)
+		return nil
+	}
+	if !definition.Accepts(usage) {
+		if usage == KeyUsageFilter {
+			return a.EmitError(fmt.Sprintf("The %s key cannot be used to filter tasks", definition.Name), key)
+		}
+		return a.EmitError(fmt.Sprintf("The %s key can only be used to filter tasks", definition.Name), key)
+	}
+	return a
+}
+
+// visitSearchKey checks that the key searches for at least one word e.g title~invoice
+func (a *Analyzer) visitSearchKey(key *ast.Key, definition *KeyDefinition, usage KeyUsage) *Analyzer {
+	if !definition.Accepts(usage) {
+		return a.EmitError(fmt.Sprintf("The %s key can only be used to filter tasks", definition.Name), key)
+	}
+	var lit, ok = key.Expr.(*ast.Literal)
+	if !ok || len(db.SearchTerms(lit.Value)) == 0 {
+		return a.EmitError(fmt.Sprintf("The %s key must search for at least one word e.g %s~invoice", definition.Name, definition.Name), key)
+	}
+	return a
+}
+
+// minSuggestedTagLength is the shortest tag that is checked for a misspelling, a single
+// edit turns most short tags into another tag e.g +cd and +ci are both valid tags
+const minSuggestedTagLength = 4
+
+// visitTag warns when the tag does not exist but is a misspelling of one that does
+// e.g +hoem when +home exists, new tags are otherwise created without a warning.
+// Virtual tags e.g +BLOCKED can only be used to filter tasks
+func (a *Analyzer) visitTag(tag *ast.Tag, usage KeyUsage) *Analyzer {
+	if tag.IsVirtual() {
+		if usage != KeyUsageFilter {
+			return a.EmitError(fmt.Sprintf("The virtual tag %s can only be used to filter tasks", tag.Value), tag)
+		}
+		return a
+	}
+	var name = strings.ToLower(tag.Value)
+	for _, known := range a.tags {
+		if known == name {
+			return a
+		}
+	}
+	if utf8.RuneCountInString(name) < minSuggestedTagLength {
+		return a
+	}
+	var suggestion, found = suggest.Closest(name, a.tags)
+	if !found {
+		return a
+	}
+	var operator = "+"
+	if tag.Operator == ast.TagOperatorMinus {
+		operator = "-"
+	}
+	a.errors.Emit(manager.NewSemanticError(fmt.Sprintf("The tag %s does not exist", name)).
+		SetNode(tag).
+		SetSeverityWarning().
+		SetSuggestion(operator + suggestion))
+	return a
+}
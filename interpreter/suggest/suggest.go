@@ -0,0 +1,63 @@
+// Finds the closest match for a misspelt word e.g prority -> priority
+package suggest
+
+import "strings"
+
+// MaxDistance returns how many edits are allowed before a candidate is no longer
+// considered a misspelling, short words only allow a single edit
+func MaxDistance(word string) int {
+	if len(word) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// Closest returns the candidate with the smallest edit distance to the word, false is
+// returned when no candidate is within MaxDistance or the word is already a candidate
+func Closest(word string, candidates []string) (string, bool) {
+	var lower = strings.ToLower(word)
+	var best = ""
+	var bestDistance = MaxDistance(lower) + 1
+	for _, candidate := range candidates {
+		var distance = Distance(lower, strings.ToLower(candidate))
+		if distance == 0 {
+			return "", false
+		}
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best, best != ""
+}
+
+// Distance returns the optimal string alignment distance between a and b, this is the
+// levenshtein distance where swapping two adjacent characters counts as a single edit
+func Distance(a string, b string) int {
+	var ra, rb = []rune(a), []rune(b)
+	var rows = make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			var cost = 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(
+				rows[i-1][j]+1,      // deletion
+				rows[i][j-1]+1,      // insertion
+				rows[i-1][j-1]+cost, // substitution
+			)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1) // transposition
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
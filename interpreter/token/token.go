@@ -34,6 +34,8 @@ const (
 	Equal      TokenType = iota // =
 	Or         TokenType = iota // or
 	And        TokenType = iota // and
+	IdList     TokenType = iota // 1-5,8
+	Tilde      TokenType = iota // title~invoice
 )
 
 // Create a new token of a given type
@@ -105,6 +107,12 @@ func (t *TokenType) String() string {
 	case And:
 		return "And"
 
+	case IdList:
+		return "IdList"
+
+	case Tilde:
+		return "Tilde"
+
 	default:
 		var err = fmt.Errorf("Unknown token type: %d", *t)
 		panic(err)
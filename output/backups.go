@@ -0,0 +1,41 @@
+package output
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// backup is the json of a backup
+type backup struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	CreatedUtc string `json:"createdUtc"`
+	Size       int64  `json:"size"`
+}
+
+// Backups is the result of taskninja backup list
+func Backups(backups []db.Backup) *Result {
+	var result = &Result{
+		Header: []string{"Name", "Created", "Size"},
+		Rows:   make([][]string, 0, len(backups)),
+	}
+	var values = make([]backup, 0, len(backups))
+	for i := range backups {
+		var created = backups[i].CreatedUtc.Format(time.DateTime)
+		result.Rows = append(result.Rows, []string{
+			backups[i].Name(),
+			created,
+			strconv.FormatInt(backups[i].Size, 10),
+		})
+		values = append(values, backup{
+			Name:       backups[i].Name(),
+			Path:       backups[i].Path,
+			CreatedUtc: created,
+			Size:       backups[i].Size,
+		})
+	}
+	result.Value = values
+	return result
+}
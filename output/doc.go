@@ -0,0 +1,2 @@
+// Writes query results on the command line as aligned tables, JSON, CSV or markdown
+package output
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// info is the json of a task with its notes and history
+type info struct {
+	Task        task                `json:"task"`
+	Annotations []db.TaskAnnotation `json:"annotations"`
+	History     []db.TaskChange     `json:"history"`
+}
+
+// Info is the result of info 3, a row for every field of the task followed by
+// a row for every note and every change, the oldest first
+func Info(detailed *db.TaskDetailed, annotations []db.TaskAnnotation, history []db.TaskChange) *Result {
+	var result = &Result{
+		Header: []string{"Name", "Value"},
+		Rows: [][]string{
+			{"ID", strconv.FormatInt(detailed.ID, 10)},
+			{"Title", detailed.Title},
+			{"Status", taskStatus(detailed)},
+			{"Description", detailed.Description.String},
+			{"Project", detailed.ProjectNames.String},
+			{"Tags", detailed.TagNames.String},
+			{"Priority", detailed.PriorityStr()},
+			{"Due", detailed.Due.String},
+			{"Depends on", detailed.Dependencies.String},
+			{"Urgency", detailed.UrgencyStr()},
+		},
+	}
+	for _, annotation := range annotations {
+		result.Rows = append(result.Rows, []string{"Note", fmt.Sprintf("%s  %s", annotation.CreatedUtc, annotation.Text)})
+	}
+	for i := range history {
+		var change = fmt.Sprintf("%s  %s", history[i].ChangedUtc, history[i].String())
+		if history[i].Source != "" {
+			change = fmt.Sprintf("%s (%s)", change, history[i].Source)
+		}
+		result.Rows = append(result.Rows, []string{"Change", change})
+	}
+	result.Value = info{
+		Task: task{
+			TaskDetailed: *detailed,
+			Attributes:   detailed.AttributeValues(),
+			Urgency:      detailed.Urgency(),
+		},
+		Annotations: annotations,
+		History:     history,
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// field is a key of a json object, the fields keep the order of the struct
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object is a json object that keeps the order of its fields
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, field := range o {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		var key, err = json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteString(":")
+		var value []byte
+		value, err = json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// plain returns the value with every nullable field e.g sql.NullString replaced by its
+// value or null, rather than {"String": "", "Valid": false}
+func plain(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return plainValue(reflect.ValueOf(value))
+}
+
+func plainValue(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Struct && value.Type().Implements(valuerType) {
+		var nullable, err = value.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return nil
+		}
+		return nullable
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return plainValue(value.Elem())
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return []interface{}{}
+		}
+		var items = make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = plainValue(value.Index(i))
+		}
+		return items
+	case reflect.Struct:
+		return plainStruct(value, object{})
+	default:
+		return value.Interface()
+	}
+}
+
+// plainStruct adds the exported fields of the struct to the object using their json
+// struct tags, the fields of embedded structs are added as if they belong to the struct
+func plainStruct(value reflect.Value, fields object) object {
+	for i := 0; i < value.NumField(); i++ {
+		var structField = value.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		var tag = structField.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		var name, options, _ = strings.Cut(tag, ",")
+		if structField.Anonymous && name == "" && structField.Type.Kind() == reflect.Struct {
+			fields = plainStruct(value.Field(i), fields)
+			continue
+		}
+		if name == "" {
+			name = structField.Name
+		}
+		if slices.Contains(strings.Split(options, ","), "omitempty") && isEmptyValue(value.Field(i)) {
+			continue
+		}
+		fields = fields.set(name, plainValue(value.Field(i)))
+	}
+	return fields
+}
+
+// isEmptyValue returns true if omitempty leaves out the value, the same values as encoding/json
+// e.g 0, "" or an empty slice
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return value.IsZero()
+	}
+	return false
+}
+
+// set adds the field or replaces the value of a field with the same key, so that a
+// field of the struct replaces a field of the struct it embeds
+func (o object) set(key string, value interface{}) object {
+	for i := range o {
+		if o[i].key == key {
+			o[i].value = value
+			return o
+		}
+	}
+	return append(o, field{key: key, value: value})
+}
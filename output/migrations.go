@@ -0,0 +1,55 @@
+package output
+
+import (
+	"strconv"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// migration is the json of a migration, the up and down functions are left out
+type migration struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Checksum   string `json:"checksum"`
+	Status     string `json:"status"`
+	AppliedUtc string `json:"appliedUtc"`
+}
+
+// Migrations is the result of taskninja db status
+func Migrations(statuses []db.MigrationStatus) *Result {
+	var result = &Result{
+		Header: []string{"ID", "Name", "Status", "Applied"},
+		Rows:   make([][]string, 0, len(statuses)),
+	}
+	var values = make([]migration, 0, len(statuses))
+	for _, status := range statuses {
+		var state = migrationStatus(status)
+		result.Rows = append(result.Rows, []string{
+			strconv.Itoa(status.Migration.ID),
+			status.Migration.Name,
+			state,
+			status.AppliedUtc,
+		})
+		values = append(values, migration{
+			ID:         status.Migration.ID,
+			Name:       status.Migration.Name,
+			Checksum:   status.Migration.Checksum,
+			Status:     state,
+			AppliedUtc: status.AppliedUtc,
+		})
+	}
+	result.Value = values
+	return result
+}
+
+// migrationStatus returns whether the migration has been applied e.g pending
+func migrationStatus(status db.MigrationStatus) string {
+	switch {
+	case status.Changed:
+		return "changed"
+	case status.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
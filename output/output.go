@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is how a query result is written on the command line e.g json
+type Format string
+
+const (
+	FormatTable    Format = "table"    // Aligned columns for people to read
+	FormatJson     Format = "json"     // The value of the result using its json struct tags
+	FormatCsv      Format = "csv"      // Comma separated values with a header row
+	FormatMarkdown Format = "markdown" // A markdown table e.g to paste into an issue
+)
+
+// DefaultFormat is used when neither the command nor the config choose a format
+const DefaultFormat = FormatTable
+
+// Formats contains every format that can be chosen
+var Formats = []Format{FormatTable, FormatJson, FormatCsv, FormatMarkdown}
+
+// ParseFormat returns the format with the name e.g JSON, an empty name is the DefaultFormat
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return DefaultFormat, nil
+	}
+	var lower = Format(strings.ToLower(name))
+	for _, format := range Formats {
+		if format == lower {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown format %s, expected table, json, csv or markdown", name)
+}
+
+// Result is a query result, the text formats write the Header and Rows and the json
+// format encodes the Value using its json struct tags
+type Result struct {
+	Header []string    // The name of every column e.g ID, Title
+	Rows   [][]string  // A cell for every column of every row
+	Value  interface{} // The result before it was turned into rows e.g []db.TaskDetailed
+}
+
+// Write writes the result to out in the format
+func Write(out io.Writer, format Format, result *Result) error {
+	switch format {
+	case FormatTable:
+		return writeTable(out, result)
+	case FormatJson:
+		return writeJson(out, result)
+	case FormatCsv:
+		return writeCsv(out, result)
+	case FormatMarkdown:
+		return writeMarkdown(out, result)
+	default:
+		return fmt.Errorf("Unknown format %s, expected table, json, csv or markdown", format)
+	}
+}
+
+func writeTable(out io.Writer, result *Result) error {
+	var writer = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	var row = func(cells []string) {
+		var cleaned = make([]string, len(cells))
+		for i, cell := range cells {
+			cleaned[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+		}
+		fmt.Fprintln(writer, strings.Join(cleaned, "\t"))
+	}
+	row(result.Header)
+	for _, cells := range result.Rows {
+		row(cells)
+	}
+	return writer.Flush()
+}
+
+func writeJson(out io.Writer, result *Result) error {
+	var encoder = json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	var err = encoder.Encode(plain(result.Value))
+	if err != nil {
+		return fmt.Errorf("Failed to write the result as json: %w", err)
+	}
+	return nil
+}
+
+func writeCsv(out io.Writer, result *Result) error {
+	var writer = csv.NewWriter(out)
+	var err = writer.Write(result.Header)
+	if err == nil {
+		err = writer.WriteAll(result.Rows)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write the result as csv: %w", err)
+	}
+	return nil
+}
+
+func writeMarkdown(out io.Writer, result *Result) error {
+	var escape = strings.NewReplacer("|", `\|`, "\n", "<br>")
+	var row = func(cells []string) string {
+		var escaped = make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = escape.Replace(cell)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+	var divider = make([]string, len(result.Header))
+	for i := range divider {
+		divider[i] = "---"
+	}
+	var builder strings.Builder
+	builder.WriteString(row(result.Header))
+	builder.WriteString(row(divider))
+	for _, cells := range result.Rows {
+		builder.WriteString(row(cells))
+	}
+	var _, err = io.WriteString(out, builder.String())
+	return err
+}
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/luke-goddard/taskninja/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOutput(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Output Suite")
+}
+
+var _ = Describe("Writing query results", func() {
+	var tasks = []db.TaskDetailed{
+		{
+			Task:         db.Task{ID: 1, Title: "fix build", Due: sql.NullString{String: "2024-01-31 00:00:00", Valid: true}},
+			TagNames:     sql.NullString{String: "ci", Valid: true},
+			Attributes:   sql.NullString{String: `{"estimate":"3"}`, Valid: true},
+			ProjectCount: 0,
+		},
+		{
+			Task: db.Task{ID: 2, Title: `say "hi" | wave`, State: db.TaskStateStarted},
+		},
+	}
+
+	var write = func(format Format) string {
+		var out bytes.Buffer
+		Expect(Write(&out, format, Tasks(tasks))).To(Succeed())
+		return out.String()
+	}
+
+	DescribeTable("should parse the format",
+		func(name string, expected Format) {
+			var format, err = ParseFormat(name)
+			Expect(err).To(BeNil())
+			Expect(format).To(Equal(expected))
+		},
+		Entry("the default", "", FormatTable),
+		Entry("json", "json", FormatJson),
+		Entry("upper case", "CSV", FormatCsv),
+		Entry("markdown", "markdown", FormatMarkdown),
+	)
+
+	It("should reject an unknown format", func() {
+		var _, err = ParseFormat("yaml")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should align the columns of a table", func() {
+		var lines = bytes.Split([]byte(write(FormatTable)), []byte("\n"))
+		Expect(string(lines[0])).To(HavePrefix("ID  Title            Status"))
+		Expect(string(lines[1])).To(HavePrefix("1   fix build        pending"))
+	})
+
+	It("should write the json struct tags without the nullable wrappers", func() {
+		var decoded []map[string]interface{}
+		Expect(json.Unmarshal([]byte(write(FormatJson)), &decoded)).To(Succeed())
+		Expect(decoded).To(HaveLen(2))
+		Expect(decoded[0]["title"]).To(Equal("fix build"))
+		Expect(decoded[0]["due"]).To(Equal("2024-01-31 00:00:00"))
+		Expect(decoded[0]["tagNames"]).To(Equal("ci"))
+		Expect(decoded[0]["attributes"]).To(Equal(map[string]interface{}{"estimate": "3"}))
+		Expect(decoded[0]).To(HaveKey("urgency"))
+		Expect(decoded[1]["due"]).To(BeNil())
+		Expect(decoded[1]).To(HaveKeyWithValue("projectNames", BeNil()))
+	})
+
+	It("should leave the search fields out of a plain list", func() {
+		var decoded []map[string]interface{}
+		Expect(json.Unmarshal([]byte(write(FormatJson)), &decoded)).To(Succeed())
+		Expect(decoded[0]).NotTo(HaveKey("relevance"))
+		Expect(decoded[0]).NotTo(HaveKey("snippet"))
+	})
+
+	It("should keep the order of the struct fields in json", func() {
+		var json = write(FormatJson)
+		Expect(json).To(ContainSubstring(`"id": 1,
+    "title": "fix build"`))
+	})
+
+	It("should quote csv values", func() {
+		Expect(write(FormatCsv)).To(ContainSubstring(`2,"say ""hi"" | wave",started`))
+	})
+
+	It("should escape the pipes of markdown cells", func() {
+		var markdown = write(FormatMarkdown)
+		Expect(markdown).To(HavePrefix("| ID | Title | Status |"))
+		Expect(markdown).To(ContainSubstring("| --- | --- |"))
+		Expect(markdown).To(ContainSubstring(`| 2 | say "hi" \| wave | started |`))
+	})
+
+	It("should add the text that matched a search", func() {
+		var matches = []db.TaskDetailed{{Task: db.Task{ID: 1, Title: "pay invoice"}, Snippet: "pay \x02invoice\x03"}}
+		var result = Tasks(matches)
+		Expect(result.Header[len(result.Header)-1]).To(Equal("Match"))
+		Expect(result.Rows[0][len(result.Rows[0])-1]).To(Equal("pay invoice"))
+		Expect(Tasks(tasks).Header).NotTo(ContainElement("Match"))
+
+		var out bytes.Buffer
+		Expect(Write(&out, FormatJson, result)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"snippet": "pay invoice"`))
+	})
+
+	It("should write the notes and history of a task", func() {
+		var history = []db.TaskChange{
+			{Field: "created", NewValue: "fix build", ChangedUtc: "2024-01-01 09:00:00"},
+			{Field: "priority", OldValue: "none", NewValue: "high", Source: "modify 1 priority:high", ChangedUtc: "2024-01-02 09:00:00"},
+		}
+		var result = Info(&tasks[0], []db.TaskAnnotation{{Text: "pinged vendor", CreatedUtc: "2024-01-01 10:00:00"}}, history)
+		Expect(result.Rows[1]).To(Equal([]string{"Title", "fix build"}))
+		Expect(result.Rows).To(ContainElement([]string{"Note", "2024-01-01 10:00:00  pinged vendor"}))
+		Expect(result.Rows[len(result.Rows)-1]).To(Equal([]string{"Change", "2024-01-02 09:00:00  priority: none -> high (modify 1 priority:high)"}))
+
+		var out bytes.Buffer
+		Expect(Write(&out, FormatJson, result)).To(Succeed())
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal(out.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded["task"]).To(HaveKeyWithValue("title", "fix build"))
+		Expect(decoded["history"]).To(HaveLen(2))
+		Expect(decoded["annotations"]).To(HaveLen(1))
+	})
+
+	It("should write an empty list as an empty json array", func() {
+		var out bytes.Buffer
+		Expect(Write(&out, FormatJson, Tasks(nil))).To(Succeed())
+		Expect(out.String()).To(Equal("[]\n"))
+	})
+})
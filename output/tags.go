@@ -0,0 +1,20 @@
+package output
+
+import (
+	"strconv"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// Tags is the result of the tags command, every tag with the number of its tasks
+func Tags(tags []db.TagCount) *Result {
+	var result = &Result{
+		Header: []string{"Tag", "Tasks"},
+		Rows:   make([][]string, 0, len(tags)),
+		Value:  tags,
+	}
+	for _, tag := range tags {
+		result.Rows = append(result.Rows, []string{tag.Name, strconv.Itoa(tag.Count)})
+	}
+	return result
+}
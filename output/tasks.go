@@ -0,0 +1,89 @@
+package output
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// task adds the values of a task that are computed rather than stored to its json
+type task struct {
+	db.TaskDetailed
+	Attributes map[string]string `json:"attributes"` // Replaces the JSON text of the attributes
+	Urgency    float64           `json:"urgency"`
+	Snippet    string            `json:"snippet,omitempty"` // Replaces the snippet without the match markers
+}
+
+// Tasks is the result of a task list e.g list +work, the results of a search
+// e.g search invoice have a column with the text that matched
+func Tasks(tasks []db.TaskDetailed) *Result {
+	var result = &Result{
+		Header: []string{"ID", "Title", "Status", "Project", "Tags", "Priority", "Due", "Urgency", "Notes"},
+		Rows:   make([][]string, 0, len(tasks)),
+	}
+	var matched = false
+	for i := range tasks {
+		matched = matched || tasks[i].Snippet != ""
+	}
+	if matched {
+		result.Header = append(result.Header, "Match")
+	}
+	var values = make([]task, 0, len(tasks))
+	for i := range tasks {
+		var detailed = &tasks[i]
+		var due, _, _ = strings.Cut(detailed.Due.String, " ")
+		var notes = ""
+		if detailed.AnnotationCount > 0 {
+			notes = strconv.Itoa(detailed.AnnotationCount)
+		}
+		var row = []string{
+			strconv.FormatInt(detailed.ID, 10),
+			detailed.Title,
+			taskStatus(detailed),
+			detailed.ProjectNames.String,
+			detailed.TagNames.String,
+			detailed.PriorityStr(),
+			due,
+			detailed.UrgencyStr(),
+			notes,
+		}
+		if matched {
+			row = append(row, db.PlainSnippet(detailed.Snippet))
+		}
+		result.Rows = append(result.Rows, row)
+		values = append(values, task{
+			TaskDetailed: *detailed,
+			Attributes:   detailed.AttributeValues(),
+			Urgency:      detailed.Urgency(),
+			Snippet:      db.PlainSnippet(detailed.Snippet),
+		})
+	}
+	result.Value = values
+	return result
+}
+
+// Annotations is the result of the timeline of notes on a task
+func Annotations(annotations []db.TaskAnnotation) *Result {
+	var result = &Result{
+		Header: []string{"Created", "Note"},
+		Rows:   make([][]string, 0, len(annotations)),
+		Value:  annotations,
+	}
+	for _, annotation := range annotations {
+		result.Rows = append(result.Rows, []string{annotation.CreatedUtc, annotation.Text})
+	}
+	return result
+}
+
+// taskStatus returns the state of the task e.g started
+func taskStatus(task *db.TaskDetailed) string {
+	switch task.State {
+	case db.TaskStateStarted:
+		return "started"
+	case db.TaskStateCompleted:
+		return "completed"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luke-goddard/taskninja/db"
+)
+
+// GetTaskDetailed returns the task including completed tasks, nil if it does not exist
+func (handler *ServiceHandler) GetTaskDetailed(id int64) (*db.TaskDetailed, error) {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	var tasks, err = handler.Store.ListTasksQuery(ctx, &db.TaskQuery{
+		Filter:           "SELECT ?",
+		Args:             []interface{}{id},
+		IncludeCompleted: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get task %d: %w", id, err)
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return &tasks[0], nil
+}
+
+// ListTaskAnnotations returns the timeline of notes on the task, the oldest first
+func (handler *ServiceHandler) ListTaskAnnotations(id int64) ([]db.TaskAnnotation, error) {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	return handler.Store.ListTaskAnnotations(ctx, id)
+}
+
+// ListTaskHistory returns the changes made to the task, the oldest first
+func (handler *ServiceHandler) ListTaskHistory(id int64) ([]db.TaskChange, error) {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	return handler.Store.ListTaskHistory(ctx, id)
+}
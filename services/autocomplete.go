@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+
+	"github.com/luke-goddard/taskninja/interpreter"
+)
+
+// Autocomplete returns the completions for the word under the cursor of a partial program
+func (handler *ServiceHandler) Autocomplete(input string, cursor int) (*interpreter.Completions, error) {
+	if handler.completer == nil {
+		handler.completer = interpreter.NewCompleter(handler.Store).
+			SetReports(handler.Interprete.ReportNames())
+	}
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	return handler.completer.Complete(ctx, input, cursor)
+}
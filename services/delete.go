@@ -1,9 +1,17 @@
 package services
 
-import "context"
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
 
 func (handler *ServiceHandler) DeleteTaskById(id int64) (bool, error) {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.DeleteTaskById(ctx, id)
+	var affected bool
+	var err = handler.journaled(fmt.Sprintf("delete task %d", id), func(tx *sqlx.Tx) error {
+		var err error
+		affected, err = handler.Store.DeleteTaskByIdTx(tx, id)
+		return err
+	})
+	return affected, err
 }
@@ -14,6 +14,9 @@ type ServiceHandler struct {
 	Interprete *interpreter.Interpreter
 	Store      *db.Store
 	Timeout    time.Duration
+	filter     *TaskFilter            // Set by the list command, applied to ListTasks
+	tags       []db.TagCount          // Set by the tags command, see Tags
+	completer  *interpreter.Completer // Created on the first call to Autocomplete
 }
 
 func NewServiceHandler(
@@ -4,28 +4,57 @@ import (
 	"context"
 
 	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
 )
 
+// TaskFilter is the transpiled output of the list command e.g list +work
+// it selects the IDs of the tasks that should be listed
+type TaskFilter struct {
+	Sql  ast.SqlStatement // e.g SELECT tasks.id FROM tasks WHERE ...
+	Args ast.SqlArgs      // Arguments for the placeholders in Sql
+	Sort []db.TaskSortKey // e.g sort:due+, nil uses db.DefaultTaskSort
+
+	Limit            int      // e.g limit:10, 0 lists every task
+	IncludeCompleted bool     // e.g status:completed
+	Report           string   // The name of the report that set the filter e.g next
+	Columns          []string // The columns of the report, nil shows the default columns
+	Search           string   // The text of the search command, ranks and highlights the tasks
+}
+
+// SetFilter will only list tasks matching the filter, nil will list every task
+func (handler *ServiceHandler) SetFilter(filter *TaskFilter) {
+	handler.filter = filter
+}
+
+// Filter returns the filter currently applied to ListTasks (may be nil)
+func (handler *ServiceHandler) Filter() *TaskFilter {
+	return handler.filter
+}
+
 func (handler *ServiceHandler) ListTasks() ([]db.TaskDetailed, error) {
 	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
 	defer cancle()
-	var tasks, err = handler.Store.ListTasks(ctx)
-	if err != nil {
-		return nil, err
+	var filter = handler.filter
+	if filter == nil {
+		filter = &TaskFilter{}
 	}
-	handler.SortTasksByUrgency(tasks)
-	return tasks, nil
-
+	var keys = filter.Sort
+	if keys == nil {
+		keys = db.DefaultTaskSort
+	}
+	return handler.Store.ListTasksQuery(ctx, &db.TaskQuery{
+		Filter:           string(filter.Sql),
+		Args:             filter.Args,
+		Sort:             keys,
+		Limit:            filter.Limit,
+		IncludeCompleted: filter.IncludeCompleted,
+		Search:           filter.Search,
+	})
 }
 
+// SortTasksByUrgency sorts the most urgent tasks first, ties keep their order
 func (handler *ServiceHandler) SortTasksByUrgency(tasks []db.TaskDetailed) {
-	for i := 0; i < len(tasks); i++ {
-		for j := 0; j < len(tasks)-1; j++ {
-			if tasks[j].Urgency() < tasks[j+1].Urgency() {
-				tasks[j], tasks[j+1] = tasks[j+1], tasks[j]
-			}
-		}
-	}
+	db.SortTasks(tasks, db.DefaultTaskSort)
 }
 
 func (handler *ServiceHandler) CountTasks() (int64, error) {
@@ -39,5 +68,3 @@ func (handler *ServiceHandler) GetTaskById(taskId int64) (*db.Task, error) {
 	defer cancle()
 	return handler.Store.GetTaskById(ctx, taskId)
 }
-
-
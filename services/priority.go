@@ -1,25 +1,38 @@
 package services
 
 import (
-	"context"
+	"fmt"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/luke-goddard/taskninja/db"
 )
 
 func (handler *ServiceHandler) IncreasePriority(id int64) (bool, error) {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.IncreasePriority(ctx, id)
+	var applied bool
+	var err = handler.journaled(fmt.Sprintf("increase the priority of task %d", id), func(tx *sqlx.Tx) error {
+		var err error
+		applied, err = handler.Store.IncreasePriorityTx(tx, id)
+		return err
+	})
+	return applied, err
 }
 
 func (handler *ServiceHandler) DecreasePriority(id int64) (bool, error) {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.DecreasePriority(ctx, id)
+	var applied bool
+	var err = handler.journaled(fmt.Sprintf("decrease the priority of task %d", id), func(tx *sqlx.Tx) error {
+		var err error
+		applied, err = handler.Store.DecreasePriorityTx(tx, id)
+		return err
+	})
+	return applied, err
 }
 
 func (handler *ServiceHandler) SetPriority(id int64, priority db.TaskPriority) (bool, error) {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.SetPriority(ctx, id, priority)
+	var applied bool
+	var err = handler.journaled(fmt.Sprintf("set the priority of task %d", id), func(tx *sqlx.Tx) error {
+		var err error
+		applied, err = handler.Store.SetPriorityTx(tx, id, priority)
+		return err
+	})
+	return applied, err
 }
@@ -6,28 +6,84 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/luke-goddard/taskninja/assert"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter"
 	"github.com/luke-goddard/taskninja/interpreter/ast"
 	"github.com/rs/zerolog/log"
 )
 
 func (handler *ServiceHandler) RunProgram(program string) (*ast.Command, error) {
 	log.Info().Msg("Running program")
+	var sql ast.SqlStatement
+	var args ast.SqlArgs
+	var err = handler.journaled(program, func(tx *sqlx.Tx) error {
+		var err error
+		sql, args, err = handler.Interprete.ExecuteTx(program, tx)
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Error executing program")
+		return nil, err
+	}
+	var lastCmd = handler.Interprete.GetLastCmd()
+	assert.NotNil(lastCmd, "last command is nil")
+	if lastCmd.ListsTasks() {
+		handler.applyListCommand(lastCmd, sql, args)
+	}
+	handler.tags = nil
+	if lastCmd.Kind == ast.CommandKindTags {
+		err = handler.countTags(sql, args)
+	}
+	return lastCmd, err
+}
+
+// ExplainProgram runs the program without committing any changes, see interpreter.Explain
+func (handler *ServiceHandler) ExplainProgram(program string) (*interpreter.Explanation, error) {
+	log.Info().Msg("Explaining program")
 	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
 	defer cancle()
 
-	var tx *sqlx.Tx
-	var err error
+	var recorder = db.NewRecorder()
+	var tx, err = handler.Store.Con.BeginTxx(db.RecordStatements(ctx, recorder), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error starting transaction when explaining program: %v", err)
+	}
+	return handler.Interprete.Explain(program, tx, recorder)
+}
 
-	tx, err = handler.Store.Con.BeginTxx(ctx, nil)
+// applyListCommand filters and sorts the task list, list without any options clears the filter.
+// A report keeps its name and columns so the TUI can show which report is active,
+// a search ranks the most relevant tasks first unless it is given a sort
+func (handler *ServiceHandler) applyListCommand(cmd *ast.Command, sql ast.SqlStatement, args ast.SqlArgs) {
+	var report = handler.Interprete.LastReport()
+	var search, _ = cmd.SearchText()
+	if len(cmd.Options) == 0 && report == nil && search == "" {
+		handler.SetFilter(nil)
+		return
+	}
+	var keys, err = cmd.SortKeys(handler.Store.UDAs())
 	if err != nil {
-		return nil, fmt.Errorf("Error starting transaction when running transpiler: %v", err)
+		log.Error().Err(err).Msg("Failed to parse the sort of the list command")
 	}
-	_, _, err = handler.Interprete.Execute(program, tx)
+	if keys == nil && search != "" {
+		keys = db.SearchTaskSort
+	}
+	var limit int
+	limit, err = cmd.Limit()
 	if err != nil {
-		log.Error().Err(err).Msg("Error executing program")
-		return nil, err
+		log.Error().Err(err).Msg("Failed to parse the limit of the list command")
 	}
-	var lastCmd = handler.Interprete.GetLastCmd()
-	assert.NotNil(lastCmd, "last command is nil")
-	return lastCmd, err
+	var filter = &TaskFilter{
+		Sql:              sql,
+		Args:             args,
+		Sort:             keys,
+		Limit:            limit,
+		IncludeCompleted: cmd.IncludesCompleted(),
+		Search:           search,
+	}
+	if report != nil {
+		filter.Report = report.Name
+		filter.Columns = report.Columns
+	}
+	handler.SetFilter(filter)
 }
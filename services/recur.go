@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GenerateMissedRecurrences creates the instances of recurring tasks that became due
+// while the app was closed, returns the number of instances created
+func (handler *ServiceHandler) GenerateMissedRecurrences(now time.Time) (int, error) {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	var tx, err = handler.Store.Con.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("Error starting transaction when generating recurring tasks: %v", err)
+	}
+	defer tx.Rollback()
+	var created int
+	created, err = handler.Store.TemplateGenerateMissedTx(tx, now)
+	if err != nil {
+		return 0, err
+	}
+	return created, tx.Commit()
+}
@@ -1,8 +1,12 @@
 package services_test
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/luke-goddard/taskninja/config"
 	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/interpreter"
 	"github.com/luke-goddard/taskninja/services"
@@ -444,6 +448,361 @@ var _ = Describe("Running a program", func() {
 	})
 })
 
+// ============================================================================
+// LIST FILTER
+// ============================================================================
+var _ = Describe("Running a list program", func() {
+	var services *services.ServiceHandler
+	var work *db.Task
+	var err error
+	BeforeEach(func() {
+		services = newTestHandler()
+		_, _ = services.CreateTask(&db.Task{Title: "cook", Priority: db.TaskPriorityHigh})
+		work, _ = services.CreateTask(&db.Task{Title: "deploy", Priority: db.TaskPriorityHigh})
+		_, _ = services.CreateTask(&db.Task{Title: "clean", Priority: db.TaskPriorityLow})
+		var tagId, _ = services.TagCreate("work")
+		Expect(services.TagLinkTask(tagId, work.ID)).To(Succeed())
+	})
+	It("should only list the tasks matching the filter", func() {
+		_, err = services.RunProgram(`list +work priority:high`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].ID).To(Equal(work.ID))
+	})
+	It("should list every task once the filter is cleared", func() {
+		_, err = services.RunProgram(`list priority:high`)
+		Expect(err).To(BeNil())
+		Expect(services.ListTasks()).To(HaveLen(2))
+
+		_, err = services.RunProgram(`list`)
+		Expect(err).To(BeNil())
+		Expect(services.Filter()).To(BeNil())
+		Expect(services.ListTasks()).To(HaveLen(3))
+	})
+	It("should sort by several keys", func() {
+		_, err = services.RunProgram(`list sort:priority-,title+`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		var titles = []string{}
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		Expect(titles).To(Equal([]string{"cook", "deploy", "clean"}))
+	})
+	It("should sort by urgency in Go after the other keys", func() {
+		_, err = services.RunProgram(fmt.Sprintf("next %d", work.ID))
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`list sort:priority+,urgency-`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(tasks[0].Title).To(Equal("clean"))
+		Expect(tasks[1].ID).To(Equal(work.ID))
+		Expect(tasks[2].Title).To(Equal("cook"))
+	})
+	It("should rank the results of a search and include completed tasks", func() {
+		_, err = services.RunProgram(`annotate 1 "deploy the oven"`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(fmt.Sprintf("done %d", work.ID))
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`search deploy`)
+		Expect(err).To(BeNil())
+		Expect(services.Filter().Search).To(Equal("deploy"))
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].ID).To(Equal(work.ID))
+		Expect(tasks[1].Title).To(Equal("cook"))
+		Expect(tasks[1].Snippet).To(ContainSubstring(db.SnippetMatchStart))
+	})
+	It("should keep the previous filter when the list program is invalid", func() {
+		_, err = services.RunProgram(`list priority:high`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`list priority:urgent`)
+		Expect(err).ToNot(BeNil())
+		Expect(services.ListTasks()).To(HaveLen(2))
+	})
+})
+
+// ============================================================================
+// REPORTS
+// ============================================================================
+var _ = Describe("Running reports", func() {
+	var services *services.ServiceHandler
+	var err error
+	var titles = func(tasks []db.TaskDetailed) []string {
+		var titles = []string{}
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+	BeforeEach(func() {
+		services = newTestHandler()
+		_, _ = services.CreateTask(&db.Task{Title: "cook", Priority: db.TaskPriorityHigh})
+		_, _ = services.CreateTask(&db.Task{Title: "deploy", Priority: db.TaskPriorityMedium})
+		var clean, _ = services.CreateTask(&db.Task{Title: "clean", Priority: db.TaskPriorityLow})
+		Expect(services.CompleteTaskById(clean.ID)).To(BeTrue())
+	})
+	It("should list the completed tasks that are otherwise hidden", func() {
+		Expect(services.ListTasks()).To(HaveLen(2))
+		_, err = services.RunProgram(`report completed`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(titles(tasks)).To(Equal([]string{"clean"}))
+		Expect(services.Filter().Report).To(Equal("completed"))
+		Expect(services.Filter().Columns).To(Equal(config.DefaultReports()["completed"].Columns))
+	})
+	It("should limit the tasks after sorting them", func() {
+		services.Interprete.SetReports(map[string]config.Report{
+			"top": {Sort: "urgency-", Limit: 1},
+		})
+		_, err = services.RunProgram(`report top`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(titles(tasks)).To(Equal([]string{"cook"}))
+		Expect(services.Filter().Report).To(Equal("top"))
+	})
+	It("should limit and include every status from the list command", func() {
+		_, err = services.RunProgram(`list status:all sort:title+ limit:2`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(titles(tasks)).To(Equal([]string{"clean", "cook"}))
+		Expect(services.Filter().Report).To(Equal(""))
+	})
+})
+
+// ============================================================================
+// USER DEFINED ATTRIBUTES
+// ============================================================================
+var _ = Describe("User defined attributes", func() {
+	var services *services.ServiceHandler
+	var err error
+	var titles = func(tasks []db.TaskDetailed) []string {
+		var titles = []string{}
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+	BeforeEach(func() {
+		services = newTestHandler()
+		Expect(services.Store.SetUDAs(map[string]config.UDA{
+			"estimate": {Type: config.UDATypeNumber},
+			"customer": {Urgency: 5},
+		})).To(Succeed())
+		_, err = services.RunProgram(`add "cook" estimate:2`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`add "deploy" estimate:10 customer:acme`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`add "clean" estimate:9.5`)
+		Expect(err).To(BeNil())
+	})
+	It("should add the urgency coefficient of the attributes", func() {
+		_, err = services.RunProgram(`list sort:urgency-,id`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(tasks[0].Title).To(Equal("deploy"))
+		Expect(tasks[0].Urgency() - tasks[1].Urgency()).To(BeNumerically("~", 5, 0.5))
+	})
+	It("should sort the attributes as numbers", func() {
+		_, err = services.RunProgram(`list sort:estimate-`)
+		Expect(err).To(BeNil())
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(titles(tasks)).To(Equal([]string{"deploy", "clean", "cook"}))
+	})
+})
+
+// ============================================================================
+// UNDO
+// ============================================================================
+var _ = Describe("Undoing changes", func() {
+	var services *services.ServiceHandler
+	var err error
+	var titles = func() []string {
+		var tasks, err = services.Store.ListTasksQuery(context.Background(), &db.TaskQuery{
+			IncludeCompleted: true,
+			Sort:             []db.TaskSortKey{{Field: "id"}},
+		})
+		Expect(err).To(BeNil())
+		var titles = []string{}
+		for _, task := range tasks {
+			titles = append(titles, fmt.Sprintf("%s %s", task.Title, task.TagNames.String))
+		}
+		return titles
+	}
+	BeforeEach(func() {
+		services = newTestHandler()
+		_, err = services.RunProgram(`add "cook" +home project:house`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`add "clean" +home`)
+		Expect(err).To(BeNil())
+	})
+	It("should restore a deleted task with its tags, projects and time", func() {
+		Expect(services.StartTimeToggleById(1)).To(Succeed())
+		Expect(services.StopTimeToggleById(1)).To(Succeed())
+		_, err = services.RunProgram(`depends 2 on 1`)
+		Expect(err).To(BeNil())
+		var deleted, err = services.DeleteTaskById(1)
+		Expect(err).To(BeNil())
+		Expect(deleted).To(BeTrue())
+		Expect(titles()).To(Equal([]string{"clean home"}))
+
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		Expect(titles()).To(Equal([]string{"cook home", "clean home"}))
+		var task = services.Store.GetTaskByIdOrPanic(1)
+		Expect(task.Title).To(Equal("cook"))
+		var times, _ = services.GetTaskTimes(1)
+		Expect(times).To(HaveLen(1))
+		var deps, _ = services.GetDependenciesForServices(2)
+		Expect(deps).To(HaveLen(1))
+		var tasks, _ = services.Store.ListTasksFiltered(context.Background(), "SELECT 1")
+		Expect(tasks[0].ProjectNames.String).To(Equal("house"))
+	})
+	It("should walk back through the most recent operations", func() {
+		var _, err = services.CompleteTaskById(2)
+		Expect(err).To(BeNil())
+		_, err = services.IncreasePriority(1)
+		Expect(err).To(BeNil())
+		_, err = services.SetPriority(1, db.TaskPriorityLow)
+		Expect(err).To(BeNil())
+		Expect(services.Store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityLow))
+
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		Expect(services.Store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityLow))
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		Expect(services.Store.GetTaskByIdOrPanic(1).Priority).To(Equal(db.TaskPriorityNone))
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		Expect(services.Store.GetTaskByIdOrPanic(2).State).To(Equal(db.TaskStateIncomplete))
+	})
+	It("should remove the tags created by an added task", func() {
+		_, err = services.RunProgram(`add "shop" +groceries`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		Expect(titles()).To(Equal([]string{"cook home", "clean home"}))
+		var _, tagErr = services.Store.TagGetByName("groceries")
+		Expect(tagErr).NotTo(BeNil())
+	})
+	It("should not record programs that do not change anything", func() {
+		_, err = services.RunProgram(`list +home`)
+		Expect(err).To(BeNil())
+		var operations, _ = services.Store.ListUndoOperations(context.Background())
+		Expect(operations).To(HaveLen(2))
+		Expect(operations[0].Description).To(Equal(`add "clean" +home`))
+	})
+	It("should remove an annotation", func() {
+		_, err = services.RunProgram(`annotate 1 "pinged vendor"`)
+		Expect(err).To(BeNil())
+		var task, _ = services.GetTaskDetailed(1)
+		Expect(task.AnnotationCount).To(Equal(1))
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		var annotations, _ = services.ListTaskAnnotations(1)
+		Expect(annotations).To(BeEmpty())
+	})
+	It("should report when there is nothing to undo", func() {
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`undo`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`undo`)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring(db.ErrNothingToUndo.Error()))
+	})
+})
+
+// ============================================================================
+// RECURRING TASKS
+// ============================================================================
+var _ = Describe("Recurring tasks", func() {
+	var services *services.ServiceHandler
+	var err error
+	var now = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		services = newTestHandler()
+		services.Interprete.SetClock(func() time.Time { return now })
+	})
+	It("should create the next instance when an instance is completed", func() {
+		_, err = services.RunProgram(`add "handover" recur:weekly due:today +oncall project:ops priority:high`)
+		Expect(err).To(BeNil())
+		Expect(services.CompleteTaskById(1)).To(BeTrue())
+
+		var tasks, err = services.ListTasks()
+		Expect(err).To(BeNil())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].Title).To(Equal("handover"))
+		Expect(tasks[0].Due.Value()).To(Equal("2024-01-08 00:00:00"))
+		Expect(tasks[0].Priority).To(Equal(db.TaskPriorityHigh))
+		Expect(tasks[0].TagNames.Value()).To(Equal("oncall"))
+		Expect(tasks[0].ProjectNames.Value()).To(Equal("ops"))
+		Expect(tasks[0].TemplateId).To(Equal(services.Store.GetTaskByIdOrPanic(1).TemplateId))
+	})
+	It("should create the next instance when the done command is run", func() {
+		_, err = services.RunProgram(`add "bump deps" recur:2w`)
+		Expect(err).To(BeNil())
+		_, err = services.RunProgram(`done 1`)
+		Expect(err).To(BeNil())
+		Expect(services.Store.GetTaskByIdOrPanic(2).Due.Value()).To(Equal("2024-01-15 09:00:00"))
+	})
+	It("should stop once the until date has passed", func() {
+		_, err = services.RunProgram(`add "handover" recur:weekly due:today until:2024-01-05`)
+		Expect(err).To(BeNil())
+		Expect(services.CompleteTaskById(1)).To(BeTrue())
+		Expect(services.ListTasks()).To(HaveLen(0))
+	})
+	It("should generate the missed periods", func() {
+		_, err = services.RunProgram(`add "standup" recur:daily due:today`)
+		Expect(err).To(BeNil())
+		var created int
+		created, err = services.GenerateMissedRecurrences(now.AddDate(0, 0, 3))
+		Expect(err).To(BeNil())
+		Expect(created).To(Equal(3))
+		Expect(services.ListTasks()).To(HaveLen(4))
+
+		created, err = services.GenerateMissedRecurrences(now.AddDate(0, 0, 3))
+		Expect(err).To(BeNil())
+		Expect(created).To(Equal(0))
+	})
+	It("should only generate the latest missed periods", func() {
+		_, err = services.RunProgram(`add "standup" recur:daily due:today`)
+		Expect(err).To(BeNil())
+		var created int
+		created, err = services.GenerateMissedRecurrences(now.AddDate(0, 3, 0))
+		Expect(err).To(BeNil())
+		Expect(created).To(Equal(db.MaxMissedInstances))
+		Expect(services.ListTasks()).To(HaveLen(db.MaxMissedInstances + 1))
+		var latest = services.Store.GetTaskByIdOrPanic(int64(db.MaxMissedInstances + 1))
+		Expect(latest.Due.Value()).To(Equal("2024-04-01 00:00:00"))
+	})
+	It("should reject an until date before the due date", func() {
+		_, err = services.RunProgram(`add "handover" recur:weekly due:2024-02-01 until:2024-01-15`)
+		Expect(err).To(MatchError(ContainSubstring("The until date cannot be before the due date")))
+	})
+	It("should reject an unknown pattern", func() {
+		_, err = services.RunProgram(`add "handover" recur:sometimes`)
+		Expect(err).ToNot(BeNil())
+		Expect(services.ListTasks()).To(HaveLen(0))
+	})
+	It("should reject until without recur", func() {
+		_, err = services.RunProgram(`add "handover" until:eoy`)
+		Expect(err).ToNot(BeNil())
+	})
+})
+
 // ============================================================================
 // TASK START
 // ============================================================================
@@ -1,15 +1,21 @@
 package services
 
-import "context"
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
 
 func (handler *ServiceHandler) StartTimeToggleById(id int64) error {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.StartTrackingTaskTime(ctx, id)
+	return handler.journaled(fmt.Sprintf("start task %d", id), func(tx *sqlx.Tx) error {
+		var _, err = handler.Store.StartTrackingTaskTimeTx(tx, id)
+		return err
+	})
 }
 
 func (handler *ServiceHandler) StopTimeToggleById(id int64) error {
-	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
-	defer cancle()
-	return handler.Store.StopTrackingTaskTime(ctx, id)
+	return handler.journaled(fmt.Sprintf("stop task %d", id), func(tx *sqlx.Tx) error {
+		var _, err = handler.Store.StopTrackingTaskTimeTx(tx, id)
+		return err
+	})
 }
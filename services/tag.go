@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/interpreter/ast"
 )
 
 // CreateTag will create a new tag in the database (this should not exist)
@@ -24,5 +25,22 @@ func (handler *ServiceHandler) TagList() ([]db.Tag, error) {
 func (handler *ServiceHandler) TagLinkTask(tagId, taskId int64) error {
 	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
 	defer cancle()
-	return handler.Store.TagLinkTaskCtx(ctx, tagId, taskId)
+	return handler.Store.TagLinkTaskCtx(ctx, taskId, tagId)
+}
+
+// Tags returns the tags listed by the last tags program, empty for every other program
+func (handler *ServiceHandler) Tags() []db.TagCount {
+	return handler.tags
+}
+
+// countTags runs the query of the tags command, see Tags
+func (handler *ServiceHandler) countTags(sql ast.SqlStatement, args ast.SqlArgs) error {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	var tags, err = handler.Store.TagCountsQuery(ctx, string(sql), args)
+	if err != nil {
+		return err
+	}
+	handler.tags = tags
+	return nil
 }
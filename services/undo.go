@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// journaled runs fn inside a transaction that is recorded in the undo journal as a
+// single operation, the undo command reverts the most recent operation
+func (handler *ServiceHandler) journaled(description string, fn func(tx *sqlx.Tx) error) error {
+	var ctx, cancle = context.WithDeadline(context.Background(), handler.timeout())
+	defer cancle()
+	var tx, err = handler.Store.Con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Error starting transaction when running %s: %v", description, err)
+	}
+	defer tx.Rollback()
+	err = handler.Store.BeginUndoOperationTx(tx, description)
+	if err != nil {
+		return err
+	}
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	err = handler.Store.EndUndoOperationTx(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
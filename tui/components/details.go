@@ -0,0 +1,125 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/luke-goddard/taskninja/assert"
+	"github.com/luke-goddard/taskninja/bus"
+	"github.com/luke-goddard/taskninja/db"
+	"github.com/luke-goddard/taskninja/events"
+)
+
+var (
+	detailsTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(highlightColor)
+	detailsLabelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// TaskDetails is the panel below the task list that shows everything about the selected
+// task including the timeline of its notes and its history, i toggles the panel and
+// info 3 opens it for a task
+type TaskDetails struct {
+	ID          int64               // The task that is shown, NOID when the panel is hidden
+	Task        *db.TaskDetailed    // nil until the details are received
+	Annotations []db.TaskAnnotation // The notes on the task, the oldest first
+	History     []db.TaskChange     // The changes made to the task, the oldest first
+	bus         *bus.Bus
+}
+
+func NewTaskDetails(bus *bus.Bus) *TaskDetails {
+	assert.NotNil(bus, "bus is nil")
+	return &TaskDetails{ID: NOID, bus: bus}
+}
+
+func (m TaskDetails) Init() tea.Cmd { return nil }
+
+func (m *TaskDetails) Notify(e *events.Event) {
+	// Little adapter to allow tea's interface to be compatible with the bus
+	m.Update(e)
+}
+
+// Show opens the panel and asks for the details of the task
+func (m *TaskDetails) Show(id int64) {
+	m.ID = id
+	m.bus.Publish(events.NewTaskDetailsEvent(id))
+}
+
+// Hide closes the panel
+func (m *TaskDetails) Hide() {
+	m.ID = NOID
+	m.Task = nil
+	m.Annotations = nil
+	m.History = nil
+}
+
+// Visible returns true while the panel is open
+func (m *TaskDetails) Visible() bool {
+	return m.ID != NOID
+}
+
+func (m *TaskDetails) Update(msg tea.Msg) (*TaskDetails, tea.Cmd) {
+	var e, ok = msg.(*events.Event)
+	if !ok {
+		return m, nil
+	}
+	if e.Type == events.EventOpenTaskDetails {
+		m.Show(events.DecodeOpenTaskDetailsEvent(e).ID)
+		return m, nil
+	}
+	if !m.Visible() {
+		return m, nil
+	}
+	switch e.Type {
+	case events.EventListTaskResponse:
+		// The task may have been changed e.g annotate 1 "pinged vendor"
+		m.bus.Publish(events.NewTaskDetailsEvent(m.ID))
+	case events.EventTaskDetailsResponse:
+		var details = events.DecodeTaskDetailsResponseEvent(e)
+		if details.ID != m.ID {
+			break
+		}
+		if details.Task == nil {
+			m.Hide()
+			break
+		}
+		m.Task = details.Task
+		m.Annotations = details.Annotations
+		m.History = details.History
+	}
+	return m, nil
+}
+
+func (m TaskDetails) View() string {
+	if m.Task == nil {
+		return ""
+	}
+	var task = m.Task
+	var document strings.Builder
+	var field = func(label string, value string) {
+		if value != "" {
+			document.WriteString(fmt.Sprintf("%s %s\n", detailsLabelStyle.Render(label), value))
+		}
+	}
+	document.WriteString(detailsTitleStyle.Render(fmt.Sprintf("Task %d: %s", task.ID, task.Title)) + "\n")
+	field("Description:", task.Description.String)
+	field("Project:", task.ProjectNames.String)
+	field("Tags:", task.TagNames.String)
+	field("Due:", dueDate(task))
+	field("Depends on:", task.Dependencies.String)
+	field("Urgency:", task.UrgencyStr())
+	document.WriteString(detailsTitleStyle.Render(fmt.Sprintf("Notes (%d)", len(m.Annotations))) + "\n")
+	for _, annotation := range m.Annotations {
+		document.WriteString(fmt.Sprintf("%s %s\n", detailsLabelStyle.Render(annotation.CreatedUtc), annotation.Text))
+	}
+	document.WriteString(detailsTitleStyle.Render(fmt.Sprintf("History (%d)", len(m.History))) + "\n")
+	for i := range m.History {
+		var change = m.History[i].String()
+		if m.History[i].Source != "" {
+			change = fmt.Sprintf("%s %s", change, detailsLabelStyle.Render("("+m.History[i].Source+")"))
+		}
+		document.WriteString(fmt.Sprintf("%s %s\n", detailsLabelStyle.Render(m.History[i].ChangedUtc), change))
+	}
+	return document.String()
+}
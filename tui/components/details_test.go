@@ -0,0 +1,62 @@
+package components
+
+import (
+	"github.com/luke-goddard/taskninja/bus"
+	"github.com/luke-goddard/taskninja/bus/handler"
+	"github.com/luke-goddard/taskninja/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Task Details", func() {
+	var details *TaskDetails
+	var bus_ *bus.Bus
+
+	BeforeEach(func() {
+		bus_ = bus.NewBus()
+		bus_.Subscribe(handler.NewEventHandler(newTestHandler(), bus_))
+		details = NewTaskDetails(bus_)
+		bus_.Subscribe(details)
+		bus_.Publish(events.NewRunProgramEvent(`add "call vendor" +work`))
+		bus_.Publish(events.NewRunProgramEvent(`annotate 1 "pinged vendor"`))
+	})
+	It("should be hidden until a task is shown", func() {
+		Expect(details.Visible()).To(BeFalse())
+		Expect(details.View()).To(BeEmpty())
+	})
+	It("should show the task and its notes", func() {
+		details.Show(1)
+		Expect(details.Task).NotTo(BeNil())
+		Expect(details.Task.AnnotationCount).To(Equal(1))
+		Expect(details.Annotations).To(HaveLen(1))
+		Expect(details.View()).To(ContainSubstring("call vendor"))
+		Expect(details.View()).To(ContainSubstring("pinged vendor"))
+	})
+	It("should refresh the notes when the task changes", func() {
+		details.Show(1)
+		bus_.Publish(events.NewRunProgramEvent(`annotate 1 "blocked on review"`))
+		Expect(details.Annotations).To(HaveLen(2))
+		Expect(details.Annotations[1].Text).To(Equal("blocked on review"))
+	})
+	It("should show the history of the task", func() {
+		bus_.Publish(events.NewRunProgramEvent(`modify 1 priority:high`))
+		details.Show(1)
+		Expect(details.History).To(HaveLen(3))
+		Expect(details.History[2].String()).To(Equal("priority: none -> high"))
+		Expect(details.View()).To(ContainSubstring("History (3)"))
+		Expect(details.View()).To(ContainSubstring("modify 1 priority:high"))
+	})
+	It("should open for the task of the info command", func() {
+		bus_.Publish(events.NewRunProgramEvent(`add "write docs"`))
+		bus_.Publish(events.NewRunProgramEvent(`info 2`))
+		Expect(details.Visible()).To(BeTrue())
+		Expect(details.Task).NotTo(BeNil())
+		Expect(details.Task.Title).To(Equal("write docs"))
+		Expect(details.History).To(HaveLen(1))
+	})
+	It("should hide when the task is deleted", func() {
+		details.Show(1)
+		bus_.Publish(events.NewDeleteTaskByIdEvent(1))
+		Expect(details.Visible()).To(BeFalse())
+	})
+})
@@ -0,0 +1,90 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/luke-goddard/taskninja/assert"
+	"github.com/luke-goddard/taskninja/bus"
+	"github.com/luke-goddard/taskninja/events"
+)
+
+const NOREPORT int = -1
+
+var (
+	inactiveReportStyle = lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("240"))
+	activeReportStyle   = lipgloss.NewStyle().Padding(0, 1).Bold(true).Foreground(highlightColor).Underline(true)
+)
+
+// Reports is the bar above the task list that switches between the named reports,
+// [ and ] run the previous and next report
+type Reports struct {
+	Names  []string // e.g next, active, waiting
+	Active int      // Index of the report that selected the task list, NOREPORT if there is none
+	bus    *bus.Bus
+}
+
+func NewReports(names []string, bus *bus.Bus) *Reports {
+	assert.NotNil(bus, "bus is nil")
+	return &Reports{Names: names, Active: NOREPORT, bus: bus}
+}
+
+func (m Reports) Init() tea.Cmd { return nil }
+
+func (m *Reports) Notify(e *events.Event) {
+	// Little adapter to allow tea's interface to be compatible with the bus
+	m.Update(e)
+}
+
+func (m *Reports) Update(msg tea.Msg) (*Reports, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if len(m.Names) == 0 {
+			return m, nil
+		}
+		switch msg.String() {
+		case "]":
+			m.run((m.Active + 1) % len(m.Names))
+		case "[":
+			if m.Active <= 0 {
+				m.run(len(m.Names) - 1)
+			} else {
+				m.run(m.Active - 1)
+			}
+		}
+	case *events.Event:
+		if msg.Type == events.EventListTaskResponse {
+			m.Active = m.indexOf(events.DecodeListTasksResponseEvent(msg).Report)
+		}
+	}
+	return m, nil
+}
+
+// run selects the report and asks the interpreter to list its tasks
+func (m *Reports) run(index int) {
+	m.Active = index
+	m.bus.Publish(events.NewRunProgramEvent(fmt.Sprintf("report %s", m.Names[index])))
+}
+
+func (m *Reports) indexOf(name string) int {
+	for i, report := range m.Names {
+		if report == name {
+			return i
+		}
+	}
+	return NOREPORT
+}
+
+func (m Reports) View() string {
+	var rendered = make([]string, 0, len(m.Names))
+	for i, name := range m.Names {
+		if i == m.Active {
+			rendered = append(rendered, activeReportStyle.Render(name))
+		} else {
+			rendered = append(rendered, inactiveReportStyle.Render(name))
+		}
+	}
+	return strings.Join(rendered, "│")
+}
@@ -0,0 +1,41 @@
+package components
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/luke-goddard/taskninja/bus"
+	"github.com/luke-goddard/taskninja/events"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reports", func() {
+	var reports *Reports
+	var sub *SubscriberMock
+
+	BeforeEach(func() {
+		var bus_ = bus.NewBus()
+		sub = &SubscriberMock{}
+		bus_.Subscribe(sub)
+		reports = NewReports([]string{"next", "active", "completed"}, bus_)
+	})
+	It("should not have an active report", func() {
+		Expect(reports.Active).To(Equal(NOREPORT))
+	})
+	It("should run the first report when pressing ']'", func() {
+		reports.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}})
+		Expect(reports.Active).To(Equal(0))
+		var event = sub.GetEventOfType(events.EventRunProgram)
+		Expect(event).NotTo(BeNil())
+		Expect(event.Data.(*events.RunProgram).Program).To(Equal("report next"))
+	})
+	It("should wrap around to the last report when pressing '['", func() {
+		reports.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'['}})
+		Expect(reports.Active).To(Equal(2))
+	})
+	It("should highlight the report that listed the tasks", func() {
+		reports.Update(events.NewListReportResponse(nil, "active", nil))
+		Expect(reports.Active).To(Equal(1))
+		reports.Update(events.NewListTasksResponse(nil))
+		Expect(reports.Active).To(Equal(NOREPORT))
+	})
+})
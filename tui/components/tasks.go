@@ -2,6 +2,7 @@ package components
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -30,8 +31,31 @@ const (
 	TableColumnTags
 	TableColumnDependencies
 	TableColumnUrgency
+	TableColumnDue
+	TableColumnNotes
+	TableColumnMatch
 )
 
+// TableColumnNames maps the name of a column in a report to its index e.g columns: [id, title, due]
+var TableColumnNames = map[string]int{
+	"id":       TableColumnID,
+	"started":  TableColumnStarted,
+	"title":    TableColumnName,
+	"age":      TableColumnAge,
+	"priority": TableColumnPriority,
+	"project":  TableColumnProject,
+	"tags":     TableColumnTags,
+	"deps":     TableColumnDependencies,
+	"urgency":  TableColumnUrgency,
+	"due":      TableColumnDue,
+	"notes":    TableColumnNotes,
+	"match":    TableColumnMatch,
+}
+
+// DefaultTableColumns are shown when the report does not choose its columns,
+// the match column is added to the results of a search e.g search invoice
+var DefaultTableColumns = []string{"id", "started", "title", "age", "priority", "project", "tags", "deps", "urgency"}
+
 type TaskTable struct {
 	Table                 table.Model
 	baseStyle             lipgloss.Style
@@ -41,6 +65,8 @@ type TaskTable struct {
 	fuzzyFilter           string
 	TaskIdsMatchingFilter []int64
 	tableStyle            table.Styles
+	widths                []int    // The width of every column when it is shown
+	columns               []string // The names of the columns that are shown, see SetColumns
 }
 
 type TaskRow table.Row
@@ -66,6 +92,13 @@ func NewTaskTable(baseStyle lipgloss.Style, dimensions *utils.TerminalDimensions
 		{Title: "Tags", Width: dimensions.Width.PercentOrMin(0.08, 0)},
 		{Title: "Deps", Width: dimensions.Width.PercentOrMin(0.06, 0)},
 		{Title: "Urgency", Width: dimensions.Width.PercentOrMin(0.21, 0)},
+		{Title: "Due", Width: dimensions.Width.PercentOrMin(0.1, 0)},
+		{Title: "Notes", Width: dimensions.Width.PercentOrMin(0.05, 0)},
+		{Title: "Match", Width: dimensions.Width.PercentOrMin(0.3, 0)},
+	}
+	var widths = make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = column.Width
 	}
 
 	var rows = []table.Row{}
@@ -90,7 +123,7 @@ func NewTaskTable(baseStyle lipgloss.Style, dimensions *utils.TerminalDimensions
 		Bold(true)
 
 	tbl.SetStyles(style)
-	return &TaskTable{
+	var taskTable = &TaskTable{
 		Table:                 tbl,
 		baseStyle:             baseStyle,
 		bus:                   bus,
@@ -98,7 +131,47 @@ func NewTaskTable(baseStyle lipgloss.Style, dimensions *utils.TerminalDimensions
 		fuzzyFilter:           "",
 		TaskIdsMatchingFilter: []int64{},
 		tableStyle:            style,
+		widths:                widths,
 	}
+	taskTable.SetColumns(DefaultTableColumns)
+	return taskTable
+}
+
+// SetColumns only shows the named columns e.g id, title, due, unknown names are ignored
+// and nil shows DefaultTableColumns. The width of the hidden columns is given to the title
+func (m *TaskTable) SetColumns(names []string) {
+	if len(names) == 0 {
+		names = DefaultTableColumns
+	}
+	var visible = make(map[int]bool)
+	for _, name := range names {
+		if index, ok := TableColumnNames[strings.ToLower(name)]; ok {
+			visible[index] = true
+		}
+	}
+	var total, used = 0, 0
+	var columns = m.Table.Columns()
+	for i := range columns {
+		// Only the default columns share the width, due, notes and match are extra
+		if i < TableColumnDue {
+			total += m.widths[i]
+		}
+		columns[i].Width = 0
+		if visible[i] {
+			columns[i].Width = m.widths[i]
+			used += m.widths[i]
+		}
+	}
+	if visible[TableColumnName] && total > used {
+		columns[TableColumnName].Width += total - used
+	}
+	m.Table.SetColumns(columns)
+	m.columns = names
+}
+
+// Columns returns the names of the columns that are shown
+func (m *TaskTable) Columns() []string {
+	return m.columns
 }
 
 func (m *TaskTable) Notify(e *events.Event) {
@@ -111,6 +184,11 @@ func (m *TaskTable) Update(msg tea.Msg) (*TaskTable, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "u" {
+			// Undo also works on an empty table e.g after deleting the last task
+			m.bus.Publish(events.NewRunProgramEvent("undo"))
+			break
+		}
 		var id = m.GetIdForCurrentRow()
 		if id == NOID {
 			break
@@ -191,6 +269,17 @@ func (m *TaskTable) handleListTasksResponse(e *events.ListTasksResponse) {
 	var ids = []int64{}
 	var index = 0
 
+	var visible = e.Columns
+	if len(visible) == 0 {
+		visible = DefaultTableColumns
+	}
+	if matched(e.Tasks) && !slices.Contains(visible, "match") {
+		visible = append(slices.Clone(visible), "match")
+	}
+	if !slices.Equal(m.columns, visible) {
+		m.SetColumns(visible)
+	}
+
 	for _, task := range e.Tasks {
 		if m.fuzzyFilter != "" {
 			if !fuzzy.MatchFold(m.fuzzyFilter, task.Title) {
@@ -228,9 +317,12 @@ func (m *TaskTable) handleListTasksResponse(e *events.ListTasksResponse) {
 		columns = append(columns, task.AgeStr())            // AGE
 		columns = append(columns, priority)                 // PRIORITY
 		columns = append(columns, task.ProjectNames.String) // PROJECT
-		columns = append(columns, "")                       // TAGS
+		columns = append(columns, task.TagNames.String)     // TAGS
 		columns = append(columns, task.Dependencies.String) // DEPENDENCIES
 		columns = append(columns, urgency)                  // URGENCY
+		columns = append(columns, dueDate(&task))           // DUE
+		columns = append(columns, notes(&task))             // NOTES
+		columns = append(columns, m.snippet(&task))         // MATCH
 
 		index++
 		rows = append(rows, columns)
@@ -239,6 +331,51 @@ func (m *TaskTable) handleListTasksResponse(e *events.ListTasksResponse) {
 	m.Table.SetRows(rows)
 }
 
+// dueDate returns the date the task is due without the time e.g 2024-01-31
+func dueDate(task *db.TaskDetailed) string {
+	if !task.Due.Valid {
+		return ""
+	}
+	var date, _, _ = strings.Cut(task.Due.String, " ")
+	date, _, _ = strings.Cut(date, "T")
+	return date
+}
+
+// notes returns the number of notes on the task, empty if there are none
+func notes(task *db.TaskDetailed) string {
+	if task.AnnotationCount == 0 {
+		return ""
+	}
+	return strconv.Itoa(task.AnnotationCount)
+}
+
+// matched returns true if the tasks are the results of a search e.g search invoice
+func matched(tasks []db.TaskDetailed) bool {
+	for i := range tasks {
+		if tasks[i].Snippet != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns the text of the task that matched the search with the matching words highlighted
+func (m *TaskTable) snippet(task *db.TaskDetailed) string {
+	var highlight = lipgloss.NewStyle().Foreground(m.theme.PrimaryColor).Bold(true)
+	var text = strings.ReplaceAll(task.Snippet, "\n", " ")
+	var snippet strings.Builder
+	for {
+		var before, rest, found = strings.Cut(text, db.SnippetMatchStart)
+		snippet.WriteString(before)
+		if !found {
+			return snippet.String()
+		}
+		var word string
+		word, text, _ = strings.Cut(rest, db.SnippetMatchEnd)
+		snippet.WriteString(highlight.Render(word))
+	}
+}
+
 func (m *TaskTable) handleFuzzySearchResponse(e *events.TableFuzzySearch) {
 	m.fuzzyFilter = e.Match
 	m.Table.SetCursor(0)
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/luke-goddard/taskninja/bus"
 	"github.com/luke-goddard/taskninja/bus/handler"
+	"github.com/luke-goddard/taskninja/config"
 	"github.com/luke-goddard/taskninja/db"
 	"github.com/luke-goddard/taskninja/events"
 	"github.com/luke-goddard/taskninja/interpreter"
@@ -137,6 +138,12 @@ var _ = Describe("Task Table", func() {
 			table.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
 			Expect(sub.HasEventOfType(events.EventDeleteTaskById)).To(BeTrue())
 		})
+		It("Pressing u should undo the last change", func() {
+			table.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+			Expect(table.Table.Rows()).To(HaveLen(1))
+			table.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+			Expect(table.Table.Rows()).To(HaveLen(2))
+		})
 		It("Pressing d should complete the selected row", func() {
 			table.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
 			Expect(sub.HasEventOfType(events.EventCompleteTaskById)).To(BeTrue())
@@ -235,4 +242,46 @@ var _ = Describe("Task Table", func() {
 			Expect(row.PriorityStr()).To(Equal("❌"))
 		})
 	})
+
+	Describe("When a report chooses the columns", func() {
+		BeforeEach(func() {
+			service.Interprete.SetReports(map[string]config.Report{
+				"due": {Columns: []string{"id", "title", "due"}},
+			})
+			bus_.Publish(events.NewRunProgramEvent(`add "T1" due:tomorrow`))
+			bus_.Publish(events.NewRunProgramEvent(`report due`))
+		})
+		It("should only show the columns of the report", func() {
+			Expect(table.Columns()).To(Equal([]string{"id", "title", "due"}))
+			Expect(table.Table.Columns()[TableColumnUrgency].Width).To(Equal(0))
+			Expect(table.Table.Columns()[TableColumnDue].Width).To(BeNumerically(">", 0))
+			Expect(table.GetCurrentRow()[TableColumnDue]).NotTo(BeEmpty())
+		})
+		It("should show the default columns again after listing", func() {
+			bus_.Publish(events.NewRunProgramEvent(`list`))
+			Expect(table.Columns()).To(Equal(DefaultTableColumns))
+			Expect(table.Table.Columns()[TableColumnDue].Width).To(Equal(0))
+		})
+	})
+
+	Describe("When searching every task", func() {
+		BeforeEach(func() {
+			bus_.Publish(events.NewRunProgramEvent(`add "T1"`))
+			bus_.Publish(events.NewRunProgramEvent(`add "T2"`))
+			bus_.Publish(events.NewRunProgramEvent(`annotate 2 "the old ticket"`))
+			bus_.Publish(events.NewRunProgramEvent(`search ticket`))
+		})
+		It("should show the text that matched", func() {
+			Expect(table.Table.Rows()).To(HaveLen(1))
+			Expect(table.Columns()).To(ContainElement("match"))
+			Expect(table.Table.Columns()[TableColumnMatch].Width).To(BeNumerically(">", 0))
+			Expect(table.GetCurrentRow()[TableColumnMatch]).To(ContainSubstring("ticket"))
+			Expect(table.GetCurrentRow()[TableColumnMatch]).NotTo(ContainSubstring(db.SnippetMatchStart))
+		})
+		It("should hide the match column after listing", func() {
+			bus_.Publish(events.NewRunProgramEvent(`list`))
+			Expect(table.Columns()).To(Equal(DefaultTableColumns))
+			Expect(table.Table.Columns()[TableColumnMatch].Width).To(Equal(0))
+		})
+	})
 })
@@ -1,18 +1,25 @@
 package components
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/luke-goddard/taskninja/assert"
 	"github.com/luke-goddard/taskninja/bus"
 	"github.com/luke-goddard/taskninja/events"
+	"github.com/luke-goddard/taskninja/interpreter/manager"
+	"github.com/luke-goddard/taskninja/output"
 	"github.com/luke-goddard/taskninja/tui/utils"
 	"github.com/rs/zerolog/log"
 )
 
 const RINGBUFFER_SIZE = 30
+const COMPLETION_POPUP_SIZE = 8 // How many completions are visible at once
 
 type InputMode int
 
@@ -23,13 +30,18 @@ const (
 )
 
 type TextInput struct {
-	dimensions *utils.TerminalDimensions
-	txtInput   textinput.Model
-	enabled    bool
-	bus        *bus.Bus
-	err        *error
-	history    *InputHistoryRingBuffer
-	inputMode  InputMode
+	dimensions  *utils.TerminalDimensions
+	theme       *utils.Theme
+	txtInput    textinput.Model
+	enabled     bool
+	bus         *bus.Bus
+	err         *error
+	explanation *string // Set by explain e.g explain add "milk" and by tags
+
+	completions     *events.AutocompleteResponse // Open popup, nil when closed
+	completionIndex int                          // The selected completion
+	history         *InputHistoryRingBuffer
+	inputMode       InputMode
 }
 
 func (t *TextInput) Enable() {
@@ -52,8 +64,9 @@ func (t *TextInput) CanQuit() bool {
 	return t.Disabled()
 }
 
-func NewTextInput(dimensions *utils.TerminalDimensions, bus *bus.Bus) *TextInput {
+func NewTextInput(dimensions *utils.TerminalDimensions, theme *utils.Theme, bus *bus.Bus) *TextInput {
 	assert.NotNil(dimensions, "TerminalDimensions is nil")
+	assert.NotNil(theme, "Theme is nil")
 	assert.NotNil(bus, "Bus is nil")
 	var txtIn = textinput.New()
 	txtIn.Placeholder = "Type here..."
@@ -61,6 +74,7 @@ func NewTextInput(dimensions *utils.TerminalDimensions, bus *bus.Bus) *TextInput
 	txtIn.Width = dimensions.Width.Percent(0.95)
 	return &TextInput{
 		dimensions: dimensions,
+		theme:      theme,
 		txtInput:   txtIn,
 		enabled:    false,
 		bus:        bus,
@@ -69,12 +83,33 @@ func NewTextInput(dimensions *utils.TerminalDimensions, bus *bus.Bus) *TextInput
 	}
 }
 
+// OpenCommand enables the input in command mode with the value already typed
+func (t *TextInput) OpenCommand(value string) {
+	t.inputMode = InputModeCmd
+	t.ClearErr()
+	t.Enable()
+	t.txtInput.Focus()
+	t.txtInput.SetValue(value)
+}
+
 func (t *TextInput) Update(msg tea.Msg) (*TextInput, tea.Cmd) {
 	var cmd tea.Cmd
 	var enabled = t.Enabled()
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if enabled && t.inputMode == InputModeCmd {
+			switch msg.Type {
+			case tea.KeyTab:
+				t.cycleCompletion(1)
+				return t, cmd
+			case tea.KeyShiftTab:
+				t.cycleCompletion(-1)
+				return t, cmd
+			}
+		}
+		t.closeCompletions()
+
 		switch msg.Type {
 		case tea.KeyEnter:
 			t.ClearErr()
@@ -105,11 +140,12 @@ func (t *TextInput) Update(msg tea.Msg) (*TextInput, tea.Cmd) {
 		switch msg.String() {
 		case "a":
 			if !enabled {
-				t.inputMode = InputModeCmd
-				t.ClearErr()
-				t.Enable()
-				t.txtInput.Focus()
-				t.txtInput.SetValue("add \"")
+				t.OpenCommand("add \"")
+				return t, cmd
+			}
+		case "f":
+			if !enabled {
+				t.OpenCommand("list ")
 				return t, cmd
 			}
 		case "/":
@@ -129,6 +165,24 @@ func (t *TextInput) Update(msg tea.Msg) (*TextInput, tea.Cmd) {
 			t.err = &err
 			t.enabled = false
 			return t, cmd
+		case events.EventExplainProgram:
+			var explanation = events.DecodeExplainProgramEvent(msg).Explanation
+			t.explanation = &explanation
+			t.enabled = false
+			return t, cmd
+		case events.EventListTagsResponse:
+			var tags bytes.Buffer
+			var err = output.Write(&tags, output.FormatTable, output.Tags(events.DecodeListTagsResponseEvent(msg).Tags))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to render the tags")
+			}
+			var text = strings.TrimRight(tags.String(), "\n")
+			t.explanation = &text
+			t.enabled = false
+			return t, cmd
+		case events.EventAutocompleteResponse:
+			t.openCompletions(events.DecodeAutocompleteResponseEvent(msg))
+			return t, cmd
 		case events.EventRunProgram:
 			return t, cmd
 		}
@@ -139,10 +193,6 @@ func (t *TextInput) Update(msg tea.Msg) (*TextInput, tea.Cmd) {
 	case tea.KeyMsg:
 		if enabled {
 			t.txtInput, cmd = t.txtInput.Update(msg)
-			if t.inputMode == InputModeSearch {
-				log.Info().Interface("msg", msg).Msg("TextInput Update")
-				t.submitSearch()
-			}
 		}
 	}
 
@@ -151,8 +201,15 @@ func (t *TextInput) Update(msg tea.Msg) (*TextInput, tea.Cmd) {
 
 func (t *TextInput) View() string {
 	if t.err != nil && !t.enabled {
+		var diagnostics *manager.Diagnostics
+		if errors.As(*t.err, &diagnostics) {
+			return t.viewDiagnostics(diagnostics)
+		}
 		return fmt.Sprintf("Error: %s\n", (*t.err).Error())
 	}
+	if t.explanation != nil && !t.enabled {
+		return *t.explanation + "\n"
+	}
 	if !t.enabled {
 		return ""
 	}
@@ -160,7 +217,7 @@ func (t *TextInput) View() string {
 		return fmt.Sprintf(
 			"Enter a command:\n\n%s\n",
 			t.txtInput.View(),
-		) + "\n"
+		) + t.viewCompletions() + "\n"
 	}
 	if t.inputMode == InputModeSearch {
 		return fmt.Sprintf(
@@ -174,6 +231,103 @@ func (t *TextInput) View() string {
 	) + "\n"
 }
 
+// viewDiagnostics renders the input with the offending range of each diagnostic underlined,
+// fatal errors are underlined in the danger colour and warnings in the warning colour
+func (t *TextInput) viewDiagnostics(diagnostics *manager.Diagnostics) string {
+	var builder strings.Builder
+	if diagnostics.HasFatal() {
+		builder.WriteString("Error:\n")
+	} else {
+		builder.WriteString("Warning:\n")
+	}
+	builder.WriteString(diagnostics.Input)
+	builder.WriteString("\n")
+	for i := range diagnostics.Items {
+		var item = &diagnostics.Items[i]
+		var colour = t.theme.WarningColor
+		if item.IsFatal() {
+			colour = t.theme.DangerColor
+		}
+		var style = lipgloss.NewStyle().Foreground(colour)
+		var start, end = diagnostics.Underline(item)
+		builder.WriteString(strings.Repeat(" ", start))
+		builder.WriteString(style.Render(strings.Repeat("^", end-start)))
+		builder.WriteString("\n")
+		builder.WriteString(style.Render(item.Summary()))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// cycleCompletion selects the next (1) or previous (-1) completion, the completions
+// are requested from the bus when the popup is closed
+func (t *TextInput) cycleCompletion(direction int) {
+	if t.completions == nil {
+		var value = t.txtInput.Value()
+		var cursor = len(string([]rune(value)[:t.txtInput.Position()]))
+		t.bus.Publish(events.NewAutocompleteEvent(value, cursor))
+		return
+	}
+	var count = len(t.completions.Items)
+	t.completionIndex = (t.completionIndex + direction + count) % count
+	t.applyCompletion()
+}
+
+// openCompletions shows the popup and inserts the first completion, a single
+// completion is inserted without opening the popup
+func (t *TextInput) openCompletions(response *events.AutocompleteResponse) {
+	if !t.enabled || response.Input != t.txtInput.Value() || len(response.Items) == 0 {
+		return
+	}
+	t.completions = response
+	t.completionIndex = 0
+	t.applyCompletion()
+	if len(response.Items) == 1 {
+		t.closeCompletions()
+	}
+}
+
+func (t *TextInput) applyCompletion() {
+	var value, cursor = t.completions.Apply(t.completionIndex)
+	t.txtInput.SetValue(value)
+	t.txtInput.SetCursor(len([]rune(value[:cursor])))
+}
+
+func (t *TextInput) closeCompletions() {
+	t.completions = nil
+	t.completionIndex = 0
+}
+
+// viewCompletions renders the popup below the input, the selected completion is highlighted
+func (t *TextInput) viewCompletions() string {
+	if t.completions == nil {
+		return ""
+	}
+	var items = t.completions.Items
+	var first = max(0, min(t.completionIndex-COMPLETION_POPUP_SIZE/2, len(items)-COMPLETION_POPUP_SIZE))
+	var last = min(len(items), first+COMPLETION_POPUP_SIZE)
+
+	var width = 0
+	for _, item := range items[first:last] {
+		width = max(width, len(item.Text))
+	}
+	var selected = lipgloss.NewStyle().
+		Background(t.theme.PrimaryColor).
+		Foreground(t.theme.ForgroundColor)
+	var lines = make([]string, 0, last-first)
+	for i := first; i < last; i++ {
+		var line = strings.TrimRight(fmt.Sprintf("%-*s  %s", width, items[i].Text, items[i].Description), " ")
+		if i == t.completionIndex {
+			line = selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.theme.SecondaryColor).
+		Render(strings.Join(lines, "\n")) + "\n"
+}
+
 func (t *TextInput) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -187,7 +341,7 @@ func (t *TextInput) handleSubmit() {
 		t.submitProgram()
 		t.txtInput.SetValue("")
 	case InputModeSearch:
-		t.submitSearch()
+		t.submitFullTextSearch()
 		t.txtInput.SetValue("")
 	default:
 		log.Error().Str("inputMode", fmt.Sprintf("%d", t.inputMode)).Msg("Unknown input mode")
@@ -204,16 +358,23 @@ func (t *TextInput) submitProgram() {
 	t.txtInput.SetValue("")
 }
 
-func (t *TextInput) submitSearch() {
-	log.Info().Str("search", t.txtInput.Value()).Msg("Submitting search")
+// submitFullTextSearch searches every task e.g search "old ticket", nothing is searched
+// while typing so the table only changes once. An empty search lists the tasks again
+func (t *TextInput) submitFullTextSearch() {
+	var text = strings.ReplaceAll(t.txtInput.Value(), `"`, " ")
+	log.Info().Str("search", text).Msg("Submitting full text search")
 	t.err = nil
-	var search = t.txtInput.Value()
-	t.bus.Publish(events.NewTableFuzzySearch(search))
-	t.bus.Publish(events.NewListTasksEvent())
+	if strings.TrimSpace(text) == "" {
+		t.bus.Publish(events.NewListTasksEvent())
+		return
+	}
+	t.bus.Publish(events.NewRunProgramEvent(fmt.Sprintf(`search "%s"`, text)))
 }
 
+// ClearErr hides the error and the explanation of the last program
 func (t *TextInput) ClearErr() {
 	t.err = nil
+	t.explanation = nil
 }
 
 type InputHistoryRingBuffer struct {
@@ -0,0 +1,109 @@
+package components
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/luke-goddard/taskninja/bus"
+	"github.com/luke-goddard/taskninja/bus/handler"
+	"github.com/luke-goddard/taskninja/events"
+	"github.com/luke-goddard/taskninja/tui/utils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// inputSubscriber forwards the events to the input like the tui model does
+type inputSubscriber struct{ input **TextInput }
+
+func (s inputSubscriber) Notify(e *events.Event) { (*s.input).Update(e) }
+
+var _ = Describe("Text Input", func() {
+	var input *TextInput
+	var bus_ *bus.Bus
+
+	var press = func(keyType tea.KeyType) {
+		input.Update(tea.KeyMsg{Type: keyType})
+	}
+
+	BeforeEach(func() {
+		var service = newTestHandler()
+		bus_ = bus.NewBus()
+		bus_.Subscribe(handler.NewEventHandler(service, bus_))
+		bus_.Publish(events.NewRunProgramEvent(`add "milk" +home +health +work`))
+		input = NewTextInput(
+			&utils.TerminalDimensions{Width: 100, Height: 100},
+			utils.NewTheme(),
+			bus_,
+		)
+		bus_.Subscribe(inputSubscriber{input: &input})
+	})
+
+	Describe("When pressing tab", func() {
+		BeforeEach(func() {
+			input.OpenCommand(`add "bread" +h`)
+		})
+
+		It("should insert the first completion", func() {
+			press(tea.KeyTab)
+			Expect(input.txtInput.Value()).To(Equal(`add "bread" +health`))
+			Expect(input.View()).To(ContainSubstring("+home"))
+		})
+
+		It("should cycle forwards and backwards", func() {
+			press(tea.KeyTab)
+			press(tea.KeyTab)
+			Expect(input.txtInput.Value()).To(Equal(`add "bread" +home`))
+			press(tea.KeyTab)
+			Expect(input.txtInput.Value()).To(Equal(`add "bread" +health`))
+			press(tea.KeyShiftTab)
+			Expect(input.txtInput.Value()).To(Equal(`add "bread" +home`))
+		})
+
+		It("should close the popup when typing", func() {
+			press(tea.KeyTab)
+			input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
+			Expect(input.completions).To(BeNil())
+			Expect(input.View()).NotTo(ContainSubstring("+home"))
+		})
+	})
+
+	It("should insert a single completion without opening the popup", func() {
+		input.OpenCommand(`add "bread" +w`)
+		press(tea.KeyTab)
+		Expect(input.txtInput.Value()).To(Equal(`add "bread" +work`))
+		Expect(input.completions).To(BeNil())
+	})
+
+	It("should not reload the tasks while the search is typed", func() {
+		var sub = &SubscriberMock{}
+		bus_.Subscribe(sub)
+		input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+		input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(`o`)})
+		input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(`l`)})
+		Expect(input.txtInput.Value()).To(Equal("ol"))
+		Expect(sub.events).To(BeEmpty())
+	})
+
+	It("should search every task when the search is submitted", func() {
+		var sub = &SubscriberMock{}
+		bus_.Subscribe(sub)
+		input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+		input.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(`old "ticket`)})
+		press(tea.KeyEnter)
+		var event = sub.GetEventOfType(events.EventRunProgram)
+		Expect(event).NotTo(BeNil())
+		Expect(events.DecodeRunProgramEvent(event).Program).To(Equal(`search "old  ticket"`))
+	})
+
+	It("should show the tags with their task count", func() {
+		bus_.Publish(events.NewRunProgramEvent(`add "bread" +home`))
+		bus_.Publish(events.NewRunProgramEvent(`tags`))
+		Expect(input.View()).To(MatchRegexp(`Tag\s+Tasks`))
+		Expect(input.View()).To(MatchRegexp(`home\s+2`))
+		Expect(input.View()).To(MatchRegexp(`work\s+1`))
+	})
+
+	It("should complete the task id with its title", func() {
+		input.OpenCommand(`done `)
+		press(tea.KeyTab)
+		Expect(input.txtInput.Value()).To(Equal(`done 1`))
+	})
+})
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -21,8 +22,10 @@ type model struct {
 	tabs       *components.Tabs
 	bus        *bus.Bus
 	table      *components.TaskTable
+	reports    *components.Reports
 	input      *components.TextInput
 	doughnut   *components.Doughnut
+	details    *components.TaskDetails
 	dimensions *utils.TerminalDimensions
 	activeTab  int
 }
@@ -36,6 +39,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.input.CanQuit() {
 				return m, tea.Quit
 			}
+		case "e":
+			var id = m.table.GetIdForCurrentRow()
+			if m.input.Disabled() && id != components.NOID {
+				m.input.OpenCommand(fmt.Sprintf("modify %d ", id))
+				return m, nil
+			}
+		case "A":
+			var id = m.table.GetIdForCurrentRow()
+			if m.input.Disabled() && id != components.NOID {
+				m.input.OpenCommand(fmt.Sprintf(`annotate %d "`, id))
+				return m, nil
+			}
+		case "i":
+			if !m.input.Disabled() {
+				break
+			}
+			if m.details.Visible() {
+				m.details.Hide()
+			} else if id := m.table.GetIdForCurrentRow(); id != components.NOID {
+				m.details.Show(id)
+			}
+			return m, nil
 		}
 	case *events.Event:
 		var newTable, _ = m.table.Update(msg)
@@ -43,6 +68,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		var newTabs, _ = m.tabs.Update(msg)
 		m.tabs = newTabs
+
+		var newReports, _ = m.reports.Update(msg)
+		m.reports = newReports
+
+		var newDetails, _ = m.details.Update(msg)
+		m.details = newDetails
 	}
 
 	if m.input.Disabled() {
@@ -51,6 +82,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		var newTabs, _ = m.tabs.Update(msg)
 		m.tabs = newTabs
+
+		if _, isKey := msg.(tea.KeyMsg); isKey {
+			var newReports, _ = m.reports.Update(msg)
+			m.reports = newReports
+		}
 	}
 
 	var newInput *components.TextInput
@@ -71,8 +107,12 @@ func (m model) View() string {
 		document.WriteString("\n")
 		document.WriteString(m.doughnut.View() + "\n")
 	} else {
+		document.WriteString(m.reports.View() + "\n")
 		document.WriteString(m.table.View() + "\n")
 		document.WriteString(m.table.HelpView() + "\n")
+		if m.details.Visible() {
+			document.WriteString(baseStyle.Render(m.details.View()) + "\n")
+		}
 		document.WriteString(m.input.View() + "\n")
 	}
 	return document.String()
@@ -92,8 +132,10 @@ func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		m.table.Init(),
 		m.tabs.Init(),
+		m.reports.Init(),
 		m.input.Init(),
 		m.doughnut.Init(),
+		m.details.Init(),
 	)
 }
 
@@ -108,7 +150,8 @@ func (m model) RefreshTaskListProgramatically() {
 	}
 }
 
-func NewTui(bus *bus.Bus) (*tea.Program, error) {
+// NewTui creates the program, reports are the names of the reports that can be switched between
+func NewTui(bus *bus.Bus, reports []string) (*tea.Program, error) {
 
 	var dimensions, err = utils.NewTerminalDimensions()
 	if err != nil {
@@ -120,9 +163,11 @@ func NewTui(bus *bus.Bus) (*tea.Program, error) {
 	var tabs = components.NewTabs()
 	var model = model{
 		bus:        bus,
-		input:      components.NewTextInput(dimensions, bus),
+		input:      components.NewTextInput(dimensions, theme, bus),
 		table:      components.NewTaskTable(baseStyle, dimensions, theme, bus),
+		reports:    components.NewReports(reports, bus),
 		doughnut:   components.NewDonut(dimensions),
+		details:    components.NewTaskDetails(bus),
 		tabs:       tabs,
 		dimensions: dimensions,
 	}